@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/player"
+	"karolbroda.com/lyrecho/internal/spotify"
+)
+
+// newPlayerService creates the configured player backend. the returned
+// function releases whatever connection the backend holds.
+func newPlayerService(cfg *config.Config) (player.Service, func(), error) {
+	backend := strings.ToLower(cfg.Backend)
+	if backend == "" || backend == "auto" {
+		backend = autoBackend()
+	}
+
+	switch backend {
+	case "mpris":
+		bus, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to session bus: %w", err)
+		}
+
+		mpris, err := player.NewMPRIS(bus, cfg.MprisService)
+		if err != nil {
+			bus.Close()
+			return nil, nil, fmt.Errorf("failed to create player service: %w", err)
+		}
+		// a priority list only makes sense when switching between players
+		mpris.SetFollow(cfg.Follow || len(cfg.Players) > 0)
+		mpris.SetPriority(cfg.Players)
+		mpris.SetIgnore(cfg.IgnorePlayers)
+		mpris.SetDialer(func() (*dbus.Conn, error) {
+			return dbus.ConnectSessionBus()
+		})
+
+		return mpris, func() { bus.Close() }, nil
+
+	case "smtc":
+		smtc, err := player.NewSMTC()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create player service: %w", err)
+		}
+		return smtc, func() {}, nil
+
+	case "mpd":
+		mpd, err := player.NewMPD(cfg.MPDHost, cfg.MPDPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create player service: %w", err)
+		}
+		return mpd, func() {}, nil
+
+	case "spotify":
+		web, err := player.NewSpotifyWeb()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create player service: %w", err)
+		}
+		return web, func() {}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown backend %q (use auto, mpris, smtc, mpd or spotify)", cfg.Backend)
+	}
+}
+
+// autoBackend picks the platform's local backend. on linux it falls back
+// to the spotify web api when no mpris player is running but a login exists.
+func autoBackend() string {
+	if runtime.GOOS == "windows" {
+		return "smtc"
+	}
+
+	if _, err := spotify.LoadToken(); err == nil && !hasMPRISPlayers() {
+		return "spotify"
+	}
+
+	return "mpris"
+}
+
+func hasMPRISPlayers() bool {
+	bus, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return false
+	}
+	defer bus.Close()
+
+	players, err := player.ListPlayers(bus)
+	return err == nil && len(players) > 0
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/ui"
+)
+
+var (
+	// flags for bench
+	benchDuration time.Duration
+	benchSize     string
+	benchScenes   []string
+	benchTheme    string
+	benchJSON     bool
+)
+
+// benchOutput is a scene as printed by bench --json
+type benchOutput struct {
+	Scene          string  `json:"scene"`
+	Frames         int     `json:"frames"`
+	FPS            float64 `json:"fps"`
+	FrameMicros    int64   `json:"frame_us"`
+	AllocsPerFrame float64 `json:"allocs_per_frame"`
+	BytesPerFrame  float64 `json:"bytes_per_frame"`
+	FrameSize      int     `json:"frame_size"`
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "measure how fast frames render",
+	Long: fmt.Sprintf(`render representative frames off screen and report frames per second and
+allocations per frame, to catch rendering getting slower. nothing is drawn
+and no player is needed. colors are rendered as true color whatever the
+terminal supports, so runs compare.
+
+scenes:`+"\n%s", benchSceneList()),
+	Example: `  lyrecho bench
+  lyrecho bench --scene side,backdrop --duration 5s --size 200x60
+  lyrecho bench --animation none --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		width, height, err := parseSize(benchSize)
+		if err != nil {
+			return err
+		}
+
+		palette, err := artwork.ThemePalette(benchTheme)
+		if err != nil {
+			return err
+		}
+
+		cfg := config.Load()
+		animation, err := loadAnimation(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		lipgloss.SetColorProfile(termenv.TrueColor)
+
+		results, err := ui.Bench(ui.BenchConfig{
+			Palette:   palette,
+			Animation: animation,
+			Width:     width,
+			Height:    height,
+			Duration:  benchDuration,
+			Scenes:    benchScenes,
+		})
+		if err != nil {
+			return err
+		}
+
+		if benchJSON {
+			out := make([]benchOutput, len(results))
+			for i, result := range results {
+				out[i] = benchOutput{
+					Scene:          result.Scene,
+					Frames:         result.Frames,
+					FPS:            result.FPS(),
+					FrameMicros:    result.FrameTime().Microseconds(),
+					AllocsPerFrame: result.AllocsPerFrame(),
+					BytesPerFrame:  result.BytesPerFrame(),
+					FrameSize:      result.FrameSize,
+				}
+			}
+			return printJSON(out)
+		}
+
+		fmt.Printf("%dx%d, %s per scene, animation %s\n\n", width, height, benchDuration, cfg.Animation)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "SCENE\tFRAMES\tFPS\tFRAME\tALLOCS/FRAME\tBYTES/FRAME\tOUTPUT\t")
+		for _, result := range results {
+			fmt.Fprintf(w, "%s\t%d\t%.0f\t%s\t%.0f\t%s\t%s\t\n",
+				result.Scene,
+				result.Frames,
+				result.FPS(),
+				result.FrameTime().Round(time.Microsecond),
+				result.AllocsPerFrame(),
+				formatBytes(int64(result.BytesPerFrame())),
+				formatBytes(int64(result.FrameSize)),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().DurationVar(&benchDuration, "duration", 2*time.Second, "how long to render each scene")
+	benchCmd.Flags().StringVar(&benchSize, "size", "120x40", "terminal size to render at, columns x rows")
+	benchCmd.Flags().StringSliceVar(&benchScenes, "scene", nil, "scenes to run, all when empty: "+strings.Join(ui.BenchSceneNames(), ", "))
+	benchCmd.Flags().StringVar(&benchTheme, "theme", "default", "theme to render with")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "output as json")
+
+	_ = benchCmd.RegisterFlagCompletionFunc("scene", cobra.FixedCompletions(ui.BenchSceneNames(), cobra.ShellCompDirectiveNoFileComp))
+	_ = benchCmd.RegisterFlagCompletionFunc("theme", cobra.FixedCompletions(artwork.ThemeNames(), cobra.ShellCompDirectiveNoFileComp))
+}
+
+// helper functions
+
+func benchSceneList() string {
+	var b strings.Builder
+	for _, scene := range ui.BenchScenes {
+		fmt.Fprintf(&b, "  %-10s %s\n", scene.Name, scene.Description)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// parseSize reads a size given as columns x rows, e.g. 120x40
+func parseSize(value string) (int, int, error) {
+	var width, height int
+	_, err := fmt.Sscanf(strings.ToLower(value), "%dx%d", &width, &height)
+	if err != nil || width < 20 || height < 5 {
+		return 0, 0, fmt.Errorf("invalid size %q, expected columns x rows like 120x40 (at least 20x5)", value)
+	}
+	return width, height, nil
+}
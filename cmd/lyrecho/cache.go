@@ -1,22 +1,44 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
 
 	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/lyrics"
 )
 
 var (
 	// flags for cache list
 	cacheSortBy string
 	cacheConfirm bool
+
+	// flags for cache list and show
+	cacheJSON bool
+
+	// flags for cache stats
+	statsTop int
+
+	// flags for cache verify
+	verifyRefetch bool
+
+	// flags for cache search
+	searchLimit int
+
+	// flags for cache dump
+	dumpOutput string
 )
 
 var cacheCmd = &cobra.Command{
@@ -28,7 +50,9 @@ var cacheCmd = &cobra.Command{
 var cacheStatsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "show cache statistics",
-	Long:  `display cache statistics including number of entries, total size, and cache location.`,
+	Long: `display cache statistics including number of entries, total size, and cache location,
+with a breakdown of synced and plain-only lyrics, lyric lines, the oldest and
+newest entries, and the artists with the most cached songs.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		diskCache := cache.GetGlobalCache()
 
@@ -45,6 +69,43 @@ var cacheStatsCmd = &cobra.Command{
 		fmt.Printf("  entries:  %d\n", count)
 		fmt.Printf("  size:     %s\n", formatBytes(sizeBytes))
 
+		entries, err := getAllCacheEntries(diskCache)
+		if err != nil {
+			return fmt.Errorf("failed to list cache: %w", err)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		coverage := summarizeCache(entries)
+		fmt.Println()
+		fmt.Println("lyrics:")
+		fmt.Printf("  synced:       %d\n", coverage.synced)
+		fmt.Printf("  plain only:   %d\n", coverage.plain)
+		fmt.Printf("  instrumental: %d\n", coverage.instrumental)
+		fmt.Printf("  none:         %d\n", coverage.empty)
+		fmt.Printf("  lines:        %d\n", coverage.lines)
+		fmt.Printf("  oldest:       %s - %s (%s)\n", coverage.oldest.ArtistName, coverage.oldest.TrackName,
+			time.Unix(coverage.oldest.CreatedAt, 0).Format("2006-01-02"))
+		fmt.Printf("  newest:       %s - %s (%s)\n", coverage.newest.ArtistName, coverage.newest.TrackName,
+			time.Unix(coverage.newest.CreatedAt, 0).Format("2006-01-02"))
+
+		artists := coverage.artists
+		if statsTop > 0 && len(artists) > statsTop {
+			artists = artists[:statsTop]
+		}
+
+		fmt.Printf("\nartists: %d\n", len(coverage.artists))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  ARTIST\tSONGS\tALBUMS\tSYNCED")
+		for _, artist := range artists {
+			fmt.Fprintf(w, "  %s\t%d\t%d\t%d\n", artist.name, artist.songs, len(artist.albums), artist.synced)
+		}
+		w.Flush()
+		if len(artists) < len(coverage.artists) {
+			fmt.Printf("  … %d more (use --top 0 to show all)\n", len(coverage.artists)-len(artists))
+		}
+
 		return nil
 	},
 }
@@ -61,7 +122,7 @@ var cacheListCmd = &cobra.Command{
 			return fmt.Errorf("failed to list cache: %w", err)
 		}
 
-		if len(entries) == 0 {
+		if len(entries) == 0 && !cacheJSON {
 			fmt.Println("cache is empty")
 			return nil
 		}
@@ -69,6 +130,14 @@ var cacheListCmd = &cobra.Command{
 		// sort entries
 		sortCacheEntries(entries, cacheSortBy)
 
+		if cacheJSON {
+			out := make([]cacheEntryJSON, 0, len(entries))
+			for _, entry := range entries {
+				out = append(out, newCacheEntryJSON(entry, false))
+			}
+			return printJSON(out)
+		}
+
 		// display as table
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 		fmt.Fprintln(w, "ARTIST\tTITLE\tSYNC OFFSET\tCACHED")
@@ -91,10 +160,11 @@ var cacheListCmd = &cobra.Command{
 }
 
 var cacheShowCmd = &cobra.Command{
-	Use:   "show <artist> <title>",
-	Short: "show cached entry for specific song",
-	Long:  `display detailed information about a cached song including lyrics and sync offset.`,
-	Args:  cobra.ExactArgs(2),
+	Use:               "show <artist> <title>",
+	Short:             "show cached entry for specific song",
+	Long:              `display detailed information about a cached song including lyrics and sync offset.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		artist := args[0]
 		title := args[1]
@@ -114,6 +184,10 @@ var cacheShowCmd = &cobra.Command{
 			return fmt.Errorf("song not found in cache: %w", err)
 		}
 
+		if cacheJSON {
+			return printJSON(newCacheEntryJSON(entry, true))
+		}
+
 		fmt.Printf("artist:       %s\n", entry.ArtistName)
 		fmt.Printf("title:        %s\n", entry.TrackName)
 		fmt.Printf("album:        %s\n", entry.AlbumName)
@@ -167,7 +241,8 @@ var cacheClearCmd = &cobra.Command{
 var cachePruneCmd = &cobra.Command{
 	Use:   "prune",
 	Short: "remove expired cache entries",
-	Long:  `remove all expired cache entries to free up disk space.`,
+	Long: `remove all expired cache entries, and files that don't decode, to free up
+disk space. entries from another version of lyrecho are kept for cache migrate.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		diskCache := cache.GetGlobalCache()
 
@@ -182,10 +257,11 @@ var cachePruneCmd = &cobra.Command{
 }
 
 var cacheDeleteCmd = &cobra.Command{
-	Use:   "delete <artist> <title>",
-	Short: "remove specific song from cache",
-	Long:  `remove a specific song from the cache by artist and title.`,
-	Args:  cobra.ExactArgs(2),
+	Use:               "delete <artist> <title>",
+	Short:             "remove specific song from cache",
+	Long:              `remove a specific song from the cache by artist and title.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		artist := args[0]
 		title := args[1]
@@ -218,6 +294,194 @@ var cacheDeleteCmd = &cobra.Command{
 	},
 }
 
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "check every cache file",
+	Long: `decode every cached entry and report files that are corrupt or were written
+by another cache version, without removing them.
+
+use --refetch to fetch damaged entries again, keeping their sync offset.
+this needs the song's names, so corrupt files that don't decode at all can
+only be removed with "lyrecho cache prune".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		diskCache := cache.GetGlobalCache()
+
+		result, err := diskCache.Verify()
+		if err != nil {
+			return fmt.Errorf("failed to verify cache: %w", err)
+		}
+
+		cfg := config.Load()
+		if lrclibURL != "" {
+			cfg.LrclibURL = lrclibURL
+		}
+
+		corrupt, outdated, refetched := 0, 0, 0
+		for _, problem := range result.Problems {
+			name := filepath.Base(problem.Path)
+			if errors.Is(problem.Err, cache.ErrCacheVersion) {
+				outdated++
+				fmt.Printf("outdated: %s (%s - %s, version %d)\n", name,
+					problem.Entry.ArtistName, problem.Entry.TrackName, problem.Entry.Version)
+			} else {
+				corrupt++
+				fmt.Printf("corrupt:  %s\n", name)
+			}
+
+			if !verifyRefetch || problem.Entry == nil {
+				continue
+			}
+			err = refetchDamaged(cfg.LrclibURL, problem)
+			if err != nil {
+				fmt.Printf("  refetch failed: %v\n", err)
+				continue
+			}
+			refetched++
+			fmt.Println("  refetched")
+		}
+
+		fmt.Println()
+		fmt.Println("cache verification:")
+		fmt.Printf("  checked:   %d\n", result.Checked)
+		fmt.Printf("  ok:        %d\n", result.Checked-len(result.Problems))
+		fmt.Printf("  expired:   %d\n", result.Expired)
+		fmt.Printf("  corrupt:   %d\n", corrupt)
+		fmt.Printf("  outdated:  %d\n", outdated)
+		if verifyRefetch {
+			fmt.Printf("  refetched: %d\n", refetched)
+		}
+
+		if outdated > 0 && !verifyRefetch {
+			fmt.Println("\nuse \"lyrecho cache migrate\" to upgrade outdated entries")
+		}
+		if len(result.Problems) > refetched {
+			fmt.Println("\nuse --refetch to fetch damaged entries again, or \"lyrecho cache prune\" to remove corrupt ones")
+		}
+
+		return nil
+	},
+}
+
+var cacheSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "search cached songs",
+	Long: `search cached songs by artist, title and lyrics. every word of the query has
+to match, loosely: letters may be skipped, so "hottogo" finds "HOT TO GO!".
+matches in the artist and title rank above matches in the lyrics.`,
+	Example: `  lyrecho cache search chappell hot
+  lyrecho cache search "the words i remember"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := getAllCacheEntries(cache.GetGlobalCache())
+		if err != nil {
+			return fmt.Errorf("failed to list cache: %w", err)
+		}
+
+		results := searchCache(entries, strings.Join(args, " "))
+		if len(results) == 0 {
+			fmt.Println("no cached songs matched")
+			return nil
+		}
+
+		shown := results
+		if searchLimit > 0 && len(shown) > searchLimit {
+			shown = shown[:searchLimit]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ARTIST\tTITLE\tLYRICS")
+		for _, result := range shown {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", result.entry.ArtistName, result.entry.TrackName, result.line)
+		}
+		w.Flush()
+
+		if len(shown) < len(results) {
+			fmt.Printf("\n%d of %d matches (use --limit 0 to show all)\n", len(shown), len(results))
+		}
+
+		return nil
+	},
+}
+
+var cacheDumpCmd = &cobra.Command{
+	Use:   "dump <artist> <title>",
+	Short: "write cached lyrics as an lrc file",
+	Long: `write the cached synced lyrics of a song as a standard lrc file, for use in
+other players. the tuned sync offset is applied to the timestamps.
+
+writes to stdout unless -o is given.`,
+	Example:           `  lyrecho cache dump "Chappell Roan" "HOT TO GO!" -o hot-to-go.lrc`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		artist := args[0]
+		title := args[1]
+
+		entry, err := cache.GetGlobalCache().Get(artist, title)
+		if err != nil {
+			return fmt.Errorf("song not found in cache: %w", err)
+		}
+		if entry.SyncedLyrics == "" {
+			return fmt.Errorf("no synced lyrics cached for %s - %s", artist, title)
+		}
+
+		lines := lyrics.Shift(lyrics.ParseSynced(entry.SyncedLyrics), entry.SyncOffset)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "[ar:%s]\n", entry.ArtistName)
+		fmt.Fprintf(&b, "[ti:%s]\n", entry.TrackName)
+		if entry.AlbumName != "" {
+			fmt.Fprintf(&b, "[al:%s]\n", entry.AlbumName)
+		}
+		if entry.Duration > 0 {
+			length := int(entry.Duration)
+			fmt.Fprintf(&b, "[length:%02d:%02d]\n", length/60, length%60)
+		}
+		b.WriteString(lyrics.FormatLRC(lines))
+
+		if dumpOutput == "" || dumpOutput == "-" {
+			_, err = os.Stdout.WriteString(b.String())
+			return err
+		}
+
+		err = os.WriteFile(dumpOutput, []byte(b.String()), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write lrc: %w", err)
+		}
+
+		fmt.Printf("wrote %d lines to %s\n", len(lines), dumpOutput)
+		if entry.SyncOffset != 0 {
+			fmt.Printf("sync offset %+.2fs applied\n", entry.SyncOffset)
+		}
+		return nil
+	},
+}
+
+var cacheMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "upgrade entries from older versions",
+	Long: `upgrade cache entries written by an older version of lyrecho to the current
+format, keeping their lyrics and sync offsets. entries are also upgraded when
+they are read, this does all of them at once.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result, err := cache.GetGlobalCache().Migrate()
+		if err != nil {
+			return fmt.Errorf("failed to migrate cache: %w", err)
+		}
+
+		for _, problem := range result.Failed {
+			fmt.Printf("failed: %s: %v\n", filepath.Base(problem.Path), problem.Err)
+		}
+
+		fmt.Printf("migrated %d entries, %d already up to date\n", result.Migrated, result.Current)
+		if len(result.Failed) > 0 {
+			fmt.Printf("%d entries could not be migrated and were kept\n", len(result.Failed))
+		}
+
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(cacheCmd)
 
@@ -227,9 +491,29 @@ func init() {
 	cacheCmd.AddCommand(cacheClearCmd)
 	cacheCmd.AddCommand(cachePruneCmd)
 	cacheCmd.AddCommand(cacheDeleteCmd)
+	cacheCmd.AddCommand(cacheVerifyCmd)
+	cacheCmd.AddCommand(cacheMigrateCmd)
+	cacheCmd.AddCommand(cacheSearchCmd)
+	cacheCmd.AddCommand(cacheDumpCmd)
+
+	// flags for cache stats
+	cacheStatsCmd.Flags().IntVar(&statsTop, "top", 10, "artists to list, 0 for all")
+
+	// flags for cache verify
+	cacheVerifyCmd.Flags().BoolVar(&verifyRefetch, "refetch", false, "fetch damaged entries again when their names can be read")
+
+	// flags for cache dump
+	cacheDumpCmd.Flags().StringVarP(&dumpOutput, "output", "o", "", "lrc file to write, stdout when empty")
+
+	// flags for cache search
+	cacheSearchCmd.Flags().IntVar(&searchLimit, "limit", 20, "matches to show, 0 for all")
 
 	// flags for cache list
 	cacheListCmd.Flags().StringVar(&cacheSortBy, "sort", "date", "sort by: date, artist, title")
+	cacheListCmd.Flags().BoolVar(&cacheJSON, "json", false, "print the entries as json")
+
+	// flags for cache show
+	cacheShowCmd.Flags().BoolVar(&cacheJSON, "json", false, "print the entry as json, lyrics included")
 
 	// flags for cache clear
 	cacheClearCmd.Flags().BoolVar(&cacheConfirm, "confirm", false, "skip confirmation prompt")
@@ -262,6 +546,269 @@ func getCacheDir() string {
 	return home + "/.cache/lyric-shower/lyrics"
 }
 
+// cacheEntryJSON is a cached entry as printed by --json. the lyrics are only
+// included for a single entry, lists carry line counts instead.
+type cacheEntryJSON struct {
+	Artist       string  `json:"artist"`
+	Title        string  `json:"title"`
+	Album        string  `json:"album,omitempty"`
+	Duration     float64 `json:"duration,omitempty"`
+	Instrumental bool    `json:"instrumental"`
+	SyncOffset   float64 `json:"sync_offset"`
+	Translation  string  `json:"translation,omitempty"`
+	CachedAt     string  `json:"cached_at"`
+	ExpiresAt    string  `json:"expires_at"`
+	SyncedLines  int     `json:"synced_lines"`
+	PlainLines   int     `json:"plain_lines"`
+	SyncedLyrics string  `json:"synced_lyrics,omitempty"`
+	PlainLyrics  string  `json:"plain_lyrics,omitempty"`
+}
+
+func newCacheEntryJSON(entry *cache.LyricEntry, withLyrics bool) cacheEntryJSON {
+	out := cacheEntryJSON{
+		Artist:       entry.ArtistName,
+		Title:        entry.TrackName,
+		Album:        entry.AlbumName,
+		Duration:     entry.Duration,
+		Instrumental: entry.Instrumental,
+		SyncOffset:   entry.SyncOffset,
+		Translation:  entry.Translation,
+		CachedAt:     time.Unix(entry.CreatedAt, 0).UTC().Format(time.RFC3339),
+		ExpiresAt:    time.Unix(entry.ExpiresAt, 0).UTC().Format(time.RFC3339),
+		SyncedLines:  countLyricLines(entry.SyncedLyrics),
+		PlainLines:   countLyricLines(entry.PlainLyrics),
+	}
+	if withLyrics {
+		out.SyncedLyrics = entry.SyncedLyrics
+		out.PlainLyrics = entry.PlainLyrics
+	}
+	return out
+}
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// refetchDamaged replaces a damaged cache file with freshly fetched lyrics
+// and carries its sync offset over
+func refetchDamaged(lrclibURL string, problem cache.FileProblem) error {
+	entry := problem.Entry
+	if entry.ArtistName == "" || entry.TrackName == "" {
+		return errors.New("names could not be read")
+	}
+
+	err := os.Remove(problem.Path)
+	if err != nil {
+		return err
+	}
+
+	_, err = lyrics.Fetch(context.Background(), lrclibURL, &lyrics.TrackParams{
+		Title:        entry.TrackName,
+		Artist:       entry.ArtistName,
+		Album:        entry.AlbumName,
+		DurationSecs: int64(entry.Duration),
+		SkipCache:    true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if entry.SyncOffset != 0 {
+		filter := cache.EntryFilter{Artist: entry.ArtistName, Title: entry.TrackName}
+		_, err = cache.GetGlobalCache().SetOffsets(filter, entry.SyncOffset)
+	}
+	return err
+}
+
+// searchResult is a cached song matching a search, line is the lyric line
+// that matched, empty when the names did
+type searchResult struct {
+	entry *cache.LyricEntry
+	score int
+	line  string
+}
+
+// how well a query word matches a field, higher is better
+const (
+	matchNone = iota
+	matchLyrics
+	matchFuzzy
+	matchSubstring
+	matchWord
+)
+
+// searchCache ranks the entries matching every word of the query
+func searchCache(entries []*cache.LyricEntry, query string) []searchResult {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil
+	}
+
+	var results []searchResult
+	for _, entry := range entries {
+		names := strings.ToLower(entry.ArtistName + " " + entry.TrackName)
+		var lines []string
+		if entry.SyncedLyrics != "" {
+			for _, line := range lyrics.ParseSynced(entry.SyncedLyrics) {
+				lines = append(lines, line.Text)
+			}
+		} else {
+			lines = lyrics.PlainLines(entry.PlainLyrics)
+		}
+
+		result := searchResult{entry: entry}
+		for _, word := range words {
+			score := matchNameWord(names, word)
+			if score == matchNone {
+				for _, line := range lines {
+					if strings.Contains(strings.ToLower(line), word) {
+						score = matchLyrics
+						if result.line == "" {
+							result.line = line
+						}
+						break
+					}
+				}
+			}
+			if score == matchNone {
+				result.score = matchNone
+				break
+			}
+			result.score += score
+		}
+
+		if result.score > matchNone {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return strings.ToLower(results[i].entry.ArtistName) < strings.ToLower(results[j].entry.ArtistName)
+	})
+
+	return results
+}
+
+// matchNameWord scores a query word against the lowercased artist and title
+func matchNameWord(names string, word string) int {
+	for _, field := range strings.FieldsFunc(names, isWordSeparator) {
+		if field == word {
+			return matchWord
+		}
+	}
+	if strings.Contains(names, word) {
+		return matchSubstring
+	}
+	// short words would skip their way into almost anything
+	if len([]rune(word)) >= 3 && isSubsequence(word, names) {
+		return matchFuzzy
+	}
+	return matchNone
+}
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// isSubsequence reports whether every rune of word appears in s in order
+func isSubsequence(word string, s string) bool {
+	runes := []rune(word)
+	i := 0
+	for _, r := range s {
+		if i < len(runes) && r == runes[i] {
+			i++
+		}
+	}
+	return i == len(runes)
+}
+
+// cacheCoverage is how much of the library the cache covers
+type cacheCoverage struct {
+	synced       int
+	plain        int
+	instrumental int
+	empty        int
+	lines        int
+	oldest       *cache.LyricEntry
+	newest       *cache.LyricEntry
+	// artists are sorted by most cached songs
+	artists []*artistCoverage
+}
+
+type artistCoverage struct {
+	name   string
+	songs  int
+	synced int
+	albums map[string]bool
+}
+
+func summarizeCache(entries []*cache.LyricEntry) cacheCoverage {
+	var coverage cacheCoverage
+	byArtist := make(map[string]*artistCoverage)
+
+	for _, entry := range entries {
+		switch {
+		case entry.SyncedLyrics != "":
+			coverage.synced++
+			coverage.lines += countLyricLines(entry.SyncedLyrics)
+		case entry.PlainLyrics != "":
+			coverage.plain++
+			coverage.lines += countLyricLines(entry.PlainLyrics)
+		case entry.Instrumental:
+			coverage.instrumental++
+		default:
+			coverage.empty++
+		}
+
+		if coverage.oldest == nil || entry.CreatedAt < coverage.oldest.CreatedAt {
+			coverage.oldest = entry
+		}
+		if coverage.newest == nil || entry.CreatedAt > coverage.newest.CreatedAt {
+			coverage.newest = entry
+		}
+
+		key := strings.ToLower(entry.ArtistName)
+		artist, ok := byArtist[key]
+		if !ok {
+			artist = &artistCoverage{name: entry.ArtistName, albums: make(map[string]bool)}
+			byArtist[key] = artist
+			coverage.artists = append(coverage.artists, artist)
+		}
+		artist.songs++
+		if entry.SyncedLyrics != "" {
+			artist.synced++
+		}
+		if entry.AlbumName != "" {
+			artist.albums[strings.ToLower(entry.AlbumName)] = true
+		}
+	}
+
+	sort.Slice(coverage.artists, func(i, j int) bool {
+		a, b := coverage.artists[i], coverage.artists[j]
+		if a.songs != b.songs {
+			return a.songs > b.songs
+		}
+		return strings.ToLower(a.name) < strings.ToLower(b.name)
+	})
+
+	return coverage
+}
+
+func countLyricLines(text string) int {
+	count := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count
+}
+
 func getAllCacheEntries(diskCache *cache.DiskCache) ([]*cache.LyricEntry, error) {
 	entries, err := diskCache.ListAll()
 	if err != nil {
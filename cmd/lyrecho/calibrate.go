@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/calibrate"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/lyrics"
+	"karolbroda.com/lyrecho/internal/player"
+)
+
+// runAutoCalibrate records the audio around the first lyric of the current
+// song, detects where the vocals start and proposes a sync offset
+func runAutoCalibrate(ctx context.Context, playerService player.Service, cfg *config.Config, cachePolicy lyrics.CachePolicy) error {
+	trk, err := playerService.GetCurrentTrack()
+	if err != nil || !trk.IsValid() {
+		return errors.New("no track is playing")
+	}
+
+	fmt.Printf("calibrating %s - %s (experimental)\n", trk.Artist, trk.Title)
+
+	lyricsData, err := lyrics.Fetch(ctx, cfg.LrclibURL, &lyrics.TrackParams{
+		Title:        trk.Title,
+		Artist:       trk.Artist,
+		Album:        trk.Album,
+		DurationSecs: trk.DurationSecs,
+		Cache:        cachePolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch lyrics: %w", err)
+	}
+	if lyricsData.SyncedLyrics == "" {
+		return errors.New("no synced lyrics available")
+	}
+
+	lyricSecs := -1.0
+	for _, line := range lyrics.ParseSynced(lyricsData.SyncedLyrics) {
+		if strings.TrimSpace(line.Text) != "" {
+			lyricSecs = line.TimeSeconds
+			break
+		}
+	}
+	if lyricSecs < 0 {
+		return errors.New("lyrics have no timed lines")
+	}
+
+	startSecs := lyricSecs - calibrate.LeadIn.Seconds()
+	if startSecs < 0 {
+		return fmt.Errorf("first lyric at %s is too early to calibrate against", formatTimestamp(lyricSecs))
+	}
+
+	position := func() (float64, error) {
+		micros, err := playerService.GetPositionMicros()
+		return float64(micros) / 1_000_000, err
+	}
+
+	pos, err := position()
+	if err != nil {
+		return err
+	}
+
+	if pos > startSecs {
+		fmt.Printf("seek to before %s or restart the song to calibrate...\n", formatTimestamp(startSecs))
+		for pos > startSecs-0.5 {
+			err = sleepContext(ctx, 100*time.Millisecond)
+			if err != nil {
+				return err
+			}
+			pos, err = position()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("first lyric at %s, waiting for playback to reach it...\n", formatTimestamp(lyricSecs))
+	for pos < startSecs {
+		err = sleepContext(ctx, 20*time.Millisecond)
+		if err != nil {
+			return err
+		}
+		pos, err = position()
+		if err != nil {
+			return err
+		}
+	}
+
+	captureWall := time.Now()
+	recording, err := calibrate.Capture(ctx, calibrate.LeadIn+calibrate.Tail)
+	if err != nil {
+		return fmt.Errorf("failed to capture audio: %w", err)
+	}
+	captureSecs := pos + recording.Started.Sub(captureWall).Seconds()
+
+	onset, err := calibrate.DetectOnset(recording.Samples, calibrate.SampleRate)
+	if err != nil {
+		return err
+	}
+
+	offset := calibrate.Offset(lyricSecs, captureSecs, onset)
+
+	fmt.Printf("vocals detected at %s\n", formatTimestamp(captureSecs+onset))
+	fmt.Printf("proposed sync offset: %+.2fs (current: %+.2fs)\n", offset, lyricsData.SyncOffset)
+
+	fmt.Print("save this offset for the song? (y/n): ")
+	var response string
+	fmt.Scanln(&response)
+	if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+		fmt.Println("not saved")
+		return nil
+	}
+
+	cached, err := cache.GetGlobalCache().SetOffset(trk.Artist, trk.Title, float64(trk.DurationSecs), offset)
+	if err != nil {
+		return fmt.Errorf("failed to save offset: %w", err)
+	}
+
+	fmt.Println("offset saved")
+	if !cached {
+		fmt.Println("the song isn't cached, the offset applies once its lyrics are fetched again")
+	}
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/cache"
+)
+
+// completeCachedSong completes <artist> <title> arguments from the cache:
+// the cached artists first, then the titles cached for the chosen artist
+func completeCachedSong(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	entries, err := cache.GetGlobalCache().ListAll()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if len(args) == 0 {
+			names = append(names, entry.ArtistName)
+		} else if strings.EqualFold(entry.ArtistName, args[0]) {
+			names = append(names, entry.TrackName)
+		}
+	}
+
+	return matchCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCachedSongThenFile completes a song, then a file after it
+func completeCachedSongThenFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return completeCachedSong(cmd, args, toComplete)
+}
+
+// completeCachedField completes a flag from one field of the cached entries
+func completeCachedField(field func(*cache.LyricEntry) string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		entries, err := cache.GetGlobalCache().ListAll()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveError
+		}
+
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, field(entry))
+		}
+		return matchCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// matchCompletions keeps the names starting with what was typed, ignoring
+// case, once each and sorted
+func matchCompletions(names []string, toComplete string) []string {
+	prefix := strings.ToLower(toComplete)
+	seen := make(map[string]bool)
+
+	var out []string
+	for _, name := range names {
+		if name == "" || seen[name] || !strings.HasPrefix(strings.ToLower(name), prefix) {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToLower(out[i]) < strings.ToLower(out[j])
+	})
+	return out
+}
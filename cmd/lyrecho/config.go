@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/config"
+)
+
+var (
+	// flags for config
+	configForce bool
+	configJSON  bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage the config file",
+	Long: `settings are read from the config file, then the environment, then flags,
+each overriding the one before. view preferences changed with keys in the
+viewer are remembered and override the environment.
+
+the config file holds one "name = value" per line, # starts a comment.`,
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "write a config file listing every setting",
+	Long: `write a config file with every setting commented out at its default value,
+to uncomment and change.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.FilePath()
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stat(path)
+		if err == nil && !configForce {
+			return fmt.Errorf("%s already exists, use --force to replace it", path)
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(path, []byte(config.DefaultFile()), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+
+		fmt.Printf("wrote %s\n", path)
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "print the settings in effect",
+	Long: `print the value in effect for a key, or every setting with where its value
+comes from: default, file, env, state (remembered by the viewer) or flag.
+flags given to this command are taken into account.`,
+	Example: `  lyrecho config get
+  lyrecho config get layout
+  lyrecho config get --layout side --json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeConfigKey,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := effectiveSettings(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+
+		if len(args) == 1 {
+			key, ok := config.LookupKey(args[0])
+			if !ok {
+				return fmt.Errorf("unknown key %q", args[0])
+			}
+			for _, setting := range settings {
+				if setting.Key.Name == key.Name {
+					if configJSON {
+						return printJSON(newConfigEntry(setting))
+					}
+					fmt.Println(setting.Value)
+				}
+			}
+			return nil
+		}
+
+		if configJSON {
+			entries := make([]configEntry, len(settings))
+			for i, setting := range settings {
+				entries[i] = newConfigEntry(setting)
+			}
+			return printJSON(entries)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+		for _, setting := range settings {
+			value := setting.Value
+			if value == "" {
+				value = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key.Name, value, setting.Source)
+		}
+		return w.Flush()
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "write a setting to the config file",
+	Long: `write a setting to the config file, replacing the line that sets it or its
+commented default. an empty value unsets it. a layout, header or context
+lines setting the viewer remembered is forgotten, so the file decides again.`,
+	Example: `  lyrecho config set layout side
+  lyrecho config set players spotify,mpv
+  lyrecho config set proxy ""`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKey,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, ok := config.LookupKey(args[0])
+		if !ok {
+			return fmt.Errorf("unknown key %q, see lyrecho config get", args[0])
+		}
+
+		value := strings.TrimSpace(args[1])
+		if value != "" {
+			err := key.Validate(value)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := config.SetValue(key, value)
+		if err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+
+		path, _ := config.FilePath()
+		fmt.Printf("set %s = %s in %s\n", key.Name, value, path)
+
+		// the view the viewer remembers would win over the file
+		forgotten, err := config.ForgetState(key.Name)
+		if err != nil {
+			statePath, _ := config.StatePath()
+			fmt.Printf("note: the viewer's saved %s in %s overrides the file\n", key.Name, statePath)
+		} else if forgotten {
+			fmt.Printf("dropped the %s the viewer saved\n", key.Name)
+		}
+		if os.Getenv(key.Env) != "" {
+			fmt.Printf("note: %s is set and overrides the file\n", key.Env)
+		}
+		return nil
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "print where the config file is read from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.FilePath()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configPathCmd)
+
+	configInitCmd.Flags().BoolVar(&configForce, "force", false, "replace an existing config file")
+	configGetCmd.Flags().BoolVar(&configJSON, "json", false, "output as json")
+}
+
+// configEntry is a setting as printed by config get --json
+type configEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Env    string `json:"env"`
+	Flag   string `json:"flag,omitempty"`
+}
+
+// helper functions
+
+func newConfigEntry(setting config.Setting) configEntry {
+	return configEntry{
+		Key:    setting.Key.Name,
+		Value:  setting.Value,
+		Source: setting.Source,
+		Env:    setting.Key.Env,
+		Flag:   setting.Key.Flag,
+	}
+}
+
+// effectiveSettings resolves every setting like the viewer does, flags
+// given to cmd included
+func effectiveSettings(cmd *cobra.Command) ([]config.Setting, error) {
+	settings, err := config.Settings()
+
+	for i, setting := range settings {
+		if setting.Key.Flag == "" {
+			continue
+		}
+		flag := cmd.Flags().Lookup(setting.Key.Flag)
+		if flag == nil || !flag.Changed {
+			continue
+		}
+		value := flag.Value.String()
+		if flag.Value.Type() == "stringSlice" {
+			list, _ := cmd.Flags().GetStringSlice(setting.Key.Flag)
+			value = strings.Join(list, ",")
+		}
+		settings[i].Value = value
+		settings[i].Source = "flag"
+	}
+
+	return settings, err
+}
+
+// completeConfigKey completes the key, then true or false for switches
+func completeConfigKey(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		names := make([]string, len(config.Keys))
+		for i, key := range config.Keys {
+			names[i] = key.Name
+		}
+		return matchCompletions(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	key, ok := config.LookupKey(args[0])
+	if cmd.Name() == "set" && len(args) == 1 && ok && key.Kind == config.KindBool {
+		return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
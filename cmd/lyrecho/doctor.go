@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/httpclient"
+	"karolbroda.com/lyrecho/internal/player"
+	"karolbroda.com/lyrecho/internal/terminal"
+)
+
+// lrclib answering slower than this makes track changes feel sluggish
+const slowLrclib = 2 * time.Second
+
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+	checkSkip
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case checkPass:
+		return "✓"
+	case checkWarn:
+		return "!"
+	case checkFail:
+		return "✗"
+	default:
+		return "-"
+	}
+}
+
+// doctorCheck is the outcome of one check, with a way to fix it when it
+// didn't pass
+type doctorCheck struct {
+	name   string
+	status checkStatus
+	detail string
+	fix    string
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "check that lyrecho can run here",
+	Long: `checks the player connection, lrclib, the terminal and the cache, and prints
+how to fix whatever fails. exits with an error when a check fails.
+
+the player flags (--backend, --mpris-service, --mpd-host, ...) and
+--lrclib-url are checked as given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := loadPlayerConfig(cmd)
+
+		var checks []doctorCheck
+		checks = append(checks, checkMPRIS(cfg)...)
+		checks = append(checks, checkPlayer(cfg))
+		checks = append(checks, checkLrclib(cmd.Context(), cfg.LrclibURL))
+		checks = append(checks, checkTerminal()...)
+		checks = append(checks, checkCacheDir())
+
+		width := 0
+		for _, check := range checks {
+			width = max(width, len(check.name))
+		}
+
+		failed := 0
+		for _, check := range checks {
+			fmt.Printf("%s %-*s  %s\n", check.status, width, check.name, check.detail)
+			if check.fix != "" && (check.status == checkFail || check.status == checkWarn) {
+				fmt.Printf("  %-*s  fix: %s\n", width, "", check.fix)
+			}
+			if check.status == checkFail {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// checkMPRIS checks the session bus and lists the players on it, when mpris
+// is the backend in use
+func checkMPRIS(cfg *config.Config) []doctorCheck {
+	backend := strings.ToLower(cfg.Backend)
+	if runtime.GOOS == "windows" || (backend != "" && backend != "auto" && backend != "mpris") {
+		return []doctorCheck{{name: "session bus", status: checkSkip, detail: "not used by this backend"}}
+	}
+
+	bus, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return []doctorCheck{{
+			name:   "session bus",
+			status: checkFail,
+			detail: err.Error(),
+			fix:    "run lyrecho inside your desktop session, or check DBUS_SESSION_BUS_ADDRESS",
+		}}
+	}
+	defer bus.Close()
+
+	checks := []doctorCheck{{name: "session bus", status: checkPass, detail: "connected"}}
+
+	players, err := player.ListPlayers(bus)
+	if err != nil {
+		return append(checks, doctorCheck{name: "mpris players", status: checkFail, detail: err.Error()})
+	}
+
+	ignore := player.IgnoreList(cfg.IgnorePlayers)
+	var names []string
+	ignored := 0
+	for _, service := range players {
+		if ignore.Ignores(bus, service) {
+			ignored++
+			continue
+		}
+		names = append(names, strings.TrimPrefix(service, "org.mpris.MediaPlayer2."))
+	}
+
+	if len(names) == 0 {
+		detail := "none running"
+		if ignored > 0 {
+			detail = fmt.Sprintf("none running (%d ignored)", ignored)
+		}
+		return append(checks, doctorCheck{
+			name:   "mpris players",
+			status: checkWarn,
+			detail: detail,
+			fix:    "start a player with mpris support, some need a plugin (e.g. mpv-mpris)",
+		})
+	}
+
+	return append(checks, doctorCheck{
+		name:   "mpris players",
+		status: checkPass,
+		detail: fmt.Sprintf("%d found: %s", len(names), strings.Join(names, ", ")),
+	})
+}
+
+// checkPlayer connects to the configured backend and reads its track
+func checkPlayer(cfg *config.Config) doctorCheck {
+	playerService, closeBackend, err := newPlayerService(cfg)
+	if err != nil {
+		return doctorCheck{
+			name:   "player",
+			status: checkFail,
+			detail: err.Error(),
+			fix:    "check --backend, --mpris-service and --mpd-host, or run lyrecho player list",
+		}
+	}
+	defer closeBackend()
+
+	trk, err := playerService.GetCurrentTrack()
+	if err != nil || !trk.IsValid() {
+		return doctorCheck{
+			name:   "player",
+			status: checkWarn,
+			detail: fmt.Sprintf("connected to %s, nothing playing", playerService.ServiceName()),
+			fix:    "play something, lyrecho waits for a track with an artist and title",
+		}
+	}
+
+	return doctorCheck{
+		name:   "player",
+		status: checkPass,
+		detail: fmt.Sprintf("%s playing %s - %s", playerService.ServiceName(), trk.Artist, trk.Title),
+	}
+}
+
+// checkLrclib asks lrclib for a well known song and times the answer, any
+// response counts as reachable
+func checkLrclib(parent context.Context, lrclibURL string) doctorCheck {
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	parsed, err := url.Parse(lrclibURL)
+	if err != nil || parsed.Host == "" {
+		return doctorCheck{
+			name:   "lrclib",
+			status: checkFail,
+			detail: fmt.Sprintf("invalid url %q", lrclibURL),
+			fix:    "set --lrclib-url or LRCLIB_GET_URL to a full url like " + config.DefaultLrclibGetURL,
+		}
+	}
+	query := parsed.Query()
+	query.Set("artist_name", "Queen")
+	query.Set("track_name", "Bohemian Rhapsody")
+	parsed.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(parent, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return doctorCheck{name: "lrclib", status: checkFail, detail: err.Error()}
+	}
+
+	start := time.Now()
+	resp, err := httpclient.Get().Do(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return doctorCheck{
+			name:   "lrclib",
+			status: checkFail,
+			detail: "unreachable: " + err.Error(),
+			fix:    "check your connection, or route requests through --proxy",
+		}
+	}
+	resp.Body.Close()
+
+	detail := fmt.Sprintf("%s answered %d in %s", parsed.Host, resp.StatusCode, latency)
+	switch {
+	case resp.StatusCode >= 500:
+		return doctorCheck{name: "lrclib", status: checkFail, detail: detail, fix: "lrclib is having trouble, cached lyrics still work"}
+	case latency > slowLrclib:
+		return doctorCheck{name: "lrclib", status: checkWarn, detail: detail + " (slow)", fix: "lyrics take a while to show, lyrecho lyrics fetch caches them ahead"}
+	default:
+		return doctorCheck{name: "lrclib", status: checkPass, detail: detail}
+	}
+}
+
+// checkTerminal reports the colors and image protocols the terminal offers
+func checkTerminal() []doctorCheck {
+	caps := terminal.DetectCapabilities()
+
+	colors := doctorCheck{name: "colors", status: checkPass, detail: caps.ColorDepth.String()}
+	if caps.ColorDepth != terminal.ColorsTrue {
+		colors.status = checkWarn
+		colors.detail += ", artwork colors are approximated"
+		colors.fix = "set COLORTERM=truecolor if your terminal has true color, or LYRECHO_COLORS to force it"
+	}
+
+	background := "dark"
+	if caps.LightBackground {
+		background = "light"
+	}
+	backgroundCheck := doctorCheck{
+		name:   "background",
+		status: checkPass,
+		detail: background + " (set LYRECHO_BACKGROUND if that's wrong)",
+	}
+
+	term := strings.ToLower(os.Getenv("TERM"))
+	program := os.Getenv("TERM_PROGRAM")
+	kittyCapable := strings.Contains(term, "kitty") || strings.Contains(term, "ghostty") ||
+		program == "ghostty" || program == "WezTerm"
+
+	kitty := doctorCheck{name: "kitty graphics", status: checkPass, detail: "off, artwork is drawn with half blocks"}
+	switch {
+	case caps.SupportsKittyGraphics:
+		kitty.detail = "on, artwork is drawn as an image"
+	case kittyCapable:
+		kitty.status = checkWarn
+		kitty.detail = "off, but this terminal seems to support it"
+		kitty.fix = "set LYRECHO_USE_KITTY_GRAPHICS=1 for sharper artwork"
+	}
+
+	// lyrecho has no sixel output, this only says whether it would help
+	sixel := doctorCheck{name: "sixel", status: checkSkip, detail: "not detected"}
+	if strings.Contains(term, "sixel") || strings.Contains(term, "foot") || strings.Contains(term, "mlterm") || program == "WezTerm" {
+		sixel.detail = "likely supported, but unused: artwork uses kitty graphics or half blocks"
+	}
+
+	return []doctorCheck{colors, backgroundCheck, kitty, sixel}
+}
+
+// checkCacheDir makes sure lyrics can be cached by writing a file there
+func checkCacheDir() doctorCheck {
+	dir := getCacheDir()
+
+	err := os.MkdirAll(dir, 0755)
+	if err == nil {
+		var probe *os.File
+		probe, err = os.CreateTemp(dir, ".doctor-*")
+		if err == nil {
+			probe.Close()
+			err = os.Remove(probe.Name())
+		}
+	}
+	if err != nil {
+		return doctorCheck{
+			name:   "cache",
+			status: checkFail,
+			detail: err.Error(),
+			fix:    fmt.Sprintf("make %s writable, or point XDG_CACHE_HOME somewhere writable", filepath.Dir(dir)),
+		}
+	}
+
+	return doctorCheck{name: "cache", status: checkPass, detail: dir + " is writable"}
+}
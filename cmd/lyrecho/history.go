@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/history"
+)
+
+var (
+	// flags for history list
+	historySince string
+	historyJSON  bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "review what you listened to",
+	Long: `the viewer records every track played for longer than 10 seconds: when, how
+long, and how much of its lyrics were shown. set LYRECHO_HISTORY=0 to turn
+it off.`,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the tracks played in the viewer",
+	Long: `list the tracks played in the viewer, oldest first.
+
+--since takes a duration back from now (90m, 24h, 7d) or a date (2006-01-02).`,
+	Example: `  lyrecho history list --since 7d
+  lyrecho history list --since 2026-10-01 --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var since time.Time
+		if historySince != "" {
+			var err error
+			since, err = parseSince(historySince, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		plays, err := history.Load(since)
+		if err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+
+		if historyJSON {
+			if plays == nil {
+				plays = []history.Play{}
+			}
+			return printJSON(plays)
+		}
+
+		if len(plays) == 0 {
+			fmt.Println("no plays recorded")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PLAYED\tARTIST\tTITLE\tLISTENED\tLYRICS")
+		for _, play := range plays {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				play.StartedAt.Local().Format("2006-01-02 15:04"),
+				play.Artist,
+				play.Title,
+				formatDuration(int64(play.Listened)),
+				formatCoverage(play),
+			)
+		}
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\ntotal: %d plays\n", len(plays))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.AddCommand(historyListCmd)
+
+	historyListCmd.Flags().StringVar(&historySince, "since", "", "only plays since a duration ago (24h, 7d) or a date (2006-01-02)")
+	historyListCmd.Flags().BoolVar(&historyJSON, "json", false, "print the plays as json")
+}
+
+// helper functions
+
+// parseSince reads --since: a duration back from now, where d counts days,
+// or a date
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err == nil && n >= 0 {
+			return now.Add(-time.Duration(n * 24 * float64(time.Hour))), nil
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since %q (use a duration like 24h or 7d, or a date like 2006-01-02)", value)
+}
+
+// formatCoverage says how much of a play's lyrics were shown
+func formatCoverage(play history.Play) string {
+	if play.Lines == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d%%", int(play.Coverage()*100))
+}
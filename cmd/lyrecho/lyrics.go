@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -20,10 +23,11 @@ var lyricsCmd = &cobra.Command{
 }
 
 var lyricsSearchCmd = &cobra.Command{
-	Use:   "search <artist> <title>",
-	Short: "search for lyrics on lrclib",
-	Long:  `search for lyrics on lrclib.net and display availability information.`,
-	Args:  cobra.ExactArgs(2),
+	Use:               "search <artist> <title>",
+	Short:             "search for lyrics on lrclib",
+	Long:              `search for lyrics on lrclib.net and display availability information.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		artist := args[0]
 		title := args[1]
@@ -33,11 +37,17 @@ var lyricsSearchCmd = &cobra.Command{
 			cfg.LrclibURL = lrclibURL
 		}
 
+		cachePolicy, err := loadCachePolicy(cfg)
+		if err != nil {
+			return err
+		}
+
 		fmt.Printf("searching for: %s - %s\n\n", artist, title)
 
 		params := &lyrics.TrackParams{
 			Title:  title,
 			Artist: artist,
+			Cache:  cachePolicy,
 		}
 
 		lyricsData, err := lyrics.Fetch(context.Background(), cfg.LrclibURL, params)
@@ -79,8 +89,12 @@ var lyricsSearchCmd = &cobra.Command{
 var lyricsFetchCmd = &cobra.Command{
 	Use:   "fetch <artist> <title>",
 	Short: "pre-fetch and cache lyrics",
-	Long:  `fetch lyrics from lrclib.net and save them to the local cache for instant loading.`,
-	Args:  cobra.ExactArgs(2),
+	Long: `fetch lyrics from lrclib.net and save them to the local cache for instant loading.
+
+use --force to fetch again and replace a cached entry that is wrong. a sync
+offset tuned for the old entry is kept.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		artist := args[0]
 		title := args[1]
@@ -90,10 +104,15 @@ var lyricsFetchCmd = &cobra.Command{
 			cfg.LrclibURL = lrclibURL
 		}
 
+		cachePolicy, err := loadCachePolicy(cfg)
+		if err != nil {
+			return err
+		}
+
 		// check if already cached
 		diskCache := cache.GetGlobalCache()
 		cached, err := diskCache.Get(artist, title)
-		if err == nil && cached != nil {
+		if err == nil && cached != nil && !fetchForce && cachePolicy.Reads() {
 			fmt.Printf("'%s - %s' is already cached\n", artist, title)
 			if cached.SyncOffset != 0 {
 				fmt.Printf("sync offset: %.2fs\n", cached.SyncOffset)
@@ -104,8 +123,10 @@ var lyricsFetchCmd = &cobra.Command{
 		fmt.Printf("fetching: %s - %s\n", artist, title)
 
 		params := &lyrics.TrackParams{
-			Title:  title,
-			Artist: artist,
+			Title:     title,
+			Artist:    artist,
+			SkipCache: fetchForce,
+			Cache:     cachePolicy,
 		}
 
 		lyricsData, err := lyrics.Fetch(context.Background(), cfg.LrclibURL, params)
@@ -117,7 +138,11 @@ var lyricsFetchCmd = &cobra.Command{
 			return fmt.Errorf("no lyrics available for this song")
 		}
 
-		fmt.Printf("cached successfully: %s - %s\n", lyricsData.ArtistName, lyricsData.TrackName)
+		if cachePolicy.Writes() {
+			fmt.Printf("cached successfully: %s - %s\n", lyricsData.ArtistName, lyricsData.TrackName)
+		} else {
+			fmt.Printf("found (not cached, --no-cache): %s - %s\n", lyricsData.ArtistName, lyricsData.TrackName)
+		}
 		if lyricsData.SyncedLyrics != "" {
 			fmt.Println("synced lyrics available")
 		} else {
@@ -129,10 +154,11 @@ var lyricsFetchCmd = &cobra.Command{
 }
 
 var lyricsPreviewCmd = &cobra.Command{
-	Use:   "preview <artist> <title>",
-	Short: "preview lyrics in terminal",
-	Long:  `display lyrics in the terminal with timestamps (if available).`,
-	Args:  cobra.ExactArgs(2),
+	Use:               "preview <artist> <title>",
+	Short:             "preview lyrics in terminal",
+	Long:              `display lyrics in the terminal with timestamps (if available).`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		artist := args[0]
 		title := args[1]
@@ -142,9 +168,17 @@ var lyricsPreviewCmd = &cobra.Command{
 			cfg.LrclibURL = lrclibURL
 		}
 
+		cachePolicy, err := loadCachePolicy(cfg)
+		if err != nil {
+			return err
+		}
+
 		// try cache first
 		diskCache := cache.GetGlobalCache()
 		cached, err := diskCache.Get(artist, title)
+		if !cachePolicy.Reads() {
+			cached = nil
+		}
 
 		var lyricsData *lyrics.LrclibResponse
 
@@ -165,6 +199,7 @@ var lyricsPreviewCmd = &cobra.Command{
 			params := &lyrics.TrackParams{
 				Title:  title,
 				Artist: artist,
+				Cache:  cachePolicy,
 			}
 
 			lyricsData, err = lyrics.Fetch(context.Background(), cfg.LrclibURL, params)
@@ -196,7 +231,7 @@ var lyricsPreviewCmd = &cobra.Command{
 
 		if lyricsData.SyncedLyrics != "" {
 			// display synced lyrics with timestamps
-			lines := lyrics.ParseSynced(lyricsData.SyncedLyrics)
+			lines, report := lyrics.ParseSyncedWithReport(lyricsData.SyncedLyrics)
 			if len(lines) == 0 {
 				fmt.Println("\nno valid synced lyrics found")
 				return nil
@@ -205,15 +240,20 @@ var lyricsPreviewCmd = &cobra.Command{
 			fmt.Printf("\nsynced lyrics (%d lines):\n\n", len(lines))
 			for _, line := range lines {
 				timestamp := formatTimestamp(line.TimeSeconds)
-				fmt.Printf("[%s] %s\n", timestamp, line.Text)
+				// continuation lines of a multi-line block are indented under the first
+				text := strings.ReplaceAll(line.Text, "\n", "\n"+strings.Repeat(" ", len(timestamp)+3))
+				fmt.Printf("[%s] %s\n", timestamp, text)
 			}
 
 			if lyricsData.SyncOffset != 0 {
 				fmt.Printf("\nsync offset: %.2fs\n", lyricsData.SyncOffset)
 			}
+			if report.Repaired() {
+				fmt.Printf("\nrepaired timestamps: %d lines out of order, %d word timings adjusted\n", report.Reordered, report.WordsAdjusted)
+			}
 		} else if lyricsData.PlainLyrics != "" {
 			// display plain lyrics
-			fmt.Println("\nplain lyrics (no timestamps):\n")
+			fmt.Print("\nplain lyrics (no timestamps):\n\n")
 			fmt.Println(lyricsData.PlainLyrics)
 		} else {
 			fmt.Println("\nno lyrics available")
@@ -223,16 +263,290 @@ var lyricsPreviewCmd = &cobra.Command{
 	},
 }
 
+var lyricsImportCmd = &cobra.Command{
+	Use:   "import <artist> <title> <file>",
+	Short: "import synced lyrics from a file",
+	Long: `import synced lyrics from a local file into the cache.
+
+supported formats (detected by file extension):
+  .lrc          standard and enhanced lrc
+  .ttml, .xml   timed text markup as exported by apple music tooling
+  .srt, .vtt    subtitles/captions, using each cue's start time
+
+word-level timing is kept when the file provides it.`,
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: completeCachedSongThenFile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		artist := args[0]
+		title := args[1]
+		path := args[2]
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read lyrics file: %w", err)
+		}
+
+		lines, err := lyrics.ParseFile(path, data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		entry := &cache.LyricEntry{
+			TrackName:    title,
+			ArtistName:   artist,
+			PlainLyrics:  lyrics.PlainText(lines),
+			SyncedLyrics: lyrics.FormatLRC(lines),
+		}
+
+		// keep metadata and the tuned sync offset of an existing entry
+		diskCache := cache.GetGlobalCache()
+		existing, err := diskCache.Get(artist, title)
+		if err == nil && existing != nil {
+			entry.TrackName = existing.TrackName
+			entry.ArtistName = existing.ArtistName
+			entry.AlbumName = existing.AlbumName
+			entry.Duration = existing.Duration
+			entry.SyncOffset = existing.SyncOffset
+		}
+
+		err = diskCache.Set(artist, title, entry)
+		if err != nil {
+			return fmt.Errorf("failed to save to cache: %w", err)
+		}
+
+		wordTimed := 0
+		for _, line := range lines {
+			if len(line.Words) > 0 {
+				wordTimed++
+			}
+		}
+
+		fmt.Printf("imported %d lines for %s - %s\n", len(lines), artist, title)
+		if wordTimed > 0 {
+			fmt.Printf("word-level timing: %d lines\n", wordTimed)
+		}
+
+		return nil
+	},
+}
+
+// flag for lyrics fetch
+var fetchForce bool
+
+var (
+	matchAlbum    string
+	matchDuration int64
+	matchAll      bool
+)
+
+var lyricsMatchCmd = &cobra.Command{
+	Use:   "match <artist> <title>",
+	Short: "show how a song is searched for on lrclib",
+	Long: `print how the names are cleaned up, every query the search builds from them,
+and what lrclib answers to each, to find out why a song isn't found or the
+wrong one is. the cache is not used.
+
+queries are tried in order until one answers, like the viewer does; --all
+tries every one.`,
+	Example: `  lyrecho lyrics match "Chappell Roan" "HOT TO GO!" --duration 184
+  lyrecho lyrics match "Artist" "Song (Remastered 2011)" --all`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		artist := args[0]
+		title := args[1]
+
+		cfg := config.Load()
+		if lrclibURL != "" {
+			cfg.LrclibURL = lrclibURL
+		}
+		baseURL, err := url.Parse(cfg.LrclibURL)
+		if err != nil {
+			return fmt.Errorf("invalid lrclib url %q: %w", cfg.LrclibURL, err)
+		}
+
+		fmt.Println("names:")
+		for _, name := range []struct{ label, value string }{{"artist", artist}, {"title", title}} {
+			normalized, stripped := lyrics.Normalize(name.value)
+			fmt.Printf("  %-7s %q\n", name.label, name.value)
+			fmt.Printf("          normalized %q, stripped %q\n", normalized, stripped)
+		}
+
+		queries := lyrics.SearchQueries(&lyrics.TrackParams{
+			Artist:       artist,
+			Title:        title,
+			Album:        matchAlbum,
+			DurationSecs: matchDuration,
+		})
+		if len(queries) == 0 {
+			return errors.New("nothing left to search for after cleaning up the names")
+		}
+		fmt.Println("\nqueries, duplicates dropped:")
+
+		answered := 0
+		for i, query := range queries {
+			fmt.Printf("\n  %d. %s\n", i+1, query.Strategy)
+			fmt.Printf("     artist %q, title %q", query.Artist, query.Title)
+			if query.Album != "" {
+				fmt.Printf(", album %q", query.Album)
+			}
+			if query.Duration > 0 {
+				fmt.Printf(", duration %ds", query.Duration)
+			}
+			fmt.Printf("\n     %s\n", query.URL(baseURL))
+
+			if answered > 0 && !matchAll {
+				fmt.Println("     -> not tried")
+				continue
+			}
+
+			start := time.Now()
+			payload, err := lyrics.Lookup(cmd.Context(), cfg.LrclibURL, query)
+			took := time.Since(start).Round(time.Millisecond)
+			switch {
+			case err != nil:
+				fmt.Printf("     -> %v (%s)\n", err, took)
+			case payload.PlainLyrics == "" && payload.SyncedLyrics == "" && !payload.Instrumental:
+				fmt.Printf("     -> answered without lyrics (%s)\n", took)
+			default:
+				if answered == 0 {
+					answered = i + 1
+				}
+				fmt.Printf("     -> found %s - %s (%s)\n", payload.ArtistName, payload.TrackName, took)
+				fmt.Printf("        %s\n", describeLyrics(payload))
+			}
+		}
+
+		fmt.Println()
+		if answered == 0 {
+			return fmt.Errorf("lrclib has no lyrics for any of the %d queries", len(queries))
+		}
+		fmt.Printf("the search uses query %d\n", answered)
+		return nil
+	},
+}
+
+var (
+	mergeTiming string
+	mergeText   string
+	mergeDryRun bool
+)
+
+var lyricsMergeCmd = &cobra.Command{
+	Use:   "merge <artist> <title>",
+	Short: "combine timing and text from two lyric sources",
+	Long: `merge the timestamps of one lyrics source with the text of another and
+save the result to the cache.
+
+lines are aligned by order and similarity, so extra or missing lines on
+either side are handled. text lines without timing get a timestamp
+interpolated from their neighbours.
+
+sources:
+  cache    the cached synced lyrics
+  plain    the cached plain lyrics (text only)
+  <file>   a lyrics file (lrc, ttml, srt, vtt); for --text, a plain text
+           file with one line per lyric line also works`,
+	Example: `  lyrecho lyrics merge "Artist" "Title" --timing cache --text clean.txt
+  lyrecho lyrics merge "Artist" "Title" --timing video.en.vtt --text plain`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		artist := args[0]
+		title := args[1]
+
+		diskCache := cache.GetGlobalCache()
+		existing, err := diskCache.Get(artist, title)
+		if err != nil {
+			existing = nil
+		}
+
+		timing, err := loadMergeTiming(mergeTiming, existing)
+		if err != nil {
+			return err
+		}
+
+		text, err := loadMergeText(mergeText, existing)
+		if err != nil {
+			return err
+		}
+
+		merged, stats := lyrics.Merge(timing, text)
+		synced := lyrics.FormatLRC(merged)
+
+		if mergeDryRun {
+			fmt.Print(synced)
+		} else {
+			entry := &cache.LyricEntry{
+				TrackName:    title,
+				ArtistName:   artist,
+				PlainLyrics:  lyrics.PlainText(merged),
+				SyncedLyrics: synced,
+			}
+			if existing != nil {
+				entry.TrackName = existing.TrackName
+				entry.ArtistName = existing.ArtistName
+				entry.AlbumName = existing.AlbumName
+				entry.Duration = existing.Duration
+				entry.SyncOffset = existing.SyncOffset
+			}
+
+			err = diskCache.Set(artist, title, entry)
+			if err != nil {
+				return fmt.Errorf("failed to save to cache: %w", err)
+			}
+
+			fmt.Printf("merged %d lines for %s - %s\n", len(merged), artist, title)
+		}
+
+		fmt.Fprintf(os.Stderr, "replaced text: %d, kept: %d, inserted: %d\n", stats.Replaced, stats.Kept, stats.Inserted)
+
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(lyricsCmd)
 
 	lyricsCmd.AddCommand(lyricsSearchCmd)
 	lyricsCmd.AddCommand(lyricsFetchCmd)
 	lyricsCmd.AddCommand(lyricsPreviewCmd)
+	lyricsCmd.AddCommand(lyricsImportCmd)
+	lyricsCmd.AddCommand(lyricsMergeCmd)
+	lyricsCmd.AddCommand(lyricsMatchCmd)
+
+	lyricsFetchCmd.Flags().BoolVar(&fetchForce, "force", false, "fetch again even when cached, replacing the entry")
+	lyricsMergeCmd.Flags().StringVar(&mergeTiming, "timing", "cache", "source to take timestamps from: cache or a file")
+	lyricsMergeCmd.Flags().StringVar(&mergeText, "text", "", "source to take text from: cache, plain or a file")
+	lyricsMergeCmd.Flags().BoolVar(&mergeDryRun, "dry-run", false, "print the merged lrc instead of saving it")
+	_ = lyricsMergeCmd.MarkFlagRequired("text")
+	lyricsMatchCmd.Flags().StringVar(&matchAlbum, "album", "", "album, as the player would report it")
+	lyricsMatchCmd.Flags().Int64Var(&matchDuration, "duration", 0, "track length in seconds, as the player would report it")
+	lyricsMatchCmd.Flags().BoolVar(&matchAll, "all", false, "try every query instead of stopping at the first answer")
 }
 
 // helper functions
 
+// describeLyrics sums up what an lrclib answer holds
+func describeLyrics(payload *lyrics.LrclibResponse) string {
+	var parts []string
+	switch {
+	case payload.Instrumental:
+		parts = append(parts, "instrumental")
+	case payload.SyncedLyrics != "":
+		parts = append(parts, fmt.Sprintf("synced, %d lines", len(lyrics.ParseSynced(payload.SyncedLyrics))))
+	default:
+		parts = append(parts, "plain only")
+	}
+	if payload.AlbumName != "" {
+		parts = append(parts, "album "+payload.AlbumName)
+	}
+	if payload.Duration > 0 {
+		parts = append(parts, formatDuration(int64(payload.Duration)))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func formatTimestamp(seconds float64) string {
 	minutes := int(seconds) / 60
 	secs := seconds - float64(minutes*60)
@@ -288,3 +602,59 @@ func findSimilarCachedSongsLyrics(diskCache *cache.DiskCache, artist string, tit
 
 	return matches
 }
+
+func loadMergeTiming(source string, existing *cache.LyricEntry) ([]lyrics.TimedLine, error) {
+	if source == "cache" {
+		if existing == nil || existing.SyncedLyrics == "" {
+			return nil, fmt.Errorf("no cached synced lyrics to take timing from")
+		}
+		return lyrics.ParseSynced(existing.SyncedLyrics), nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timing source: %w", err)
+	}
+
+	lines, err := lyrics.ParseFile(source, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	return lines, nil
+}
+
+func loadMergeText(source string, existing *cache.LyricEntry) ([]string, error) {
+	switch source {
+	case "cache":
+		if existing == nil || existing.SyncedLyrics == "" {
+			return nil, fmt.Errorf("no cached synced lyrics to take text from")
+		}
+		return timedLineTexts(lyrics.ParseSynced(existing.SyncedLyrics)), nil
+	case "plain":
+		if existing == nil || existing.PlainLyrics == "" {
+			return nil, fmt.Errorf("no cached plain lyrics to take text from")
+		}
+		return lyrics.PlainLines(existing.PlainLyrics), nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text source: %w", err)
+	}
+
+	// timed files give cleaner line boundaries, anything else is plain text
+	lines, err := lyrics.ParseFile(source, data)
+	if err == nil {
+		return timedLineTexts(lines), nil
+	}
+	return lyrics.PlainLines(string(data)), nil
+}
+
+// timedLineTexts flattens multi-line blocks so each row aligns on its own
+func timedLineTexts(lines []lyrics.TimedLine) []string {
+	var texts []string
+	for _, line := range lines {
+		texts = append(texts, strings.Split(line.Text, "\n")...)
+	}
+	return texts
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// flags for now
+	nowJSON bool
+)
+
+// nowOutput is what now --json prints
+type nowOutput struct {
+	Playing  bool    `json:"playing"`
+	Artist   string  `json:"artist,omitempty"`
+	Title    string  `json:"title,omitempty"`
+	Album    string  `json:"album,omitempty"`
+	Duration int64   `json:"duration,omitempty"`
+	Position float64 `json:"position"`
+	Line     string  `json:"line"`
+	NextLine string  `json:"next_line"`
+	Synced   bool    `json:"synced"`
+}
+
+var nowCmd = &cobra.Command{
+	Use:   "now",
+	Short: "print the current track and lyric once",
+	Long: `prints the artist, title, position and current lyric line of what is playing,
+then exits. meant for scripts, keybindings and prompt segments.
+
+examples:
+  lyrecho now
+  lyrecho now --json | jq -r .line`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		sess, stop, err := startSession(cmd)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		snap, err := sess.Once(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read player: %w", err)
+		}
+
+		out := nowOutput{
+			Playing:  snap.Playing,
+			Position: snap.Position,
+			Synced:   len(snap.Lines) > 0,
+		}
+		if snap.Track != nil {
+			out.Artist = snap.Track.Artist
+			out.Title = snap.Track.Title
+			out.Album = snap.Track.Album
+			out.Duration = snap.Track.DurationSecs
+		}
+		if line, ok := snap.Line(0); ok {
+			out.Line = pipeText(line.Text)
+		}
+		if line, ok := snap.Line(1); ok {
+			out.NextLine = pipeText(line.Text)
+		}
+
+		if nowJSON {
+			return printJSON(out)
+		}
+
+		if snap.Track == nil {
+			return errors.New("no track is playing")
+		}
+
+		position := formatDuration(int64(out.Position))
+		if out.Duration > 0 {
+			position += "/" + formatDuration(out.Duration)
+		}
+		if !out.Playing {
+			position += " paused"
+		}
+		fmt.Printf("%s - %s [%s]\n", out.Artist, out.Title, position)
+		if out.Line != "" {
+			fmt.Println(out.Line)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nowCmd)
+
+	nowCmd.Flags().BoolVar(&nowJSON, "json", false, "print as json")
+}
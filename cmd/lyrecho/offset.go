@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/cache"
+)
+
+var (
+	// flags for offset get, set and clear
+	offsetCurrent bool
+
+	// flags for offset set
+	offsetAdjust bool
+)
+
+// offsetTarget is the song an offset command works on
+type offsetTarget struct {
+	artist   string
+	title    string
+	duration float64
+}
+
+var offsetCmd = &cobra.Command{
+	Use:   "offset",
+	Short: "manage the sync offset of one song",
+	Long: `read and change the sync offset of a song without opening the viewer.
+
+name the song with its artist and title, or use --current for whatever is
+playing now. to change many songs at once see "lyrecho cache offsets".`,
+}
+
+var offsetGetCmd = &cobra.Command{
+	Use:   "get [artist] [title]",
+	Short: "print the sync offset of a song",
+	Long:  `print the sync offset of a song in seconds, 0 when it has none.`,
+	Example: `  lyrecho offset get "Artist" "Title"
+  lyrecho offset get --current`,
+	Args:              offsetArgs(0),
+	ValidArgsFunction: completeCachedSong,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := resolveOffsetTarget(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		offset := 0.0
+		entry, err := cache.GetGlobalCache().Get(target.artist, target.title)
+		if err == nil {
+			offset = entry.SyncOffset
+		} else if stored, ok := cache.GetGlobalCache().LookupOffset(target.artist, target.title, target.duration); ok {
+			offset = stored
+		}
+
+		fmt.Printf("%+.2f\n", offset)
+		return nil
+	},
+}
+
+var offsetSetCmd = &cobra.Command{
+	Use:   "set [artist] [title] <seconds>",
+	Short: "set the sync offset of a song",
+	Long: `set the sync offset of a song, or move it by the given seconds with
+--adjust. negative offsets need -- before them so they aren't read as flags.
+
+a song that isn't cached keeps the offset until its lyrics are fetched.`,
+	Example: `  lyrecho offset set "Artist" "Title" 0.4
+  lyrecho offset set --current --adjust -- -0.1`,
+	Args:              offsetArgs(1),
+	ValidArgsFunction: completeCachedSong,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value := args[len(args)-1]
+		offset, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid offset %q: %w", value, err)
+		}
+
+		target, err := resolveOffsetTarget(cmd, args[:len(args)-1])
+		if err != nil {
+			return err
+		}
+
+		if offsetAdjust {
+			entry, err := cache.GetGlobalCache().Get(target.artist, target.title)
+			if err == nil {
+				offset += entry.SyncOffset
+			} else if stored, ok := cache.GetGlobalCache().LookupOffset(target.artist, target.title, target.duration); ok {
+				offset += stored
+			}
+		}
+
+		return saveOffset(target, math.Round(offset*100)/100)
+	},
+}
+
+var offsetClearCmd = &cobra.Command{
+	Use:   "clear [artist] [title]",
+	Short: "clear the sync offset of a song",
+	Long:  `reset the sync offset of a song to zero.`,
+	Example: `  lyrecho offset clear "Artist" "Title"
+  lyrecho offset clear --current`,
+	Args:              offsetArgs(0),
+	ValidArgsFunction: completeCachedSong,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := resolveOffsetTarget(cmd, args)
+		if err != nil {
+			return err
+		}
+		return saveOffset(target, 0)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(offsetCmd)
+
+	offsetCmd.AddCommand(offsetGetCmd)
+	offsetCmd.AddCommand(offsetSetCmd)
+	offsetCmd.AddCommand(offsetClearCmd)
+
+	for _, c := range []*cobra.Command{offsetGetCmd, offsetSetCmd, offsetClearCmd} {
+		c.Flags().BoolVar(&offsetCurrent, "current", false, "use the song playing now")
+	}
+	offsetSetCmd.Flags().BoolVar(&offsetAdjust, "adjust", false, "move the offset by the seconds given instead of setting it")
+}
+
+// helper functions
+
+// offsetArgs wants the artist and title unless --current is given,
+// followed by extra arguments
+func offsetArgs(extra int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if offsetCurrent {
+			return cobra.ExactArgs(extra)(cmd, args)
+		}
+		if len(args) != extra+2 {
+			return fmt.Errorf("name the song with <artist> <title>, or use --current (usage: %s)", cmd.UseLine())
+		}
+		return nil
+	}
+}
+
+// resolveOffsetTarget finds the song from the arguments, or asks the player
+// with --current
+func resolveOffsetTarget(cmd *cobra.Command, args []string) (offsetTarget, error) {
+	if !offsetCurrent {
+		return offsetTarget{artist: args[0], title: args[1]}, nil
+	}
+
+	playerService, _, stop, err := startPlayer(cmd)
+	if err != nil {
+		return offsetTarget{}, err
+	}
+	defer stop()
+
+	trk, err := playerService.GetCurrentTrack()
+	if err != nil || !trk.IsValid() {
+		return offsetTarget{}, errors.New("no track is playing")
+	}
+
+	return offsetTarget{artist: trk.Artist, title: trk.Title, duration: float64(trk.DurationSecs)}, nil
+}
+
+func saveOffset(target offsetTarget, offset float64) error {
+	cached, err := cache.GetGlobalCache().SetOffset(target.artist, target.title, target.duration, offset)
+	if err != nil {
+		return fmt.Errorf("failed to save offset: %w", err)
+	}
+
+	action := fmt.Sprintf("set sync offset to %+.2fs", offset)
+	if offset == 0 {
+		action = "cleared sync offset"
+	}
+	fmt.Printf("%s for %s - %s\n", action, target.artist, target.title)
+	if !cached {
+		fmt.Println("the song isn't cached yet, the offset applies once its lyrics are fetched")
+	}
+	return nil
+}
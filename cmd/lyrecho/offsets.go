@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/httpclient"
+)
+
+var (
+	// flags for cache offsets import
+	offsetsOverwrite bool
+
+	// flags for cache offsets list, set, clear and copy
+	offsetsArtist string
+	offsetsAlbum  string
+	offsetsTitle  string
+	offsetsAll    bool
+	offsetsJSON   bool
+)
+
+var cacheOffsetsCmd = &cobra.Command{
+	Use:   "offsets",
+	Short: "share tuned sync offsets",
+	Long: `export and import per-song sync offsets.
+
+offsets are keyed by artist, title and duration and stored as a sorted json
+file, so they can be kept in git or sent to a shared endpoint. nothing is
+shared unless you run these commands.
+
+list, set, clear and copy edit the offsets of cached songs in bulk, picked
+with --artist, --album and --title.`,
+}
+
+var cacheOffsetsExportCmd = &cobra.Command{
+	Use:   "export [file|url]",
+	Short: "export sync offsets as json",
+	Long: `export every tuned sync offset as json.
+
+writes to stdout when no destination is given, to a file, or POSTs the json
+to an http(s) url.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		diskCache := cache.GetGlobalCache()
+
+		file, err := diskCache.ExportOffsets()
+		if err != nil {
+			return fmt.Errorf("failed to collect offsets: %w", err)
+		}
+
+		data, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+
+		if len(args) == 0 {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+
+		dest := args[0]
+		if isHTTPURL(dest) {
+			err = postOffsets(dest, data)
+		} else {
+			err = os.WriteFile(dest, data, 0644)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export offsets: %w", err)
+		}
+
+		fmt.Printf("exported %d offsets to %s\n", len(file.Offsets), dest)
+		return nil
+	},
+}
+
+var cacheOffsetsImportCmd = &cobra.Command{
+	Use:   "import <file|url>",
+	Short: "import sync offsets from json",
+	Long: `import sync offsets from a json file or http(s) url.
+
+offsets are applied to matching cached songs and remembered for songs that
+aren't cached yet, so they apply once the lyrics are fetched. offsets you
+already tuned are kept unless --overwrite is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source := args[0]
+
+		var data []byte
+		var err error
+		if isHTTPURL(source) {
+			data, err = fetchOffsets(source)
+		} else {
+			data, err = os.ReadFile(source)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read offsets: %w", err)
+		}
+
+		var file cache.OffsetFile
+		err = json.Unmarshal(data, &file)
+		if err != nil {
+			return fmt.Errorf("invalid offsets file: %w", err)
+		}
+
+		diskCache := cache.GetGlobalCache()
+		result, err := diskCache.ImportOffsets(&file, offsetsOverwrite)
+		if err != nil {
+			return fmt.Errorf("failed to import offsets: %w", err)
+		}
+
+		fmt.Printf("imported %d offsets\n", len(file.Offsets))
+		fmt.Printf("  applied to cached songs: %d\n", result.Applied)
+		fmt.Printf("  saved for later:         %d\n", result.Stored)
+		if result.Skipped > 0 {
+			fmt.Printf("  kept existing:           %d (use --overwrite to replace)\n", result.Skipped)
+		}
+
+		return nil
+	},
+}
+
+var cacheOffsetsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list sync offsets of cached songs",
+	Long: `list the sync offsets of cached songs, only the tuned ones unless --all
+is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := cache.GetGlobalCache().ListAll()
+		if err != nil {
+			return fmt.Errorf("failed to list cache: %w", err)
+		}
+
+		filter := offsetsFilter()
+		var matched []*cache.LyricEntry
+		for _, entry := range entries {
+			if filter.Matches(entry) && (offsetsAll || entry.SyncOffset != 0) {
+				matched = append(matched, entry)
+			}
+		}
+
+		return printOffsetEntries(matched)
+	},
+}
+
+var cacheOffsetsSetCmd = &cobra.Command{
+	Use:   "set <seconds>",
+	Short: "set the sync offset of cached songs",
+	Long: `set the sync offset of every cached song picked by --artist, --album and
+--title. negative offsets need -- before them so they aren't read as flags.`,
+	Example: `  lyrecho cache offsets set 0.4 --artist "Artist" --album "Album"
+  lyrecho cache offsets set --artist "Artist" -- -0.25`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		offset, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return fmt.Errorf("invalid offset %q: %w", args[0], err)
+		}
+
+		return setOffsets(offset)
+	},
+}
+
+var cacheOffsetsClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "clear the sync offset of cached songs",
+	Long: `reset the sync offset of every cached song picked by --artist, --album and
+--title to zero. use --all to clear every song.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setOffsets(0)
+	},
+}
+
+var cacheOffsetsCopyCmd = &cobra.Command{
+	Use:   "copy <artist> <title>",
+	Short: "copy one song's sync offset to others",
+	Long: `copy the sync offset of a cached song to every cached song picked by
+--artist, --album and --title, such as the rest of its album.`,
+	Example:           `  lyrecho cache offsets copy "Artist" "Title" --album "Album"`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCachedSong,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := cache.GetGlobalCache().ListAll()
+		if err != nil {
+			return fmt.Errorf("failed to list cache: %w", err)
+		}
+
+		source := cache.EntryFilter{Artist: args[0], Title: args[1]}
+		for _, entry := range entries {
+			if source.Matches(entry) {
+				return setOffsets(entry.SyncOffset)
+			}
+		}
+
+		return fmt.Errorf("song not found in cache: %s - %s", args[0], args[1])
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheOffsetsCmd)
+
+	cacheOffsetsCmd.AddCommand(cacheOffsetsExportCmd)
+	cacheOffsetsCmd.AddCommand(cacheOffsetsImportCmd)
+	cacheOffsetsCmd.AddCommand(cacheOffsetsListCmd)
+	cacheOffsetsCmd.AddCommand(cacheOffsetsSetCmd)
+	cacheOffsetsCmd.AddCommand(cacheOffsetsClearCmd)
+	cacheOffsetsCmd.AddCommand(cacheOffsetsCopyCmd)
+
+	cacheOffsetsImportCmd.Flags().BoolVar(&offsetsOverwrite, "overwrite", false, "replace offsets already tuned locally")
+
+	for _, c := range []*cobra.Command{cacheOffsetsListCmd, cacheOffsetsSetCmd, cacheOffsetsClearCmd, cacheOffsetsCopyCmd} {
+		c.Flags().StringVar(&offsetsArtist, "artist", "", "only songs by this artist")
+		c.Flags().StringVar(&offsetsAlbum, "album", "", "only songs from this album")
+		c.Flags().StringVar(&offsetsTitle, "title", "", "only songs with this title")
+		c.Flags().BoolVar(&offsetsJSON, "json", false, "print the songs as json")
+	}
+	for _, c := range []*cobra.Command{cacheOffsetsListCmd, cacheOffsetsSetCmd, cacheOffsetsClearCmd, cacheOffsetsCopyCmd} {
+		_ = c.RegisterFlagCompletionFunc("artist", completeCachedField(func(entry *cache.LyricEntry) string { return entry.ArtistName }))
+		_ = c.RegisterFlagCompletionFunc("album", completeCachedField(func(entry *cache.LyricEntry) string { return entry.AlbumName }))
+		_ = c.RegisterFlagCompletionFunc("title", completeCachedField(func(entry *cache.LyricEntry) string { return entry.TrackName }))
+	}
+	cacheOffsetsListCmd.Flags().BoolVar(&offsetsAll, "all", false, "include songs without an offset")
+	cacheOffsetsClearCmd.Flags().BoolVar(&offsetsAll, "all", false, "clear every song when no filter is given")
+}
+
+// offsetEntry is a cached song's offset as printed by --json
+type offsetEntry struct {
+	Artist   string  `json:"artist"`
+	Title    string  `json:"title"`
+	Album    string  `json:"album,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+	Offset   float64 `json:"offset"`
+}
+
+// helper functions
+
+func offsetsFilter() cache.EntryFilter {
+	return cache.EntryFilter{Artist: offsetsArtist, Album: offsetsAlbum, Title: offsetsTitle}
+}
+
+// setOffsets applies an offset to the songs picked by the filter flags. an
+// empty filter would change the whole cache, so it needs --all.
+func setOffsets(offset float64) error {
+	filter := offsetsFilter()
+	if filter.Empty() && !offsetsAll {
+		return errors.New("pick songs with --artist, --album or --title")
+	}
+
+	updated, err := cache.GetGlobalCache().SetOffsets(filter, offset)
+	if err != nil {
+		return fmt.Errorf("failed to update offsets: %w", err)
+	}
+
+	if offsetsJSON {
+		return printOffsetEntries(updated)
+	}
+	if len(updated) == 0 {
+		fmt.Println("no cached songs matched")
+		return nil
+	}
+	if offset == 0 {
+		fmt.Printf("cleared sync offset for %d songs\n", len(updated))
+		return nil
+	}
+	fmt.Printf("set sync offset to %+.2fs for %d songs\n", offset, len(updated))
+	return nil
+}
+
+func printOffsetEntries(entries []*cache.LyricEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !strings.EqualFold(a.ArtistName, b.ArtistName) {
+			return strings.ToLower(a.ArtistName) < strings.ToLower(b.ArtistName)
+		}
+		if !strings.EqualFold(a.AlbumName, b.AlbumName) {
+			return strings.ToLower(a.AlbumName) < strings.ToLower(b.AlbumName)
+		}
+		return strings.ToLower(a.TrackName) < strings.ToLower(b.TrackName)
+	})
+
+	if offsetsJSON {
+		out := make([]offsetEntry, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, offsetEntry{
+				Artist:   entry.ArtistName,
+				Title:    entry.TrackName,
+				Album:    entry.AlbumName,
+				Duration: entry.Duration,
+				Offset:   entry.SyncOffset,
+			})
+		}
+		return printJSON(out)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("no cached songs matched")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ARTIST\tALBUM\tTITLE\tSYNC OFFSET")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%+.2fs\n", entry.ArtistName, entry.AlbumName, entry.TrackName, entry.SyncOffset)
+	}
+	return w.Flush()
+}
+
+func isHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func fetchOffsets(url string) ([]byte, error) {
+	resp, err := httpclient.Get().Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func postOffsets(url string, data []byte) error {
+	resp, err := httpclient.Get().Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/session"
+)
+
+var (
+	// flags for pipe
+	pipeTrack bool
+)
+
+var pipeCmd = &cobra.Command{
+	Use:   "pipe",
+	Short: "print each lyric line as it is sung",
+	Long: `follows the player without the viewer and prints every lyric line to stdout
+the moment it becomes current, one line per change. an empty line is printed
+for instrumental breaks and when the track changes, so whatever reads the
+output can clear what it shows.
+
+examples:
+  lyrecho pipe | while read -r line; do notify-send "$line"; done
+  lyrecho pipe --track > ~/.cache/lyric`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		sess, stop, err := startSession(cmd)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		// stdout isn't buffered, every line reaches the reader as it's printed
+		shown := ""
+		show := func(text string) {
+			if text == "" && shown == "" {
+				return
+			}
+			shown = text
+			fmt.Println(text)
+		}
+
+		err = sess.Run(ctx, func(event session.Event) {
+			snap := event.Snapshot
+			switch event.Type {
+			case session.EventTrack:
+				show("")
+				if pipeTrack && snap.Track != nil {
+					show(snap.Track.Artist + " - " + snap.Track.Title)
+				}
+			case session.EventLine:
+				line, ok := snap.Line(0)
+				if !ok {
+					show("")
+					return
+				}
+				show(pipeText(line.Text))
+			}
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	},
+}
+
+// pipeText keeps a line on one output line, lines with a translation or
+// background vocals span several
+func pipeText(text string) string {
+	parts := strings.Split(strings.TrimSpace(text), "\n")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Join(parts, " / ")
+}
+
+func init() {
+	rootCmd.AddCommand(pipeCmd)
+
+	pipeCmd.Flags().BoolVar(&pipeTrack, "track", false, "print \"artist - title\" when the track changes")
+}
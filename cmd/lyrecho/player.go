@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/spf13/cobra"
@@ -14,6 +13,9 @@ import (
 var (
 	// flags for player test
 	testService string
+
+	// flags for player list
+	listAllPlayers bool
 )
 
 var playerCmd = &cobra.Command{
@@ -33,39 +35,57 @@ var playerListCmd = &cobra.Command{
 		}
 		defer bus.Close()
 
-		// list all names on the session bus
-		var names []string
-		err = bus.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names)
+		cfg := config.Load()
+		if len(ignorePlayer) > 0 {
+			cfg.IgnorePlayers = ignorePlayer
+		}
+		ignore := player.IgnoreList(cfg.IgnorePlayers)
+
+		players, err := player.ListPlayers(bus)
 		if err != nil {
 			return fmt.Errorf("failed to list dbus names: %w", err)
 		}
 
-		// filter for mpris services
 		var mprisServices []string
-		for _, name := range names {
-			if strings.HasPrefix(name, "org.mpris.MediaPlayer2.") {
-				mprisServices = append(mprisServices, name)
+		hidden := 0
+		for _, service := range players {
+			if ignore.Ignores(bus, service) && !listAllPlayers {
+				hidden++
+				continue
 			}
+			mprisServices = append(mprisServices, service)
 		}
 
 		if len(mprisServices) == 0 {
 			fmt.Println("no mpris players found")
-			fmt.Println("\ncheck if your music player is running and supports mpris")
+			if hidden > 0 {
+				fmt.Printf("\n%d ignored player(s) hidden, use --all to show them\n", hidden)
+			} else {
+				fmt.Println("\ncheck if your music player is running and supports mpris")
+			}
 			return nil
 		}
 
 		fmt.Printf("found %d mpris player(s):\n\n", len(mprisServices))
 		for _, service := range mprisServices {
+			suffix := ""
+			if listAllPlayers && ignore.Ignores(bus, service) {
+				suffix = " [ignored]"
+			}
+
 			// try to get player identity
-			identity := getPlayerIdentity(bus, service)
+			identity := player.Identity(bus, service)
 			if identity != "" {
-				fmt.Printf("  %s (%s)\n", service, identity)
+				fmt.Printf("  %s (%s)%s\n", service, identity, suffix)
 			} else {
-				fmt.Printf("  %s\n", service)
+				fmt.Printf("  %s%s\n", service, suffix)
 			}
 		}
 
-		fmt.Println("\nuse --mpris-service flag to specify which player to use")
+		if hidden > 0 {
+			fmt.Printf("\n%d ignored player(s) hidden, use --all to show them\n", hidden)
+		}
+		fmt.Println("\nuse --mpris-service flag to specify which player to use, or --follow to switch automatically")
 
 		return nil
 	},
@@ -93,13 +113,13 @@ var playerTestCmd = &cobra.Command{
 		fmt.Printf("testing connection to: %s\n\n", serviceName)
 
 		// try to create player service
-		playerService, err := player.NewService(bus, serviceName)
+		playerService, err := player.NewMPRIS(bus, serviceName)
 		if err != nil {
 			return fmt.Errorf("failed to connect to player: %w", err)
 		}
 
 		// get player identity
-		identity := getPlayerIdentity(bus, serviceName)
+		identity := player.Identity(bus, serviceName)
 		if identity != "" {
 			fmt.Printf("player identity: %s\n", identity)
 		}
@@ -145,7 +165,7 @@ var playerCurrentCmd = &cobra.Command{
 		}
 		defer bus.Close()
 
-		playerService, err := player.NewService(bus, cfg.MprisService)
+		playerService, err := player.NewMPRIS(bus, cfg.MprisService)
 		if err != nil {
 			return fmt.Errorf("failed to connect to player: %w", err)
 		}
@@ -187,27 +207,15 @@ func init() {
 	playerCmd.AddCommand(playerTestCmd)
 	playerCmd.AddCommand(playerCurrentCmd)
 
+	// flags for player list
+	playerListCmd.Flags().BoolVar(&listAllPlayers, "all", false, "include players on the ignore list")
+
 	// flags for player test
 	playerTestCmd.Flags().StringVar(&testService, "service", "", "mpris service to test")
 }
 
 // helper functions
 
-func getPlayerIdentity(bus *dbus.Conn, serviceName string) string {
-	obj := bus.Object(serviceName, "/org/mpris/MediaPlayer2")
-	variant, err := obj.GetProperty("org.mpris.MediaPlayer2.Identity")
-	if err != nil {
-		return ""
-	}
-
-	identity, ok := variant.Value().(string)
-	if !ok {
-		return ""
-	}
-
-	return identity
-}
-
 func formatDuration(seconds int64) string {
 	if seconds < 0 {
 		return "0:00"
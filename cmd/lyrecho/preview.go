@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/terminal"
+	"karolbroda.com/lyrecho/internal/ui"
+)
+
+var (
+	// flags for preview
+	previewTheme string
+	previewText  string
+)
+
+var previewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "preview themes and animations without playing music",
+	Long: fmt.Sprintf(`render a looping fake lyric sequence at the current terminal size,
+so themes and animations can be tuned without a music player.
+
+themes:     %s
+animations: %s`, strings.Join(artwork.ThemeNames(), ", "), strings.Join(ui.AnimationNames(), ", ")),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		palette, err := artwork.ThemePalette(previewTheme)
+		if err != nil {
+			return err
+		}
+
+		cfg := config.Load()
+		animation, err := loadAnimation(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		defer terminal.Reset()
+
+		model := ui.NewPreviewModel(ui.PreviewConfig{
+			Palette:   palette,
+			Animation: animation,
+			Text:      previewText,
+			Label:     fmt.Sprintf("theme: %s · animation: %s", previewTheme, cfg.Animation),
+			TermCaps:  terminal.DetectCapabilities(),
+		})
+
+		p := tea.NewProgram(model, tea.WithAltScreen())
+		if _, err := p.Run(); err != nil {
+			return fmt.Errorf("error running bubble tea: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+
+	previewCmd.Flags().StringVar(&previewTheme, "theme", "default", "theme to preview")
+	previewCmd.Flags().StringVar(&previewText, "text", "", "sample lyric line")
+}
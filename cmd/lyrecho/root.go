@@ -2,18 +2,61 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/httpclient"
+	"karolbroda.com/lyrecho/internal/logging"
+	"karolbroda.com/lyrecho/internal/ui"
 )
 
 var (
 	// global flags
-	mprisService string
-	syncOffset   float64
-	hideHeader   bool
-	lrclibURL    string
-	noCache      bool
+	mprisService   string
+	syncOffset     float64
+	hideHeader     bool
+	plainText      bool
+	oneLine        bool
+	karaoke        bool
+	sections       bool
+	bgTint         bool
+	backdrop       bool
+	statusBar      bool
+	showVisualizer bool
+	contextLines   int
+	lrclibURL      string
+	noCache        string
+	proxyURL       string
+	endBehavior    string
+	layoutName     string
+	autoCalib      bool
+	followPlayer   bool
+	playerOrder    []string
+	ignorePlayer   []string
+	backendName    string
+	mpdHost        string
+	volumeKeys     []string
+	scrollRelock   float64
+	idleClock      float64
+	primaryColor   string
+	secondColor    string
+	accentColor    string
+	paletteBlend   float64
+
+	// logging flags
+	verbose bool
+	debug   bool
+
+	// animation flags
+	animationName   string
+	transitionTicks int
+	revealStep      float64
+	shimmer         bool
+	glow            float64
 )
 
 var rootCmd = &cobra.Command{
@@ -24,6 +67,13 @@ it displays real-time lyrics with dynamic color themes extracted from album artw
 
 when run without a subcommand, it starts the interactive TUI viewer.`,
 	Version: "1.0.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		err := setupLogging(cmd)
+		if err != nil {
+			return err
+		}
+		return configureProxy()
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// default behavior: run the TUI viewer
 		return runViewer(cmd, args)
@@ -34,11 +84,81 @@ when run without a subcommand, it starts the interactive TUI viewer.`,
 
 func init() {
 	// global flags for the viewer
+	rootCmd.PersistentFlags().StringVar(&backendName, "backend", "", "player backend: auto, mpris, smtc (windows), mpd, spotify")
+	rootCmd.PersistentFlags().StringVar(&mpdHost, "mpd-host", "", "mpd address for --backend mpd: host, host:port, password@host or a socket path")
 	rootCmd.PersistentFlags().StringVarP(&mprisService, "mpris-service", "m", "", "mpris service name (e.g., org.mpris.MediaPlayer2.spotify)")
 	rootCmd.PersistentFlags().Float64VarP(&syncOffset, "sync-offset", "s", 0, "initial sync offset in seconds")
 	rootCmd.PersistentFlags().BoolVarP(&hideHeader, "hide-header", "H", false, "hide header section")
+	rootCmd.PersistentFlags().BoolVar(&plainText, "plain", false, "draw lyrics as plain text instead of the pixel font (toggle with t)")
+	rootCmd.PersistentFlags().BoolVar(&oneLine, "oneline", false, "show only the current lyric on a single row, for tiny panes")
+	rootCmd.PersistentFlags().BoolVar(&karaoke, "karaoke", false, "sweep the current line from dim to lit as it is sung (toggle with K)")
+	rootCmd.PersistentFlags().BoolVar(&sections, "sections", false, "tint repeated chorus lines with the accent color and name the sections (toggle with C)")
+	rootCmd.PersistentFlags().BoolVar(&bgTint, "bg-tint", false, "tint the background with a darkened artwork color")
+	rootCmd.PersistentFlags().BoolVar(&backdrop, "backdrop", false, "draw a dimmed, blurred copy of the artwork behind the lyrics")
+	rootCmd.PersistentFlags().BoolVar(&statusBar, "status-bar", false, "show player, sync offset and lyrics source in a bottom bar (toggle with I)")
+	rootCmd.PersistentFlags().BoolVar(&showVisualizer, "visualizer", false, "show an audio spectrum under the lyrics, read from cava (toggle with V)")
+	rootCmd.PersistentFlags().IntVar(&contextLines, "context-lines", config.DefaultContextLines, "lines shown before and after the current one, 0 for the current line only, -1 to fit the terminal")
+	rootCmd.PersistentFlags().Float64Var(&idleClock, "idle-clock", 0, "minutes paused before a large clock replaces the lyrics, 0 never")
+	rootCmd.PersistentFlags().StringVar(&primaryColor, "primary", "", "override the primary color taken from the artwork (#rrggbb)")
+	rootCmd.PersistentFlags().StringVar(&secondColor, "secondary", "", "override the secondary color taken from the artwork (#rrggbb)")
+	rootCmd.PersistentFlags().StringVar(&accentColor, "accent", "", "override the accent color taken from the artwork (#rrggbb)")
+	rootCmd.PersistentFlags().Float64Var(&paletteBlend, "palette-blend", config.DefaultPaletteBlend, "how much of the override colors to mix into the artwork colors, from 0 to 1")
+	rootCmd.PersistentFlags().StringVar(&animationName, "animation", "", "animation preset: "+strings.Join(ui.AnimationNames(), ", "))
+	rootCmd.PersistentFlags().IntVar(&transitionTicks, "transition-ticks", 0, "ticks a line change slides for, overrides the preset")
+	rootCmd.PersistentFlags().Float64Var(&revealStep, "reveal-step", 0, "how much of a new line fades in per tick, 1 shows it at once, overrides the preset")
+	rootCmd.PersistentFlags().BoolVar(&shimmer, "shimmer", false, "shimmer across the current line, overrides the preset")
+	rootCmd.PersistentFlags().Float64Var(&glow, "glow", 0, "how bright a new line flashes, 0 to 2, overrides the preset")
 	rootCmd.PersistentFlags().StringVar(&lrclibURL, "lrclib-url", "", "custom lrclib api url")
-	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "disable cache reads (always fetch fresh)")
+	rootCmd.PersistentFlags().StringVar(&noCache, "no-cache", "", "skip the lyrics cache: read (always fetch fresh, the default for a bare --no-cache), write (don't save) or all")
+	rootCmd.PersistentFlags().Lookup("no-cache").NoOptDefVal = "read"
+	rootCmd.PersistentFlags().StringVar(&endBehavior, "end-behavior", "", "what to show after the last lyric: hold, outro, idle, scroll")
+	rootCmd.PersistentFlags().StringVar(&layoutName, "layout", "", "screen layout: stacked (artwork in the header), side (artwork panel left of the lyrics) or columns (lyric sheet next to the synced lyrics)")
+	rootCmd.PersistentFlags().BoolVar(&followPlayer, "follow", false, "switch to whichever mpris player starts playing")
+	rootCmd.PersistentFlags().StringSliceVar(&playerOrder, "players", nil, "players to follow in priority order (e.g. spotify,mpv); implies --follow")
+	rootCmd.PersistentFlags().StringSliceVar(&ignorePlayer, "ignore-player", nil, "players to skip when discovering, as globs on the bus name or identity (e.g. '*firefox*')")
+	rootCmd.PersistentFlags().BoolVar(&autoCalib, "auto-calibrate", false, "experimental: detect the vocal onset from system audio and propose a sync offset")
+	rootCmd.PersistentFlags().StringSliceVar(&volumeKeys, "volume-keys", nil, "keys that lower and raise the player volume (default \"[,]\")")
+	rootCmd.PersistentFlags().Float64Var(&scrollRelock, "scroll-relock", 0, "seconds after scrolling before the lyrics follow the playing line again, 0 to wait for f (default 5)")
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "proxy for lyrics and artwork requests (http://, socks5://)")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "log player events, lyrics lookups and cache hits to $XDG_STATE_HOME/lyrecho/log")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "log like --verbose, plus seeks, requests and render timing")
+}
+
+// setupLogging opens the log when --verbose or --debug is given, and
+// silences it otherwise
+func setupLogging(cmd *cobra.Command) error {
+	if !verbose && !debug {
+		logging.Disable()
+		return nil
+	}
+
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	_, err := logging.Open(level)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+
+	slog.Info("start", "command", cmd.CommandPath(), "version", cmd.Root().Version)
+	return nil
+}
+
+// configureProxy applies the proxy from flags or config to the shared http client.
+// without an explicit proxy, HTTP_PROXY/HTTPS_PROXY/ALL_PROXY are used.
+func configureProxy() error {
+	cfg := config.Load()
+	if proxyURL != "" {
+		cfg.Proxy = proxyURL
+	}
+
+	err := httpclient.SetProxy(cfg.Proxy)
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
 func Execute() {
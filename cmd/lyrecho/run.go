@@ -6,15 +6,19 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/godbus/dbus/v5"
 	"github.com/spf13/cobra"
 
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/colors"
 	"karolbroda.com/lyrecho/internal/config"
-	"karolbroda.com/lyrecho/internal/player"
+	"karolbroda.com/lyrecho/internal/lyrics"
 	"karolbroda.com/lyrecho/internal/terminal"
 	"karolbroda.com/lyrecho/internal/ui"
+	"karolbroda.com/lyrecho/internal/visualizer"
 )
 
 var runCmd = &cobra.Command{
@@ -45,46 +49,54 @@ func runViewer(cmd *cobra.Command, args []string) error {
 	defer terminal.Reset()
 
 	// load config from environment, then override with flags
-	cfg := config.Load()
+	cfg := loadPlayerConfig(cmd)
 
-	if mprisService != "" {
-		cfg.MprisService = mprisService
-	}
-	if lrclibURL != "" {
-		cfg.LrclibURL = lrclibURL
-	}
-	if cmd.Flags().Changed("sync-offset") {
-		cfg.SyncOffset = syncOffset
-	}
-	if cmd.Flags().Changed("hide-header") {
-		cfg.HideHeader = hideHeader
-	}
-
-	bus, err := dbus.ConnectSessionBus()
+	modelConfig, err := loadViewerConfig(cmd, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to connect to session bus: %w", err)
+		return err
 	}
-	defer bus.Close()
 
-	playerService, err := player.NewService(bus, cfg.MprisService)
+	playerService, closeBackend, err := newPlayerService(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create player service: %w", err)
+		return err
 	}
+	defer closeBackend()
 
 	err = playerService.Start()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "warning: could not set up dbus signals: %v\n", err)
+		fmt.Fprintf(os.Stderr, "warning: could not watch player: %v\n", err)
+	}
+
+	if autoCalib {
+		return runAutoCalibrate(ctx, playerService, cfg, modelConfig.CachePolicy)
+	}
+
+	if cfg.CacheMaintenance {
+		// runs alongside the viewer, an entry removed while it is read is
+		// just fetched again
+		go cache.GetGlobalCache().Maintain(cache.MaintenanceOptions{
+			MaxAge:        cfg.CacheMaxAge,
+			MaxSize:       cfg.CacheMaxSize,
+			RemoveCorrupt: cfg.CacheRemoveCorrupt,
+		})
 	}
 
 	termCaps := terminal.DetectCapabilities()
 
-	model := ui.NewModel(ui.ModelConfig{
-		Player:     playerService,
-		LrclibURL:  cfg.LrclibURL,
-		SyncOffset: cfg.SyncOffset,
-		HideHeader: cfg.HideHeader,
-		TermCaps:   termCaps,
-	})
+	var levels ui.LevelSource
+	if cfg.Visualizer {
+		cava, err := visualizer.StartCava()
+		if err != nil {
+			return err
+		}
+		defer cava.Stop()
+		levels = cava
+	}
+
+	modelConfig.Player = playerService
+	modelConfig.TermCaps = termCaps
+	modelConfig.Levels = levels
+	model := ui.NewModel(modelConfig)
 
 	p := tea.NewProgram(
 		model,
@@ -98,9 +110,203 @@ func runViewer(cmd *cobra.Command, args []string) error {
 		p.Quit()
 	}()
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("error running bubble tea: %w", err)
 	}
+	if viewer, ok := final.(ui.Model); ok {
+		_ = viewer.SaveHistory()
+	}
 
 	return nil
 }
+
+// loadViewerConfig applies the view flags over the config and turns it into
+// the viewer's settings, the player, terminal and levels are left to the
+// caller
+func loadViewerConfig(cmd *cobra.Command, cfg *config.Config) (ui.ModelConfig, error) {
+	if cmd.Flags().Changed("hide-header") {
+		cfg.HideHeader = hideHeader
+	}
+	if cmd.Flags().Changed("plain") {
+		cfg.PlainText = plainText
+	}
+	if cmd.Flags().Changed("oneline") {
+		cfg.OneLine = oneLine
+	}
+	if cmd.Flags().Changed("karaoke") {
+		cfg.Karaoke = karaoke
+	}
+	if cmd.Flags().Changed("sections") {
+		cfg.Sections = sections
+	}
+	if cmd.Flags().Changed("bg-tint") {
+		cfg.BgTint = bgTint
+	}
+	if cmd.Flags().Changed("backdrop") {
+		cfg.Backdrop = backdrop
+	}
+	if cmd.Flags().Changed("status-bar") {
+		cfg.StatusBar = statusBar
+	}
+	if cmd.Flags().Changed("visualizer") {
+		cfg.Visualizer = showVisualizer
+	}
+	if cmd.Flags().Changed("context-lines") {
+		cfg.ContextLines = contextLines
+	}
+	if endBehavior != "" {
+		cfg.EndBehavior = endBehavior
+	}
+
+	endMode, err := ui.ParseEndBehavior(cfg.EndBehavior)
+	if err != nil {
+		return ui.ModelConfig{}, err
+	}
+
+	if layoutName != "" {
+		cfg.Layout = layoutName
+	}
+	layout, err := ui.ParseLayout(cfg.Layout)
+	if err != nil {
+		return ui.ModelConfig{}, err
+	}
+
+	if len(volumeKeys) > 0 {
+		cfg.VolumeKeys = volumeKeys
+	}
+	if len(cfg.VolumeKeys) != 2 {
+		return ui.ModelConfig{}, fmt.Errorf("volume keys need exactly two keys, down and up (got %q)", cfg.VolumeKeys)
+	}
+
+	if cmd.Flags().Changed("scroll-relock") {
+		cfg.ScrollRelock = time.Duration(max(scrollRelock, 0) * float64(time.Second))
+	}
+	if cmd.Flags().Changed("idle-clock") {
+		cfg.IdleClock = time.Duration(max(idleClock, 0) * float64(time.Minute))
+	}
+
+	cachePolicy, err := loadCachePolicy(cfg)
+	if err != nil {
+		return ui.ModelConfig{}, err
+	}
+
+	paletteOverride, err := loadPaletteOverride(cmd, cfg)
+	if err != nil {
+		return ui.ModelConfig{}, err
+	}
+
+	animation, err := loadAnimation(cmd, cfg)
+	if err != nil {
+		return ui.ModelConfig{}, err
+	}
+
+	return ui.ModelConfig{
+		LrclibURL:    cfg.LrclibURL,
+		CachePolicy:  cachePolicy,
+		SyncOffset:   cfg.SyncOffset,
+		HideHeader:   cfg.HideHeader,
+		PlainText:    cfg.PlainText,
+		OneLine:      cfg.OneLine,
+		Karaoke:      cfg.Karaoke,
+		Sections:     cfg.Sections,
+		BgTint:       cfg.BgTint,
+		Backdrop:     cfg.Backdrop,
+		StatusBar:    cfg.StatusBar,
+		EndBehavior:  endMode,
+		Layout:       layout,
+		VolumeKeys:   [2]string{cfg.VolumeKeys[0], cfg.VolumeKeys[1]},
+		RelockAfter:  cfg.ScrollRelock,
+		IdleClock:    cfg.IdleClock,
+		Override:     paletteOverride,
+		Animation:    animation,
+		ContextLines: cfg.ContextLines,
+		History:      cfg.History,
+	}, nil
+}
+
+// loadCachePolicy applies --no-cache over the config
+func loadCachePolicy(cfg *config.Config) (lyrics.CachePolicy, error) {
+	if noCache != "" {
+		cfg.NoCache = noCache
+	}
+	return lyrics.ParseCachePolicy(cfg.NoCache)
+}
+
+// loadPaletteOverride merges the color flags into the config and validates
+// the colors
+func loadPaletteOverride(cmd *cobra.Command, cfg *config.Config) (artwork.Override, error) {
+	if primaryColor != "" {
+		cfg.PrimaryColor = primaryColor
+	}
+	if secondColor != "" {
+		cfg.SecondaryColor = secondColor
+	}
+	if accentColor != "" {
+		cfg.AccentColor = accentColor
+	}
+	if cmd.Flags().Changed("palette-blend") {
+		cfg.PaletteBlend = paletteBlend
+	}
+
+	override := artwork.Override{Blend: max(0, min(cfg.PaletteBlend, 1))}
+	targets := []struct {
+		value string
+		dest  *string
+	}{
+		{cfg.PrimaryColor, &override.Primary},
+		{cfg.SecondaryColor, &override.Secondary},
+		{cfg.AccentColor, &override.Accent},
+	}
+	for _, target := range targets {
+		if target.value == "" {
+			continue
+		}
+		hex, err := colors.ParseHex(target.value)
+		if err != nil {
+			return artwork.Override{}, err
+		}
+		*target.dest = hex
+	}
+
+	return override, nil
+}
+
+// loadAnimation starts from the animation preset and applies the single
+// settings from flags and environment on top
+func loadAnimation(cmd *cobra.Command, cfg *config.Config) (ui.AnimConfig, error) {
+	if animationName != "" {
+		cfg.Animation = animationName
+	}
+	if cmd.Flags().Changed("transition-ticks") {
+		cfg.TransitionTicks = &transitionTicks
+	}
+	if cmd.Flags().Changed("reveal-step") {
+		cfg.RevealStep = &revealStep
+	}
+	if cmd.Flags().Changed("shimmer") {
+		cfg.Shimmer = &shimmer
+	}
+	if cmd.Flags().Changed("glow") {
+		cfg.Glow = &glow
+	}
+
+	animation, err := ui.AnimationPreset(cfg.Animation)
+	if err != nil {
+		return ui.AnimConfig{}, err
+	}
+	if cfg.TransitionTicks != nil {
+		animation.TransitionTicks = *cfg.TransitionTicks
+	}
+	if cfg.RevealStep != nil {
+		animation.RevealStep = *cfg.RevealStep
+	}
+	if cfg.Shimmer != nil {
+		animation.Shimmer = *cfg.Shimmer
+	}
+	if cfg.Glow != nil {
+		animation.Glow = *cfg.Glow
+	}
+
+	return animation, animation.Validate()
+}
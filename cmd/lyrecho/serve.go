@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/server"
+)
+
+var (
+	// flags for serve
+	serveListen       string
+	serveAllowOrigins []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve the current track and lyrics over http",
+	Long: `follows the player without the viewer and answers http requests about what is
+playing, for scripts and apps that can't talk to dbus.
+
+endpoints (json):
+  GET  /track   the track, whether it's playing and the position in seconds
+  GET  /line    the current and next lyric line
+  GET  /lyrics  every line of the track's lyrics with its time
+  GET  /offset  the sync offset of the track
+  POST /offset  {"offset": 0.5} sets it, {"adjust": -0.1} moves it
+  GET  /ws      a websocket pushing track, lyrics, line, playback and
+                position events as they happen
+
+browsers may only connect from localhost pages, --allow-origin adds others.
+requests must name localhost, the --listen address or an allowed origin's
+host, so a page can't reach the server by pointing its own name at it.
+
+examples:
+  lyrecho serve --listen 127.0.0.1:9876
+  curl -s localhost:9876/line
+  curl -s -X POST -H 'Content-Type: application/json' -d '{"adjust": 0.2}' localhost:9876/offset`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		listener, err := net.Listen("tcp", serveListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", serveListen, err)
+		}
+
+		sess, stop, err := startSession(cmd)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		defer stop()
+
+		api := server.New(sess, serveListen, serveAllowOrigins)
+		httpServer := &http.Server{
+			Handler:           api,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		served := make(chan error, 1)
+		go func() {
+			served <- httpServer.Serve(listener)
+		}()
+		fmt.Fprintf(os.Stderr, "listening on http://%s\n", listener.Addr())
+
+		sessionDone := make(chan error, 1)
+		go func() {
+			sessionDone <- sess.Run(ctx, api.Publish)
+		}()
+
+		select {
+		case err = <-served:
+			cancel()
+		case err = <-sessionDone:
+		}
+
+		api.Close()
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancelShutdown()
+		_ = httpServer.Shutdown(shutdownCtx)
+
+		if errors.Is(err, context.Canceled) || errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:9876", "address to listen on")
+	serveCmd.Flags().StringSliceVar(&serveAllowOrigins, "allow-origin", nil, "web page origins besides localhost that may connect from a browser (e.g. https://overlay.example.com)")
+}
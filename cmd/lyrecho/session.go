@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/player"
+	"karolbroda.com/lyrecho/internal/session"
+)
+
+// startSession follows the player like the viewer does, for the commands
+// that print the lyrics instead of showing them. stop releases the player.
+func startSession(cmd *cobra.Command) (*session.Session, func(), error) {
+	playerService, cfg, stop, err := startPlayer(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cachePolicy, err := loadCachePolicy(cfg)
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return session.New(session.Config{
+		Player:     playerService,
+		LrclibURL:  cfg.LrclibURL,
+		Cache:      cachePolicy,
+		SyncOffset: cfg.SyncOffset,
+	}), stop, nil
+}
+
+// startPlayer connects to the player picked by the config and the player
+// flags
+func startPlayer(cmd *cobra.Command) (player.Service, *config.Config, func(), error) {
+	cfg := loadPlayerConfig(cmd)
+
+	playerService, closeBackend, err := newPlayerService(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = playerService.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not watch player: %v\n", err)
+	}
+
+	stop := func() {
+		playerService.Stop()
+		closeBackend()
+	}
+
+	return playerService, cfg, stop, nil
+}
+
+// loadPlayerConfig applies the player and lyrics source flags over the config
+func loadPlayerConfig(cmd *cobra.Command) *config.Config {
+	cfg := config.Load()
+
+	if mprisService != "" {
+		cfg.MprisService = mprisService
+	}
+	if lrclibURL != "" {
+		cfg.LrclibURL = lrclibURL
+	}
+	if cmd.Flags().Changed("sync-offset") {
+		cfg.SyncOffset = syncOffset
+	}
+	if cmd.Flags().Changed("follow") {
+		cfg.Follow = followPlayer
+	}
+	if len(playerOrder) > 0 {
+		cfg.Players = playerOrder
+	}
+	if len(ignorePlayer) > 0 {
+		cfg.IgnorePlayers = ignorePlayer
+	}
+	if backendName != "" {
+		cfg.Backend = backendName
+	}
+	if mpdHost != "" {
+		cfg.MPDHost = mpdHost
+	}
+
+	return cfg
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/terminal"
+	"karolbroda.com/lyrecho/internal/track"
+	"karolbroda.com/lyrecho/internal/ui"
+)
+
+var (
+	// flags for snapshot
+	snapshotOutput  string
+	snapshotPNG     bool
+	snapshotSize    string
+	snapshotTimeout time.Duration
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "save the viewer's frame for the current track",
+	Long: `render what the viewer shows for the current track off screen and save it as
+an .ans file, which cat prints in color, and optionally as a png drawn from
+the same cells. the viewer flags apply, so the snapshot can use another
+layout or font than the running viewer. the S key in the viewer saves both
+formats of what is on screen.
+
+without --output the snapshot is saved to ~/Pictures/lyrecho (or under
+$XDG_PICTURES_DIR), named after the track.`,
+	Example: `  lyrecho snapshot --png
+  lyrecho snapshot --layout side --size 160x45 -o side.png
+  lyrecho snapshot -o - | less -R`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		width, height, err := parseSize(snapshotSize)
+		if err != nil {
+			return err
+		}
+
+		playerService, cfg, stop, err := startPlayer(cmd)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		modelConfig, err := loadViewerConfig(cmd, cfg)
+		if err != nil {
+			return err
+		}
+		modelConfig.Player = playerService
+		modelConfig.TermCaps = &terminal.Capabilities{SupportsRGB: true, ColorDepth: terminal.ColorsTrue}
+		modelConfig.History = false
+
+		lipgloss.SetColorProfile(termenv.TrueColor)
+
+		frame, err := ui.Capture(modelConfig, width, height, snapshotTimeout)
+		if err != nil {
+			return err
+		}
+
+		if snapshotOutput == "-" {
+			return ui.WriteANSI(os.Stdout, frame)
+		}
+
+		paths, err := snapshotPaths(playerService.GetState().Track)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			err := ui.SaveSnapshot(path, frame, false)
+			if err != nil {
+				return fmt.Errorf("failed to save snapshot: %w", err)
+			}
+			fmt.Println(path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "file to save to, a png when it ends in .png, - prints the frame")
+	snapshotCmd.Flags().BoolVar(&snapshotPNG, "png", false, "save a png next to the .ans file")
+	snapshotCmd.Flags().StringVar(&snapshotSize, "size", "120x40", "terminal size to render at, columns x rows")
+	snapshotCmd.Flags().DurationVar(&snapshotTimeout, "timeout", 10*time.Second, "how long to wait for the lyrics and artwork")
+}
+
+// helper functions
+
+// snapshotPaths lists the files to save to: --output, with a png next to it
+// for --png, or files named after the track in the snapshot directory
+func snapshotPaths(trk *track.Info) ([]string, error) {
+	if snapshotOutput != "" {
+		ext := filepath.Ext(snapshotOutput)
+		if !snapshotPNG || strings.EqualFold(ext, ".png") {
+			return []string{snapshotOutput}, nil
+		}
+		return []string{snapshotOutput, strings.TrimSuffix(snapshotOutput, ext) + ".png"}, nil
+	}
+
+	dir, err := ui.SnapshotDir()
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, ui.SnapshotName(trk, time.Now()))
+	paths := []string{base + ".ans"}
+	if snapshotPNG {
+		paths = append(paths, base+".png")
+	}
+	return paths, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/spotify"
+)
+
+var (
+	// flags for spotify login
+	spotifyClientID    string
+	spotifyRedirectURI string
+)
+
+var spotifyCmd = &cobra.Command{
+	Use:   "spotify",
+	Short: "spotify web api login",
+	Long: `manage the login used by --backend spotify, which follows playback
+through the spotify web api instead of a local player.`,
+}
+
+var spotifyLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "authorize lyrecho with your spotify account",
+	Long: `authorize lyrecho with your spotify account.
+
+create an app at https://developer.spotify.com/dashboard, add the redirect
+uri (default ` + spotify.DefaultRedirectURI + `) and pass its client id.
+no client secret is needed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.Load()
+		if spotifyClientID != "" {
+			cfg.SpotifyClientID = spotifyClientID
+		}
+		if cfg.SpotifyClientID == "" {
+			return errors.New("spotify client id required (use --client-id or LYRECHO_SPOTIFY_CLIENT_ID)")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+
+		token, err := spotify.Login(ctx, cfg.SpotifyClientID, spotifyRedirectURI, func(authURL string) {
+			fmt.Println("open this url to authorize lyrecho:")
+			fmt.Println()
+			fmt.Println("  " + authURL)
+			fmt.Println()
+			_ = openBrowser(authURL)
+			fmt.Println("waiting for spotify...")
+		})
+		if err != nil {
+			return err
+		}
+
+		err = spotify.SaveToken(token)
+		if err != nil {
+			return fmt.Errorf("failed to save token: %w", err)
+		}
+
+		path, _ := spotify.TokenPath()
+		fmt.Printf("logged in, token saved to %s\n", path)
+		return nil
+	},
+}
+
+var spotifyLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "forget the saved spotify token",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := spotify.DeleteToken()
+		if err != nil {
+			return err
+		}
+		fmt.Println("logged out")
+		return nil
+	},
+}
+
+var spotifyStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show what is playing on spotify",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := spotify.NewClient()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		playback, err := client.CurrentlyPlaying(ctx)
+		if err != nil {
+			return err
+		}
+
+		if playback == nil || playback.Item == nil {
+			fmt.Println("logged in, nothing playing")
+			return nil
+		}
+
+		item := playback.Item
+		state := "paused"
+		if playback.Playing {
+			state = "playing"
+		}
+
+		fmt.Printf("%s - %s (%s)\n", item.Artist, item.Title, state)
+		fmt.Printf("position: %s / %s\n",
+			formatDuration(playback.ProgressMs/1000), formatDuration(item.DurationMs/1000))
+		return nil
+	},
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+func init() {
+	rootCmd.AddCommand(spotifyCmd)
+
+	spotifyCmd.AddCommand(spotifyLoginCmd)
+	spotifyCmd.AddCommand(spotifyLogoutCmd)
+	spotifyCmd.AddCommand(spotifyStatusCmd)
+
+	spotifyLoginCmd.Flags().StringVar(&spotifyClientID, "client-id", "", "spotify app client id")
+	spotifyLoginCmd.Flags().StringVar(&spotifyRedirectURI, "redirect-uri", spotify.DefaultRedirectURI, "redirect uri registered for the app")
+}
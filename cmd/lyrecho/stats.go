@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/history"
+	"karolbroda.com/lyrecho/internal/lyrics"
+)
+
+var (
+	// flags for stats
+	reportSince string
+	reportTop   int
+	reportJSON  bool
+)
+
+// statsReport is what stats prints, and the json of --json
+type statsReport struct {
+	Since *time.Time `json:"since,omitempty"`
+
+	Plays  int `json:"plays"`
+	Tracks int `json:"tracks"`
+	// SyncedTracks counts the played tracks that had synced lyrics
+	SyncedTracks int     `json:"synced_tracks"`
+	Listened     float64 `json:"listened_seconds"`
+	LyricsTime   float64 `json:"lyrics_seconds"`
+	// LinesShown is the share of synced lines reached over all plays
+	LinesShown float64 `json:"lines_shown"`
+	// CacheHits counts plays whose lyrics came from the cache, Lookups
+	// every play lyrics were looked up for
+	CacheHits int `json:"cache_hits"`
+	Lookups   int `json:"lookups"`
+
+	CachedSongs  int `json:"cached_songs"`
+	CachedSynced int `json:"cached_synced"`
+	CachedPlain  int `json:"cached_plain"`
+
+	TopArtists []artistPlays `json:"top_artists"`
+}
+
+type artistPlays struct {
+	Artist   string  `json:"artist"`
+	Plays    int     `json:"plays"`
+	Listened float64 `json:"listened_seconds"`
+	// Synced counts the plays that had synced lyrics
+	Synced int `json:"synced"`
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "summarize listening and lyrics coverage",
+	Long: `summarizes the listening history and the cache: how many of the tracks played
+had synced lyrics, how long lyrics were on screen, how often the cache had
+them, and the artists played most.
+
+--since takes a duration back from now (24h, 7d) or a date (2006-01-02).`,
+	Example: `  lyrecho stats
+  lyrecho stats --since 30d --top 5`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var since time.Time
+		if reportSince != "" {
+			var err error
+			since, err = parseSince(reportSince, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		plays, err := history.Load(since)
+		if err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+
+		entries, err := cache.GetGlobalCache().ListAll()
+		if err != nil {
+			return fmt.Errorf("failed to list cache: %w", err)
+		}
+
+		report := buildStatsReport(plays, entries, reportTop)
+		if !since.IsZero() {
+			report.Since = &since
+		}
+
+		if reportJSON {
+			return printJSON(report)
+		}
+		printStatsReport(report)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVar(&reportSince, "since", "", "only plays since a duration ago (24h, 7d) or a date (2006-01-02)")
+	statsCmd.Flags().IntVar(&reportTop, "top", 10, "how many artists to list (0 for all)")
+	statsCmd.Flags().BoolVar(&reportJSON, "json", false, "print the report as json")
+}
+
+// helper functions
+
+func buildStatsReport(plays []history.Play, entries []*cache.LyricEntry, top int) statsReport {
+	report := statsReport{Plays: len(plays), TopArtists: []artistPlays{}}
+
+	tracks := make(map[string]bool)
+	synced := make(map[string]bool)
+	byArtist := make(map[string]*artistPlays)
+	var lines, shown int
+
+	for _, play := range plays {
+		key := strings.ToLower(play.Artist) + "|" + strings.ToLower(play.Title)
+		tracks[key] = true
+		if play.Lines > 0 {
+			synced[key] = true
+		}
+
+		report.Listened += play.Listened
+		report.LyricsTime += play.LyricsTime
+		lines += play.Lines
+		shown += play.LinesShown
+
+		// plays without a source found no lyrics, which still missed the cache
+		report.Lookups++
+		if play.Source == lyrics.SourceCache {
+			report.CacheHits++
+		}
+
+		artistKey := strings.ToLower(play.Artist)
+		artist, ok := byArtist[artistKey]
+		if !ok {
+			artist = &artistPlays{Artist: play.Artist}
+			byArtist[artistKey] = artist
+		}
+		artist.Plays++
+		artist.Listened += play.Listened
+		if play.Lines > 0 {
+			artist.Synced++
+		}
+	}
+
+	report.Tracks = len(tracks)
+	report.SyncedTracks = len(synced)
+	if lines > 0 {
+		report.LinesShown = float64(shown) / float64(lines)
+	}
+
+	for _, artist := range byArtist {
+		report.TopArtists = append(report.TopArtists, *artist)
+	}
+	sort.Slice(report.TopArtists, func(i, j int) bool {
+		a, b := report.TopArtists[i], report.TopArtists[j]
+		if a.Plays != b.Plays {
+			return a.Plays > b.Plays
+		}
+		return strings.ToLower(a.Artist) < strings.ToLower(b.Artist)
+	})
+	if top > 0 && len(report.TopArtists) > top {
+		report.TopArtists = report.TopArtists[:top]
+	}
+
+	coverage := summarizeCache(entries)
+	report.CachedSongs = len(entries)
+	report.CachedSynced = coverage.synced
+	report.CachedPlain = coverage.plain
+
+	return report
+}
+
+func printStatsReport(report statsReport) {
+	if report.Since != nil {
+		fmt.Printf("listening since %s:\n", report.Since.Local().Format("2006-01-02 15:04"))
+	} else {
+		fmt.Println("listening:")
+	}
+
+	if report.Plays == 0 {
+		fmt.Println("  no plays recorded, the viewer records them as you listen")
+	} else {
+		fmt.Printf("  plays:            %d (%d tracks)\n", report.Plays, report.Tracks)
+		fmt.Printf("  listened:         %s\n", formatSpan(report.Listened))
+		fmt.Printf("  lyrics on screen: %s\n", formatSpan(report.LyricsTime))
+		fmt.Printf("  synced lyrics:    %d of %d tracks (%s)\n", report.SyncedTracks, report.Tracks, percent(report.SyncedTracks, report.Tracks))
+		fmt.Printf("  lines shown:      %d%%\n", int(report.LinesShown*100))
+		fmt.Printf("  cache hits:       %d of %d lookups (%s)\n", report.CacheHits, report.Lookups, percent(report.CacheHits, report.Lookups))
+	}
+
+	fmt.Println()
+	fmt.Println("cache:")
+	fmt.Printf("  songs:            %d\n", report.CachedSongs)
+	fmt.Printf("  synced:           %d (%s)\n", report.CachedSynced, percent(report.CachedSynced, report.CachedSongs))
+	fmt.Printf("  plain only:       %d (%s)\n", report.CachedPlain, percent(report.CachedPlain, report.CachedSongs))
+
+	if len(report.TopArtists) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("top artists:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ARTIST\tPLAYS\tLISTENED\tSYNCED")
+	for _, artist := range report.TopArtists {
+		fmt.Fprintf(w, "  %s\t%d\t%s\t%s\n", artist.Artist, artist.Plays, formatSpan(artist.Listened), percent(artist.Synced, artist.Plays))
+	}
+	w.Flush()
+}
+
+// formatSpan shows a long stretch of time in hours and minutes
+func formatSpan(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+func percent(part int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", part*100/total)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/rivo/uniseg"
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/session"
+)
+
+var (
+	// flags for status
+	statusFormat   string
+	statusMaxWidth int
+)
+
+// waybarStatus is what waybar reads with "return-type": "json"
+type waybarStatus struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	Class   string `json:"class"`
+	Alt     string `json:"alt"`
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "print a status bar line with the track and current lyric",
+	Long: `follows the player and prints a new line whenever the track, the current lyric
+or the playback state changes, for status bars that read a script's output
+continuously.
+
+formats:
+  plain    the text as is
+  polybar  the text with polybar's format tags escaped
+  waybar   json with text, tooltip and a class of playing, paused or stopped
+
+waybar module:
+  "custom/lyrics": {
+    "exec": "lyrecho status --format waybar --max-width 60",
+    "return-type": "json"
+  }
+
+polybar module:
+  [module/lyrics]
+  type = custom/script
+  exec = lyrecho status --format polybar --max-width 60
+  tail = true`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := strings.ToLower(statusFormat)
+		switch format {
+		case "plain", "polybar", "waybar":
+		default:
+			return fmt.Errorf("unknown status format %q (use plain, polybar or waybar)", statusFormat)
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		sess, stop, err := startSession(cmd)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		printed := ""
+		err = sess.Run(ctx, func(event session.Event) {
+			if event.Type == session.EventPosition {
+				return
+			}
+			line, err := formatStatus(event.Snapshot, format, statusMaxWidth)
+			if err != nil || line == printed {
+				return
+			}
+			printed = line
+			fmt.Println(line)
+		})
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	},
+}
+
+// formatStatus renders the status line: the current lyric after the track,
+// or just the track between lyric lines
+func formatStatus(snap session.Snapshot, format string, maxWidth int) (string, error) {
+	trackText := ""
+	if snap.Track != nil {
+		trackText = snap.Track.Title
+		if snap.Track.Artist != "" {
+			trackText = snap.Track.Artist + " - " + trackText
+		}
+	}
+
+	text := trackText
+	if line, ok := snap.Line(0); ok && snap.Playing {
+		if lyric := pipeText(line.Text); lyric != "" {
+			text = trackText + ": " + lyric
+		}
+	}
+	if maxWidth > 0 {
+		text = truncateStatus(text, maxWidth)
+	}
+
+	switch format {
+	case "polybar":
+		// polybar reads %{...} as format tags
+		return strings.ReplaceAll(text, "%", "%%"), nil
+
+	case "waybar":
+		class := "stopped"
+		if snap.Track != nil {
+			class = "paused"
+			if snap.Playing {
+				class = "playing"
+			}
+		}
+		tooltip := trackText
+		if snap.Track != nil && snap.Track.Album != "" {
+			tooltip += "\n" + snap.Track.Album
+		}
+		// waybar parses text and tooltip as pango markup
+		data, err := json.Marshal(waybarStatus{
+			Text:    html.EscapeString(text),
+			Tooltip: html.EscapeString(tooltip),
+			Class:   class,
+			Alt:     class,
+		})
+		return string(data), err
+
+	default:
+		return text, nil
+	}
+}
+
+// truncateStatus shortens text to maxWidth columns with a trailing ellipsis,
+// keeping grapheme clusters whole
+func truncateStatus(text string, maxWidth int) string {
+	if uniseg.StringWidth(text) <= maxWidth {
+		return text
+	}
+
+	var kept strings.Builder
+	width := 0
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		clusterWidth := graphemes.Width()
+		if width+clusterWidth > maxWidth-1 {
+			break
+		}
+		kept.WriteString(graphemes.Str())
+		width += clusterWidth
+	}
+
+	return kept.String() + "…"
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringVar(&statusFormat, "format", "plain", "output format: plain, polybar, waybar")
+	statusCmd.Flags().IntVar(&statusMaxWidth, "max-width", 0, "cut the text to this many columns (0 for no limit)")
+}
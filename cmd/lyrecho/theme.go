@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/colors"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/lyrics"
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+var (
+	// flags for theme export
+	themeFormat string
+	themeOutput string
+	themeName   string
+)
+
+// schemeFormats are the terminals theme export writes colorschemes for
+var schemeFormats = []string{"alacritty", "kitty", "wezterm"}
+
+// the hues the ansi colors keep, so red still reads as red in a scheme
+// tinted by the artwork
+var ansiHues = [6]string{
+	"#CC4444", // red
+	"#44AA55", // green
+	"#CCAA44", // yellow
+	"#4477CC", // blue
+	"#AA55AA", // magenta
+	"#44AAAA", // cyan
+}
+
+// how far the ansi hues move towards the artwork colors. colors are mixed in
+// rgb here, blending in lch would turn the hues themselves.
+const ansiTint = 0.3
+
+var themeCmd = &cobra.Command{
+	Use:   "theme",
+	Short: "use the artwork colors outside lyrecho",
+}
+
+var themeExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "write the current track's colors as a terminal colorscheme",
+	Long: fmt.Sprintf(`turn the palette extracted from the artwork of what is playing into a
+colorscheme for alacritty, kitty or wezterm. the background is the darkened
+artwork tint, the foreground the primary color, and the ansi colors are the
+usual hues tinted along the palette gradient.
+
+--theme exports a built-in theme instead of the current track. the color
+flags (--primary, --secondary, --accent) apply like in the viewer.
+
+writes to stdout unless -o is given.
+
+themes: %s`, strings.Join(artwork.ThemeNames(), ", ")),
+	Example: `  lyrecho theme export --format kitty -o ~/.config/kitty/lyrecho.conf
+  lyrecho theme export --format alacritty > ~/.config/alacritty/lyrecho.toml
+  lyrecho theme export --format wezterm --theme ocean`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := strings.ToLower(themeFormat)
+		if !isSchemeFormat(format) {
+			return fmt.Errorf("unknown format %q, expected one of: %s", themeFormat, strings.Join(schemeFormats, ", "))
+		}
+
+		cfg := config.Load()
+		override, err := loadPaletteOverride(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		var palette *artwork.Palette
+		var name string
+		if themeName != "" {
+			palette, err = artwork.ThemePalette(themeName)
+			if err != nil {
+				return err
+			}
+			name = "lyrecho " + themeName
+		} else {
+			palette, name, err = currentPalette(cmd)
+			if err != nil {
+				return err
+			}
+		}
+		if !override.IsZero() {
+			palette = override.Apply(palette)
+		}
+
+		scheme := newColorScheme(palette)
+		var out string
+		switch format {
+		case "alacritty":
+			out = scheme.alacritty(name)
+		case "kitty":
+			out = scheme.kitty(name)
+		default:
+			out = scheme.wezterm(name)
+		}
+
+		if themeOutput == "" || themeOutput == "-" {
+			_, err = os.Stdout.WriteString(out)
+			return err
+		}
+
+		err = os.WriteFile(themeOutput, []byte(out), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write colorscheme: %w", err)
+		}
+
+		fmt.Printf("wrote %s colorscheme for %s to %s\n", format, name, themeOutput)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(themeCmd)
+	themeCmd.AddCommand(themeExportCmd)
+
+	themeExportCmd.Flags().StringVar(&themeFormat, "format", "alacritty", "colorscheme format: "+strings.Join(schemeFormats, ", "))
+	themeExportCmd.Flags().StringVarP(&themeOutput, "output", "o", "", "file to write, stdout when empty")
+	themeExportCmd.Flags().StringVar(&themeName, "theme", "", "export a built-in theme instead of the current track")
+
+	_ = themeExportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(schemeFormats, cobra.ShellCompDirectiveNoFileComp))
+	_ = themeExportCmd.RegisterFlagCompletionFunc("theme", cobra.FixedCompletions(artwork.ThemeNames(), cobra.ShellCompDirectiveNoFileComp))
+}
+
+// helper functions
+
+func isSchemeFormat(format string) bool {
+	for _, f := range schemeFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// currentPalette reads the palette of the playing track's artwork, from the
+// cache when the viewer already extracted it
+func currentPalette(cmd *cobra.Command) (*artwork.Palette, string, error) {
+	playerService, cfg, stop, err := startPlayer(cmd)
+	if err != nil {
+		return nil, "", err
+	}
+	defer stop()
+
+	cachePolicy, err := loadCachePolicy(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = playerService.Poll()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read player: %w", err)
+	}
+	trk := playerService.GetState().Track
+	if trk == nil {
+		return nil, "", errors.New("no track is playing, use --theme to export a built-in theme")
+	}
+
+	name := trk.Artist + " - " + trk.Album
+	if trk.Album == "" {
+		name = trk.Artist + " - " + trk.Title
+	}
+	if trk.ArtworkURL == "" {
+		return nil, "", fmt.Errorf("%s has no artwork to take colors from", name)
+	}
+
+	palette, err := trackPalette(trk, cachePolicy)
+	if err != nil {
+		return nil, "", err
+	}
+	return palette, name, nil
+}
+
+// trackPalette reads the palette of a track's artwork, from the cache when
+// the viewer already extracted it and extracting and caching it otherwise.
+// the cache policy can skip either step like for lyrics.
+func trackPalette(trk *track.Info, cachePolicy lyrics.CachePolicy) (*artwork.Palette, error) {
+	diskCache := cache.GetGlobalCache()
+	if cachePolicy.Reads() {
+		palette, err := diskCache.GetPalette(trk.ArtworkURL)
+		if err == nil {
+			return palette, nil
+		}
+	}
+
+	img, err := artwork.Fetch(trk.ArtworkURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch artwork: %w", err)
+	}
+	palette := artwork.ExtractPalette(img)
+	if cachePolicy.Writes() {
+		_ = diskCache.SetPalette(trk.ArtworkURL, palette)
+	}
+	return palette, nil
+}
+
+// colorScheme is a palette spread over the colors a terminal asks for
+type colorScheme struct {
+	background string
+	foreground string
+	cursor     string
+	selection  string
+	// normal and bright are ansi colors 0-7 and 8-15: black, red, green,
+	// yellow, blue, magenta, cyan, white
+	normal [8]string
+	bright [8]string
+}
+
+func newColorScheme(palette *artwork.Palette) colorScheme {
+	background := palette.BackgroundTint()
+	if background == "" {
+		background = mixRGB(palette.Base(), palette.Primary, 0.1)
+	}
+	// brightening moves away from the background, darkening on light ones
+	highlight := "#FFFFFF"
+	if palette.Light {
+		highlight = "#000000"
+	}
+
+	scheme := colorScheme{
+		background: background,
+		foreground: palette.Primary,
+		cursor:     palette.Accent,
+		selection:  mixRGB(background, palette.Accent, 0.3),
+	}
+
+	scheme.normal[0] = mixRGB(background, palette.Dim, 0.3)
+	scheme.bright[0] = palette.Dim
+	for i, hue := range ansiHues {
+		tint := palette.Accent
+		if len(palette.Gradient) > 0 {
+			tint = palette.Gradient[i*(len(palette.Gradient)-1)/(len(ansiHues)-1)]
+		}
+		scheme.normal[i+1] = mixRGB(hue, tint, ansiTint)
+		scheme.bright[i+1] = mixRGB(scheme.normal[i+1], highlight, 0.25)
+	}
+	scheme.normal[7] = mixRGB(palette.Primary, background, 0.2)
+	scheme.bright[7] = mixRGB(palette.Primary, highlight, 0.3)
+
+	return scheme
+}
+
+func mixRGB(hex1 string, hex2 string, t float64) string {
+	r1, g1, b1 := colors.HexToRGB(hex1)
+	r2, g2, b2 := colors.HexToRGB(hex2)
+	mix := func(a int, b int) int { return int(math.Round(float64(a) + t*float64(b-a))) }
+	return colors.RGBToHex(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
+
+var ansiNames = [8]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+func (s colorScheme) alacritty(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s, generated by lyrecho\n\n", name)
+	fmt.Fprintf(&b, "[colors.primary]\nbackground = %q\nforeground = %q\n\n", s.background, s.foreground)
+	fmt.Fprintf(&b, "[colors.cursor]\ncursor = %q\ntext = %q\n\n", s.cursor, s.background)
+	fmt.Fprintf(&b, "[colors.selection]\nbackground = %q\ntext = %q\n", s.selection, s.foreground)
+	for _, group := range []struct {
+		name   string
+		colors [8]string
+	}{{"normal", s.normal}, {"bright", s.bright}} {
+		fmt.Fprintf(&b, "\n[colors.%s]\n", group.name)
+		for i, color := range group.colors {
+			fmt.Fprintf(&b, "%s = %q\n", ansiNames[i], color)
+		}
+	}
+	return b.String()
+}
+
+func (s colorScheme) kitty(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s, generated by lyrecho\n\n", name)
+	fmt.Fprintf(&b, "foreground %s\nbackground %s\n", s.foreground, s.background)
+	fmt.Fprintf(&b, "cursor %s\ncursor_text_color %s\n", s.cursor, s.background)
+	fmt.Fprintf(&b, "selection_foreground %s\nselection_background %s\n\n", s.foreground, s.selection)
+	for i, color := range s.normal {
+		fmt.Fprintf(&b, "# %s\ncolor%d %s\ncolor%d %s\n", ansiNames[i], i, color, i+8, s.bright[i])
+	}
+	return b.String()
+}
+
+func (s colorScheme) wezterm(name string) string {
+	quoted := func(list [8]string) string {
+		parts := make([]string, len(list))
+		for i, color := range list {
+			parts[i] = fmt.Sprintf("%q", color)
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s, generated by lyrecho\n\n", name)
+	fmt.Fprintf(&b, "[colors]\nforeground = %q\nbackground = %q\n", s.foreground, s.background)
+	fmt.Fprintf(&b, "cursor_bg = %q\ncursor_fg = %q\ncursor_border = %q\n", s.cursor, s.background, s.cursor)
+	fmt.Fprintf(&b, "selection_bg = %q\nselection_fg = %q\n", s.selection, s.foreground)
+	fmt.Fprintf(&b, "ansi = [%s]\nbrights = [%s]\n\n", quoted(s.normal), quoted(s.bright))
+	fmt.Fprintf(&b, "[metadata]\nname = %q\n", name)
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/rivo/uniseg"
+	"github.com/spf13/cobra"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/session"
+)
+
+var (
+	// flags for tmux
+	tmuxMaxWidth int
+	tmuxNoColor  bool
+	tmuxTheme    string
+)
+
+// tmuxSeparator goes between the track and the lyric
+const tmuxSeparator = " · "
+
+var tmuxCmd = &cobra.Command{
+	Use:   "tmux",
+	Short: "print a tmux status line segment with the track and current lyric",
+	Long: `prints the track and, while playing, the current lyric once, colored with the
+artwork palette as tmux styles and cut to --max-width columns. # in titles
+and lyrics is escaped, so the segment can go straight into status-right.
+tmux runs it again every status-interval seconds. nothing is printed while
+no track is playing or no player is running.
+
+the palette comes from the cache when the viewer already extracted it,
+the default colors are used otherwise. the artwork is never downloaded
+here, tmux would do it every few seconds. --theme colors the segment with a built-in theme instead, --no-color leaves
+the colors to the status line style.
+
+~/.tmux.conf:
+  set -g status-interval 2
+  set -g status-right-length 80
+  set -g status-right '#(lyrecho tmux --max-width 70) %H:%M'`,
+	Example: `  lyrecho tmux
+  lyrecho tmux --max-width 40 --theme ember`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		sess, stop, err := startSession(cmd)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		// without a player the segment is left empty like without a track,
+		// the status line has no room for an error
+		snap, err := sess.Once(ctx)
+		if err != nil || snap.Track == nil {
+			return nil
+		}
+
+		var palette *artwork.Palette
+		if !tmuxNoColor {
+			palette, err = tmuxPalette(cmd, snap)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Println(formatTmux(snap, palette, tmuxMaxWidth))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tmuxCmd)
+
+	tmuxCmd.Flags().IntVar(&tmuxMaxWidth, "max-width", 60, "cut the segment to this many columns (0 for no limit)")
+	tmuxCmd.Flags().BoolVar(&tmuxNoColor, "no-color", false, "print the text without tmux styles")
+	tmuxCmd.Flags().StringVar(&tmuxTheme, "theme", "", "color with a built-in theme instead of the artwork")
+
+	_ = tmuxCmd.RegisterFlagCompletionFunc("theme", cobra.FixedCompletions(artwork.ThemeNames(), cobra.ShellCompDirectiveNoFileComp))
+}
+
+// helper functions
+
+// tmuxPalette picks the colors of the segment: the theme, or the cached
+// palette of the track's artwork with the color flags applied
+func tmuxPalette(cmd *cobra.Command, snap session.Snapshot) (*artwork.Palette, error) {
+	if tmuxTheme != "" {
+		return artwork.ThemePalette(tmuxTheme)
+	}
+
+	cfg := config.Load()
+	override, err := loadPaletteOverride(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cachePolicy, err := loadCachePolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	palette := artwork.DefaultPalette()
+	if snap.Track.ArtworkURL != "" && cachePolicy.Reads() {
+		// a miss keeps the default colors until the viewer or theme export
+		// extracts the palette
+		if cached, err := cache.GetGlobalCache().GetPalette(snap.Track.ArtworkURL); err == nil {
+			palette = cached
+		}
+	}
+	if !override.IsZero() {
+		palette = override.Apply(palette)
+	}
+	return palette, nil
+}
+
+// formatTmux renders the segment: the track, then the current lyric while
+// playing. a nil palette leaves out the styles.
+func formatTmux(snap session.Snapshot, palette *artwork.Palette, maxWidth int) string {
+	trackText := snap.Track.Title
+	if snap.Track.Artist != "" {
+		trackText = snap.Track.Artist + " - " + trackText
+	}
+
+	lyric := ""
+	if line, ok := snap.Line(0); ok && snap.Playing {
+		lyric = pipeText(line.Text)
+	}
+
+	if maxWidth > 0 {
+		trackText, lyric = fitTmux(trackText, lyric, maxWidth)
+	}
+
+	trackColor, separatorColor, lyricColor := "", "", ""
+	if palette != nil {
+		trackColor, separatorColor, lyricColor = palette.Secondary, palette.Dim, palette.Primary
+		if !snap.Playing {
+			trackColor = palette.Dim
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(tmuxStyle(trackColor))
+	b.WriteString(escapeTmux(trackText))
+	if lyric != "" {
+		b.WriteString(tmuxStyle(separatorColor))
+		b.WriteString(tmuxSeparator)
+		b.WriteString(tmuxStyle(lyricColor))
+		b.WriteString(escapeTmux(lyric))
+	}
+	if palette != nil {
+		// the rest of status-right goes back to the status line style
+		b.WriteString("#[default]")
+	}
+	return b.String()
+}
+
+// fitTmux cuts the track and the lyric to share maxWidth columns. the lyric
+// gets the room the track doesn't need, but the track keeps at least a third.
+func fitTmux(trackText string, lyric string, maxWidth int) (string, string) {
+	trackWidth := uniseg.StringWidth(trackText)
+	if lyric == "" {
+		return truncateStatus(trackText, maxWidth), ""
+	}
+
+	lyricWidth := uniseg.StringWidth(lyric)
+	separatorWidth := uniseg.StringWidth(tmuxSeparator)
+	if trackWidth+separatorWidth+lyricWidth <= maxWidth {
+		return trackText, lyric
+	}
+
+	trackBudget := min(trackWidth, max(maxWidth/3, maxWidth-separatorWidth-lyricWidth))
+	lyricBudget := maxWidth - separatorWidth - trackBudget
+	if lyricBudget < 2 {
+		return truncateStatus(trackText, maxWidth), ""
+	}
+	return truncateStatus(trackText, trackBudget), truncateStatus(lyric, lyricBudget)
+}
+
+// tmuxStyle sets the foreground to a palette color, nothing for no color
+func tmuxStyle(hex string) string {
+	if hex == "" {
+		return ""
+	}
+	return "#[fg=" + strings.ToLower(hex) + "]"
+}
+
+// escapeTmux keeps tmux from reading # in the text as the start of a format
+func escapeTmux(text string) string {
+	return strings.ReplaceAll(text, "#", "##")
+}
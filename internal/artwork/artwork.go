@@ -18,6 +18,7 @@ import (
 	"github.com/nfnt/resize"
 
 	"karolbroda.com/lyrecho/internal/colors"
+	"karolbroda.com/lyrecho/internal/httpclient"
 )
 
 type Palette struct {
@@ -27,6 +28,91 @@ type Palette struct {
 	Dim          string
 	Gradient     []string
 	GradientInfo string // describes which color pair was selected for gradient
+	// Dominant is the most common artwork color, empty without artwork
+	Dominant string
+	// Light marks colors darkened for a terminal with a light background
+	Light bool
+}
+
+const (
+	// backgroundTintBrightness darkens the dominant color into a background
+	backgroundTintBrightness = 0.2
+	// text on the tint has to stay at least this readable
+	minPrimaryContrast = 7.0
+	minDimContrast     = 3.0
+)
+
+// BackgroundTint returns a heavily darkened dominant color to put behind the
+// lyrics, darkened further until the text colors stay readable on it. it is
+// empty when the palette didn't come from artwork.
+func (p *Palette) BackgroundTint() string {
+	if p.Dominant == "" {
+		return ""
+	}
+
+	// a light palette gets a pale tint, lightened instead of darkened
+	step := func(tint string) string { return colors.AdjustBrightness(tint, 0.7) }
+	tint := colors.AdjustBrightness(p.Dominant, backgroundTintBrightness)
+	if p.Light {
+		step = func(tint string) string { return colors.BlendColors(tint, "#FFFFFF", 0.3) }
+		tint = colors.BlendColors(p.Dominant, "#FFFFFF", 1-backgroundTintBrightness)
+	}
+
+	for range 8 {
+		if colors.ContrastRatio(tint, p.Primary) >= minPrimaryContrast &&
+			colors.ContrastRatio(tint, p.Dim) >= minDimContrast {
+			break
+		}
+		tint = step(tint)
+	}
+
+	return tint
+}
+
+// Base is the color the palette fades into, the terminal background it
+// was made for
+func (p *Palette) Base() string {
+	if p.Light {
+		return "#FFFFFF"
+	}
+	return "#000000"
+}
+
+const (
+	// on a light background colors are darkened until they are this
+	// readable against white
+	minLightTextContrast = 4.5
+	minLightDimContrast  = 2.5
+)
+
+// ForLightBackground darkens the colors for terminals with a light
+// background, where the brightened artwork colors would wash out
+func (p *Palette) ForLightBackground() *Palette {
+	if p.Light {
+		return p
+	}
+
+	light := *p
+	light.Light = true
+	light.Primary = darkenForLight(p.Primary, minLightTextContrast)
+	light.Secondary = darkenForLight(p.Secondary, minLightTextContrast)
+	light.Accent = darkenForLight(p.Accent, minLightTextContrast)
+	light.Dim = darkenForLight(p.Dim, minLightDimContrast)
+	light.Gradient = make([]string, len(p.Gradient))
+	for i, color := range p.Gradient {
+		light.Gradient[i] = darkenForLight(color, minLightTextContrast)
+	}
+	return &light
+}
+
+func darkenForLight(color string, contrast float64) string {
+	for range 16 {
+		if colors.ContrastRatio(color, "#FFFFFF") >= contrast {
+			break
+		}
+		color = colors.AdjustBrightness(color, 0.85)
+	}
+	return color
 }
 
 func Fetch(artworkURL string) (image.Image, error) {
@@ -57,7 +143,7 @@ func Fetch(artworkURL string) (image.Image, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Get().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch artwork: %w", err)
 	}
@@ -187,6 +273,9 @@ func ExtractPalette(img image.Image) *Palette {
 	// intelligently select the best color pair for the gradient
 	gradStart, gradEnd, gradientInfo := selectBestGradientPair(primaryColor, secondaryColor, accentColor)
 
+	// kmeans results are sorted by how many pixels they cover
+	dominant := extractedColors[0].Color
+
 	return &Palette{
 		Primary:      primaryColor,
 		Secondary:    secondaryColor,
@@ -194,6 +283,7 @@ func ExtractPalette(img image.Image) *Palette {
 		Dim:          "#6272A4",
 		Gradient:     colors.GenerateGradient(gradStart, gradEnd, 20),
 		GradientInfo: gradientInfo,
+		Dominant:     colors.RGBToHex(int(dominant.R), int(dominant.G), int(dominant.B)),
 	}
 }
 
@@ -286,6 +376,37 @@ func boostColor(r, g, b uint32, brightness float64) string {
 	return fmt.Sprintf("#%02X%02X%02X", r, g, b)
 }
 
+// blurSize is how many pixels the artwork is shrunk to before it is
+// stretched back up, which blurs it without a real filter
+const blurSize = 6
+
+// BlurredPixels returns a blurred copy of the image stretched to width by
+// height pixels, as rows of colors scaled by brightness
+func BlurredPixels(img image.Image, width int, height int, brightness float64) [][]string {
+	if img == nil || width <= 0 || height <= 0 {
+		return nil
+	}
+
+	small := resize.Resize(blurSize, blurSize, img, resize.Bilinear)
+	stretched := resize.Resize(uint(width), uint(height), small, resize.Bilinear)
+	bounds := stretched.Bounds()
+
+	pixels := make([][]string, height)
+	for y := range pixels {
+		pixels[y] = make([]string, width)
+		for x := range pixels[y] {
+			r, g, b, _ := stretched.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			pixels[y][x] = colors.RGBToHex(
+				int(float64(r>>8)*brightness),
+				int(float64(g>>8)*brightness),
+				int(float64(b>>8)*brightness),
+			)
+		}
+	}
+
+	return pixels
+}
+
 func RenderHalfBlockArt(img image.Image, targetWidth int, targetHeight int) []string {
 	if img == nil || targetWidth < 4 || targetHeight < 2 {
 		return nil
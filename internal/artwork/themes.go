@@ -0,0 +1,94 @@
+package artwork
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"karolbroda.com/lyrecho/internal/colors"
+)
+
+// themes are hand-picked palettes that don't depend on album artwork.
+// each entry is primary, secondary, accent.
+var themes = map[string][3]string{
+	"default": {"#8BA4E8", "#E8A4C8", "#B8A8E8"},
+	"ember":   {"#FF9E64", "#F7768E", "#E0AF68"},
+	"ocean":   {"#7DCFFF", "#7AA2F7", "#2AC3DE"},
+	"forest":  {"#9ECE6A", "#73DACA", "#B9F27C"},
+	"dusk":    {"#BB9AF7", "#F7768E", "#C0CAF5"},
+	"mono":    {"#E0E0E0", "#8A8A8A", "#B4B4B4"},
+}
+
+// ThemeNames returns the names of the built-in themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemePalette returns the palette of a built-in theme
+func ThemePalette(name string) (*Palette, error) {
+	theme, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return NewPalette(theme[0], theme[1], theme[2]), nil
+}
+
+// NewPalette builds a palette from three colors, picking the smoothest gradient pair
+func NewPalette(primary string, secondary string, accent string) *Palette {
+	gradStart, gradEnd, gradientInfo := selectBestGradientPair(primary, secondary, accent)
+
+	return &Palette{
+		Primary:      primary,
+		Secondary:    secondary,
+		Accent:       accent,
+		Dim:          "#6272A4",
+		Gradient:     colors.GenerateGradient(gradStart, gradEnd, 20),
+		GradientInfo: gradientInfo,
+	}
+}
+
+// Override replaces or tints palette colors with ones picked by the user, for
+// when extracted colors clash with the terminal colorscheme
+type Override struct {
+	Primary   string
+	Secondary string
+	Accent    string
+	// Blend is how far to move towards the override colors, 1 replaces them
+	Blend float64
+}
+
+// IsZero reports whether the override leaves every color alone
+func (o Override) IsZero() bool {
+	return o.Primary == "" && o.Secondary == "" && o.Accent == ""
+}
+
+// Apply returns the palette with the override colors mixed in, colors left
+// empty keep the palette's own
+func (o Override) Apply(palette *Palette) *Palette {
+	if o.IsZero() || palette == nil {
+		return palette
+	}
+
+	blend := func(base string, override string) string {
+		if override == "" {
+			return base
+		}
+		if o.Blend >= 1 {
+			return override
+		}
+		return colors.BlendColors(base, override, max(o.Blend, 0))
+	}
+
+	result := NewPalette(
+		blend(palette.Primary, o.Primary),
+		blend(palette.Secondary, o.Secondary),
+		blend(palette.Accent, o.Accent),
+	)
+	result.Dominant = palette.Dominant
+	return result
+}
@@ -17,12 +17,16 @@ const (
 	defaultTTLDays  = 30
 	cacheDirName    = "lyric-shower"
 	lyricsCacheName = "lyrics"
+	// lockFileName is locked from reading an entry through writing it back,
+	// so instances sharing the cache don't drop each other's changes
+	lockFileName = ".lock"
 )
 
 var (
 	ErrCacheMiss    = errors.New("cache miss")
 	ErrCacheExpired = errors.New("cache expired")
 	ErrCacheCorrupt = errors.New("cache corrupt")
+	ErrCacheVersion = errors.New("cache version mismatch")
 )
 
 type LyricEntry struct {
@@ -37,12 +41,19 @@ type LyricEntry struct {
 	SyncOffset   float64
 	CreatedAt    int64
 	ExpiresAt    int64
+	// Translation is the lyrics.Layer last picked for lyrics with a
+	// translation, empty shows both
+	Translation string
 }
 
 type DiskCache struct {
 	basePath string
 	mu       sync.RWMutex
 	memCache map[string]*LyricEntry
+	// files are the files the memory entries were read from or written to.
+	// writes replace the file, so when another instance writes one the
+	// memory entry is read again.
+	files map[string]os.FileInfo
 }
 
 var (
@@ -57,6 +68,7 @@ func GetGlobalCache() *DiskCache {
 			cache = &DiskCache{
 				basePath: "",
 				memCache: make(map[string]*LyricEntry),
+				files:    make(map[string]os.FileInfo),
 			}
 		}
 		globalCache = cache
@@ -79,6 +91,7 @@ func NewDiskCache() (*DiskCache, error) {
 	return &DiskCache{
 		basePath: lyricsPath,
 		memCache: make(map[string]*LyricEntry),
+		files:    make(map[string]os.FileInfo),
 	}, nil
 }
 
@@ -120,16 +133,15 @@ func (c *DiskCache) Get(artist, title string) (*LyricEntry, error) {
 	// check memory cache first
 	c.mu.RLock()
 	entry, exists := c.memCache[key]
+	file := c.files[key]
 	c.mu.RUnlock()
 
 	if exists {
-		if entry.ExpiresAt > time.Now().Unix() {
+		if entry.ExpiresAt > time.Now().Unix() && sameFile(c.stat(key), file) {
 			return entry, nil
 		}
-		// expired in memory, remove it
-		c.mu.Lock()
-		delete(c.memCache, key)
-		c.mu.Unlock()
+		// expired, or changed on disk by another instance
+		c.forget(key)
 	}
 
 	// fall back to disk cache
@@ -137,6 +149,22 @@ func (c *DiskCache) Get(artist, title string) (*LyricEntry, error) {
 		return nil, ErrCacheMiss
 	}
 
+	unlock, err := c.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return c.load(key)
+}
+
+// load reads an entry from disk into memory, removing it when expired. the
+// caller holds the lock.
+func (c *DiskCache) load(key string) (*LyricEntry, error) {
+	if c.basePath == "" {
+		return nil, ErrCacheMiss
+	}
+
 	filePath := c.getFilePath(key)
 	entry, err := c.readFromDisk(filePath)
 	if err != nil {
@@ -146,13 +174,12 @@ func (c *DiskCache) Get(artist, title string) (*LyricEntry, error) {
 	// validate expiry
 	if entry.ExpiresAt <= time.Now().Unix() {
 		_ = os.Remove(filePath)
+		c.forget(key)
 		return nil, ErrCacheExpired
 	}
 
 	// populate memory cache
-	c.mu.Lock()
-	c.memCache[key] = entry
-	c.mu.Unlock()
+	c.remember(key, entry)
 
 	return entry, nil
 }
@@ -170,20 +197,142 @@ func (c *DiskCache) Set(artist, title string, entry *LyricEntry) error {
 	entry.CreatedAt = now
 	entry.ExpiresAt = now + int64(defaultTTLDays*24*60*60)
 
-	// store in memory
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// persist to disk, then remember the time of the written file
+	if c.basePath != "" {
+		err := c.writeToDisk(c.getFilePath(key), entry)
+		if err != nil {
+			c.forget(key)
+			return err
+		}
+	}
+
+	c.remember(key, entry)
+	return nil
+}
+
+// SetTranslation saves the translation layer picked for a cached song
+func (c *DiskCache) SetTranslation(artist, title string, translation string) error {
+	if artist == "" || title == "" {
+		return ErrCacheMiss
+	}
+
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// read again under the lock, another instance may have changed the entry
+	key := generateKey(artist, title)
+	entry, err := c.load(key)
+	if err != nil {
+		return err
+	}
+
+	entry.Translation = translation
+	err = c.writeToDisk(c.getFilePath(key), entry)
+	if err != nil {
+		c.forget(key)
+		return err
+	}
+	c.remember(key, entry)
+	return nil
+}
+
+// remember keeps an entry in memory along with its file
+func (c *DiskCache) remember(key string, entry *LyricEntry) {
+	file := c.stat(key)
+
 	c.mu.Lock()
 	c.memCache[key] = entry
+	c.files[key] = file
 	c.mu.Unlock()
+}
+
+func (c *DiskCache) forget(key string) {
+	c.mu.Lock()
+	delete(c.memCache, key)
+	delete(c.files, key)
+	c.mu.Unlock()
+}
 
-	// persist to disk
+// stat describes an entry's file, nil when there is no file
+func (c *DiskCache) stat(key string) os.FileInfo {
 	if c.basePath == "" {
 		return nil
 	}
+	info, err := os.Stat(c.getFilePath(key))
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// sameFile reports whether a file is still the one an entry was read from.
+// a rewrite within the clock's resolution keeps the time, but every write
+// renames a new file into place.
+func sameFile(a os.FileInfo, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime())
+}
+
+// lock takes the cache's lock, shared with other lyrecho instances and held
+// from reading what is changed until it is written. it isn't reentrant, so
+// the unexported helpers below expect the caller to hold it. the returned
+// function releases it.
+func (c *DiskCache) lock() (func(), error) {
+	if c.basePath == "" {
+		return func() {}, nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(c.basePath, lockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
 
-	return c.writeToDisk(c.getFilePath(key), entry)
+	err = lockFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
 }
 
+// readFromDisk decodes an entry, upgrading older ones in place. the caller
+// holds the lock.
 func (c *DiskCache) readFromDisk(filePath string) (*LyricEntry, error) {
+	entry, err := decodeFile(filePath)
+	if err == ErrCacheVersion {
+		// older entries are upgraded in place, the file is kept when that
+		// isn't possible so "cache migrate" can try again after an update
+		err = migrateEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		err = c.writeToDisk(filePath, entry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// decodeFile reads an entry without touching the file. on a version
+// mismatch the decoded entry is returned along with ErrCacheVersion.
+func decodeFile(filePath string) (*LyricEntry, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -200,15 +349,14 @@ func (c *DiskCache) readFromDisk(filePath string) (*LyricEntry, error) {
 		return nil, ErrCacheCorrupt
 	}
 
-	// version mismatch means stale format
 	if entry.Version != cacheVersion {
-		_ = os.Remove(filePath)
-		return nil, ErrCacheCorrupt
+		return &entry, ErrCacheVersion
 	}
 
 	return &entry, nil
 }
 
+// writeToDisk replaces an entry's file. the caller holds the lock.
 func (c *DiskCache) writeToDisk(filePath string, entry *LyricEntry) error {
 	// write to temp file first, then rename for atomicity
 	tmpPath := filePath + ".tmp"
@@ -245,12 +393,21 @@ func (c *DiskCache) writeToDisk(filePath string, entry *LyricEntry) error {
 func (c *DiskCache) Clear() error {
 	c.mu.Lock()
 	c.memCache = make(map[string]*LyricEntry)
+	c.files = make(map[string]os.FileInfo)
 	c.mu.Unlock()
 
 	if c.basePath == "" {
 		return nil
 	}
 
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	c.clearPalettes()
+
 	entries, err := os.ReadDir(c.basePath)
 	if err != nil {
 		return err
@@ -283,16 +440,11 @@ func (c *DiskCache) Prune() (int, error) {
 			continue
 		}
 
-		filePath := filepath.Join(c.basePath, dirEntry.Name())
-		entry, err := c.readFromDisk(filePath)
+		removed, err := c.pruneFile(filepath.Join(c.basePath, dirEntry.Name()), now)
 		if err != nil {
-			_ = os.Remove(filePath)
-			pruned++
-			continue
+			return pruned, err
 		}
-
-		if entry.ExpiresAt <= now {
-			_ = os.Remove(filePath)
+		if removed {
 			pruned++
 		}
 	}
@@ -300,6 +452,29 @@ func (c *DiskCache) Prune() (int, error) {
 	return pruned, nil
 }
 
+// pruneFile removes one cache file when it doesn't decode or has expired.
+// files from another version are kept for cache migrate.
+func (c *DiskCache) pruneFile(filePath string, now int64) (bool, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	entry, err := c.readFromDisk(filePath)
+	if err != nil {
+		if errors.Is(err, ErrCacheCorrupt) {
+			return os.Remove(filePath) == nil, nil
+		}
+		return false, nil
+	}
+
+	if entry.ExpiresAt <= now {
+		return os.Remove(filePath) == nil, nil
+	}
+	return false, nil
+}
+
 func (c *DiskCache) Stats() (count int, sizeBytes int64, err error) {
 	if c.basePath == "" {
 		return 0, 0, nil
@@ -340,6 +515,13 @@ func (c *DiskCache) ListAll() ([]*LyricEntry, error) {
 		return nil, err
 	}
 
+	// older entries are upgraded in place while reading
+	unlock, err := c.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	var result []*LyricEntry
 
 	for _, dirEntry := range entries {
@@ -367,17 +549,21 @@ func (c *DiskCache) Delete(artist, title string) error {
 	key := generateKey(artist, title)
 
 	// remove from memory cache
-	c.mu.Lock()
-	delete(c.memCache, key)
-	c.mu.Unlock()
+	c.forget(key)
 
 	// remove from disk
 	if c.basePath == "" {
 		return nil
 	}
 
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	filePath := c.getFilePath(key)
-	err := os.Remove(filePath)
+	err = os.Remove(filePath)
 	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
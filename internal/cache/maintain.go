@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaintenanceOptions says what Maintain removes besides expired entries
+type MaintenanceOptions struct {
+	// MaxAge removes entries cached longer ago, 0 keeps them until they expire
+	MaxAge time.Duration
+	// MaxSize removes the oldest entries until the cache fits, 0 is no limit
+	MaxSize int64
+	// RemoveCorrupt removes files that don't decode. files from another
+	// version are always kept for cache migrate.
+	RemoveCorrupt bool
+}
+
+// MaintenanceResult counts the entries Maintain removed
+type MaintenanceResult struct {
+	Expired int
+	TooOld  int
+	Corrupt int
+	// OverSize counts entries removed to get under MaxSize
+	OverSize int
+}
+
+// Removed is the total number of entries removed
+func (r MaintenanceResult) Removed() int {
+	return r.Expired + r.TooOld + r.Corrupt + r.OverSize
+}
+
+// keptFile is a cache file Maintain left in place, a candidate for MaxSize
+type keptFile struct {
+	path      string
+	size      int64
+	createdAt int64
+}
+
+// Maintain removes expired entries, and entries past the limits in opts
+func (c *DiskCache) Maintain(opts MaintenanceOptions) (MaintenanceResult, error) {
+	var result MaintenanceResult
+	if c.basePath == "" {
+		return result, nil
+	}
+
+	dirEntries, err := os.ReadDir(c.basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	var kept []keptFile
+	var totalSize int64
+
+	now := time.Now()
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ".bin") {
+			continue
+		}
+
+		file, ok, err := c.maintainFile(filepath.Join(c.basePath, name), opts, now, &result)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			kept = append(kept, file)
+			totalSize += file.size
+		}
+	}
+
+	if opts.MaxSize > 0 && totalSize > opts.MaxSize {
+		sort.Slice(kept, func(i, j int) bool {
+			return kept[i].createdAt < kept[j].createdAt
+		})
+		for _, file := range kept {
+			if totalSize <= opts.MaxSize {
+				break
+			}
+			if c.removeLocked(file.path) {
+				totalSize -= file.size
+				result.OverSize++
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.memCache = make(map[string]*LyricEntry)
+	c.files = make(map[string]os.FileInfo)
+	c.mu.Unlock()
+
+	return result, nil
+}
+
+// maintainFile removes one cache file when it is expired, too old or corrupt,
+// counting it in result, and describes the file otherwise. it holds the lock
+// from reading the file until it is removed, so an entry written meanwhile by
+// another instance isn't lost.
+func (c *DiskCache) maintainFile(filePath string, opts MaintenanceOptions, now time.Time, result *MaintenanceResult) (keptFile, bool, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return keptFile{}, false, err
+	}
+	defer unlock()
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return keptFile{}, false, nil
+	}
+
+	entry, err := decodeFile(filePath)
+	if errors.Is(err, ErrCacheVersion) {
+		err = migrateEntry(entry)
+	}
+	switch {
+	case errors.Is(err, ErrCacheCorrupt):
+		if opts.RemoveCorrupt && os.Remove(filePath) == nil {
+			result.Corrupt++
+		}
+		return keptFile{}, false, nil
+	case err != nil:
+		// newer entries, and older ones without a migration, wait for an
+		// update and cache migrate
+		return keptFile{}, false, nil
+	case entry.ExpiresAt <= now.Unix():
+		if os.Remove(filePath) == nil {
+			result.Expired++
+		}
+		return keptFile{}, false, nil
+	case opts.MaxAge > 0 && entry.CreatedAt < now.Add(-opts.MaxAge).Unix():
+		if os.Remove(filePath) == nil {
+			result.TooOld++
+		}
+		return keptFile{}, false, nil
+	}
+
+	return keptFile{path: filePath, size: info.Size(), createdAt: entry.CreatedAt}, true, nil
+}
+
+// removeLocked removes a cache file under the lock, reporting whether it did
+func (c *DiskCache) removeLocked(filePath string) bool {
+	unlock, err := c.lock()
+	if err != nil {
+		return false
+	}
+	defer unlock()
+	return os.Remove(filePath) == nil
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// migrations upgrade an entry from the version it is keyed by to the next
+// one. gob fills fields by name, so a migration only has to convert what
+// changed meaning. bumping cacheVersion needs a migration from the previous
+// version, otherwise older entries, and the offsets tuned for them, can't
+// be read anymore.
+var migrations = map[uint8]func(entry *LyricEntry) error{
+	// gob leaves out zero fields, so an entry saved without a version reads
+	// as version 0. it has the version 1 layout.
+	0: func(entry *LyricEntry) error { return nil },
+}
+
+// migrateEntry upgrades an entry to cacheVersion one version at a time
+func migrateEntry(entry *LyricEntry) error {
+	if entry.Version > cacheVersion {
+		return fmt.Errorf("%w: version %d is from a newer lyrecho", ErrCacheVersion, entry.Version)
+	}
+
+	for entry.Version < cacheVersion {
+		migrate, ok := migrations[entry.Version]
+		if !ok {
+			return fmt.Errorf("%w: no migration from version %d", ErrCacheVersion, entry.Version)
+		}
+
+		err := migrate(entry)
+		if err != nil {
+			return fmt.Errorf("migrating from version %d: %w", entry.Version, err)
+		}
+		entry.Version++
+	}
+
+	return nil
+}
+
+// MigrateResult summarizes a cache migration
+type MigrateResult struct {
+	// Migrated counts entries upgraded to the current version
+	Migrated int
+	// Current counts entries that were already up to date
+	Current int
+	// Failed lists the files that could not be upgraded, they are kept
+	Failed []FileProblem
+}
+
+// Migrate upgrades every cache file written by an older version, keeping
+// its lyrics and sync offset
+func (c *DiskCache) Migrate() (MigrateResult, error) {
+	var result MigrateResult
+	if c.basePath == "" {
+		return result, nil
+	}
+
+	dirEntries, err := os.ReadDir(c.basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ".bin") {
+			continue
+		}
+
+		err := c.migrateFile(filepath.Join(c.basePath, name), &result)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// migrateFile upgrades one cache file under the lock, counting it in result
+func (c *DiskCache) migrateFile(filePath string, result *MigrateResult) error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entry, err := decodeFile(filePath)
+	if err == nil {
+		result.Current++
+		return nil
+	}
+	if !errors.Is(err, ErrCacheVersion) {
+		result.Failed = append(result.Failed, FileProblem{Path: filePath, Err: err})
+		return nil
+	}
+
+	err = migrateEntry(entry)
+	if err == nil {
+		err = c.writeToDisk(filePath, entry)
+	}
+	if err != nil {
+		result.Failed = append(result.Failed, FileProblem{Path: filePath, Err: err, Entry: entry})
+		return nil
+	}
+	result.Migrated++
+	return nil
+}
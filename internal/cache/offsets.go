@@ -0,0 +1,423 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	offsetsFileName    = "offsets.json"
+	offsetsFileVersion = 1
+	// players and lrclib often disagree on duration by a second or two
+	offsetDurationTolerance = 2.0
+)
+
+// OffsetRecord is a tuned sync offset keyed by track identity, the unit
+// that gets shared between machines and users
+type OffsetRecord struct {
+	Artist   string  `json:"artist"`
+	Title    string  `json:"title"`
+	Duration float64 `json:"duration,omitempty"`
+	Offset   float64 `json:"offset"`
+}
+
+// OffsetFile is the json document offsets are exported to and imported from.
+// records are sorted so the file diffs cleanly when kept in git.
+type OffsetFile struct {
+	Version int            `json:"version"`
+	Offsets []OffsetRecord `json:"offsets"`
+}
+
+// ImportResult summarizes an offset import
+type ImportResult struct {
+	// Applied counts cached entries whose offset was updated
+	Applied int
+	// Stored counts records kept for songs that aren't cached yet
+	Stored int
+	// Skipped counts records ignored because the entry already had an offset
+	Skipped int
+}
+
+// ExportOffsets collects every non-zero sync offset, from cached entries and
+// from previously imported records
+func (c *DiskCache) ExportOffsets() (*OffsetFile, error) {
+	records := make(map[string]OffsetRecord)
+
+	stored, err := c.loadOffsetRecords()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range stored {
+		records[offsetKey(record.Artist, record.Title)] = record
+	}
+
+	entries, err := c.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.SyncOffset == 0 {
+			continue
+		}
+		record := OffsetRecord{
+			Artist:   entry.ArtistName,
+			Title:    entry.TrackName,
+			Duration: math.Round(entry.Duration),
+			Offset:   entry.SyncOffset,
+		}
+		records[offsetKey(record.Artist, record.Title)] = record
+	}
+
+	file := &OffsetFile{Version: offsetsFileVersion}
+	for _, record := range records {
+		file.Offsets = append(file.Offsets, record)
+	}
+	sortOffsetRecords(file.Offsets)
+
+	return file, nil
+}
+
+// ImportOffsets applies shared offsets to matching cached entries and keeps
+// every record so songs fetched later pick up their offset too. entries that
+// already have an offset are left alone unless overwrite is set.
+func (c *DiskCache) ImportOffsets(file *OffsetFile, overwrite bool) (ImportResult, error) {
+	if file == nil {
+		return ImportResult{}, errors.New("invalid offsets file")
+	}
+	if file.Version > offsetsFileVersion {
+		return ImportResult{}, errors.New("offsets file is from a newer version")
+	}
+
+	unlock, err := c.lock()
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer unlock()
+
+	return c.importOffsets(file, overwrite)
+}
+
+// importOffsets is ImportOffsets for a caller holding the lock
+func (c *DiskCache) importOffsets(file *OffsetFile, overwrite bool) (ImportResult, error) {
+	var result ImportResult
+
+	entries, err := c.entriesByKey()
+	if err != nil {
+		return result, err
+	}
+
+	for _, record := range file.Offsets {
+		matched := false
+		for key, entry := range entries {
+			if !offsetMatches(record, entry.ArtistName, entry.TrackName, entry.Duration) {
+				continue
+			}
+			matched = true
+
+			if entry.SyncOffset != 0 && !overwrite {
+				result.Skipped++
+				continue
+			}
+
+			// entries are keyed by the player's names, which may differ from
+			// the lrclib names stored inside, so update them in place
+			entry.SyncOffset = record.Offset
+			err = c.writeToDisk(c.getFilePath(key), entry)
+			if err != nil {
+				return result, err
+			}
+			c.remember(key, entry)
+			result.Applied++
+		}
+
+		if !matched {
+			result.Stored++
+		}
+	}
+
+	stored, err := c.loadOffsetRecords()
+	if err != nil {
+		return result, err
+	}
+
+	merged := make(map[string]OffsetRecord)
+	for _, record := range stored {
+		merged[offsetKey(record.Artist, record.Title)] = record
+	}
+	for _, record := range file.Offsets {
+		key := offsetKey(record.Artist, record.Title)
+		if _, exists := merged[key]; exists && !overwrite {
+			continue
+		}
+		merged[key] = record
+	}
+
+	records := make([]OffsetRecord, 0, len(merged))
+	for _, record := range merged {
+		records = append(records, record)
+	}
+
+	return result, c.saveOffsetRecords(records)
+}
+
+// LookupOffset finds an imported offset for a track that has no cached entry yet
+func (c *DiskCache) LookupOffset(artist, title string, duration float64) (float64, bool) {
+	records, err := c.loadOffsetRecords()
+	if err != nil {
+		return 0, false
+	}
+
+	for _, record := range records {
+		if offsetMatches(record, artist, title, duration) {
+			return record.Offset, true
+		}
+	}
+
+	return 0, false
+}
+
+// EntryFilter selects cached entries by name, compared case-insensitively.
+// empty fields match anything.
+type EntryFilter struct {
+	Artist string
+	Album  string
+	Title  string
+}
+
+// Empty reports whether the filter matches every entry
+func (f EntryFilter) Empty() bool {
+	return f.Artist == "" && f.Album == "" && f.Title == ""
+}
+
+// Matches reports whether the entry passes the filter
+func (f EntryFilter) Matches(entry *LyricEntry) bool {
+	return namesMatch(f.Artist, entry.ArtistName) &&
+		namesMatch(f.Album, entry.AlbumName) &&
+		namesMatch(f.Title, entry.TrackName)
+}
+
+func namesMatch(want, name string) bool {
+	return want == "" || strings.EqualFold(strings.TrimSpace(want), strings.TrimSpace(name))
+}
+
+// SetOffsets sets the sync offset of every cached entry the filter matches,
+// zero clears it. it returns the updated entries.
+func (c *DiskCache) SetOffsets(filter EntryFilter, offset float64) ([]*LyricEntry, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := c.entriesByKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []*LyricEntry
+	for key, entry := range entries {
+		if !filter.Matches(entry) {
+			continue
+		}
+
+		entry.SyncOffset = offset
+		err = c.writeToDisk(c.getFilePath(key), entry)
+		if err != nil {
+			return updated, err
+		}
+		c.remember(key, entry)
+		updated = append(updated, entry)
+
+		err = c.updateOffsetRecords(entry.ArtistName, entry.TrackName, entry.Duration, offset)
+		if err != nil {
+			return updated, err
+		}
+	}
+
+	return updated, nil
+}
+
+// SetOffset sets the sync offset of one song. a song that isn't cached keeps
+// the offset as a record, applied once its lyrics are fetched. it reports
+// whether the song was cached.
+func (c *DiskCache) SetOffset(artist, title string, duration float64, offset float64) (bool, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	// read again under the lock, another instance may have changed the entry
+	key := generateKey(artist, title)
+	entry, err := c.load(key)
+	if err == nil {
+		entry.SyncOffset = offset
+		err = c.writeToDisk(c.getFilePath(key), entry)
+		if err != nil {
+			return true, err
+		}
+		c.remember(key, entry)
+
+		// the entry may be named after lrclib rather than the player
+		err = c.updateOffsetRecords(artist, title, duration, offset)
+		if err != nil {
+			return true, err
+		}
+		return true, c.updateOffsetRecords(entry.ArtistName, entry.TrackName, entry.Duration, offset)
+	}
+
+	if offset == 0 {
+		return false, c.updateOffsetRecords(artist, title, duration, 0)
+	}
+	_, err = c.importOffsets(&OffsetFile{
+		Version: offsetsFileVersion,
+		Offsets: []OffsetRecord{{
+			Artist:   artist,
+			Title:    title,
+			Duration: math.Round(duration),
+			Offset:   offset,
+		}},
+	}, true)
+	return false, err
+}
+
+// updateOffsetRecords gives the stored records of a song a new offset, or
+// drops them for zero, so a changed or cleared offset isn't exported again or
+// put back once the song is fetched anew. the caller holds the lock.
+func (c *DiskCache) updateOffsetRecords(artist, title string, duration float64, offset float64) error {
+	records, err := c.loadOffsetRecords()
+	if err != nil {
+		return err
+	}
+
+	kept := records[:0]
+	changed := false
+	for _, record := range records {
+		if offsetMatches(record, artist, title, duration) {
+			changed = true
+			if offset == 0 {
+				continue
+			}
+			record.Offset = offset
+		}
+		kept = append(kept, record)
+	}
+
+	if !changed {
+		return nil
+	}
+	return c.saveOffsetRecords(kept)
+}
+
+// entriesByKey reads every cached entry along with its cache key. the caller
+// holds the lock.
+func (c *DiskCache) entriesByKey() (map[string]*LyricEntry, error) {
+	result := make(map[string]*LyricEntry)
+	if c.basePath == "" {
+		return result, nil
+	}
+
+	dirEntries, err := os.ReadDir(c.basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ".bin") {
+			continue
+		}
+
+		entry, err := c.readFromDisk(filepath.Join(c.basePath, name))
+		if err != nil {
+			continue
+		}
+
+		result[strings.TrimSuffix(name, ".bin")] = entry
+	}
+
+	return result, nil
+}
+
+func (c *DiskCache) offsetsPath() string {
+	if c.basePath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(c.basePath), offsetsFileName)
+}
+
+func (c *DiskCache) loadOffsetRecords() ([]OffsetRecord, error) {
+	path := c.offsetsPath()
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var file OffsetFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, ErrCacheCorrupt
+	}
+
+	return file.Offsets, nil
+}
+
+// saveOffsetRecords replaces the stored records. the caller holds the lock.
+func (c *DiskCache) saveOffsetRecords(records []OffsetRecord) error {
+	path := c.offsetsPath()
+	if path == "" {
+		return nil
+	}
+
+	sortOffsetRecords(records)
+
+	data, err := json.MarshalIndent(OffsetFile{Version: offsetsFileVersion, Offsets: records}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, append(data, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func offsetKey(artist, title string) string {
+	return strings.ToLower(strings.TrimSpace(artist)) + "|" + strings.ToLower(strings.TrimSpace(title))
+}
+
+// offsetMatches compares names case-insensitively and durations loosely,
+// an unknown duration on either side matches anything
+func offsetMatches(record OffsetRecord, artist, title string, duration float64) bool {
+	if offsetKey(record.Artist, record.Title) != offsetKey(artist, title) {
+		return false
+	}
+	if record.Duration == 0 || duration == 0 {
+		return true
+	}
+	return math.Abs(record.Duration-duration) <= offsetDurationTolerance
+}
+
+func sortOffsetRecords(records []OffsetRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		return offsetKey(records[i].Artist, records[i].Title) < offsetKey(records[j].Artist, records[j].Title)
+	})
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+)
+
+const (
+	palettesCacheName = "palettes"
+	// paletteVersion changes whenever palette extraction does, so palettes
+	// picked the old way are extracted again
+	paletteVersion = 1
+)
+
+// paletteFile is a palette extracted from artwork, saved so it isn't
+// computed again every time the track plays
+type paletteFile struct {
+	Version int              `json:"version"`
+	Key     string           `json:"key"`
+	Palette *artwork.Palette `json:"palette"`
+}
+
+// paletteKey identifies the artwork a palette was extracted from. players
+// often rewrite local artwork files in place, so their key includes the
+// modification time.
+func paletteKey(artworkURL string) string {
+	path, ok := strings.CutPrefix(artworkURL, "file://")
+	if !ok {
+		return artworkURL
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return artworkURL + "|" + info.ModTime().UTC().Format("20060102150405.000000000")
+}
+
+func (c *DiskCache) palettePath(key string) string {
+	if c.basePath == "" || key == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(filepath.Dir(c.basePath), palettesCacheName, hex.EncodeToString(hash[:12])+".json")
+}
+
+// GetPalette returns the palette saved for the artwork at a url
+func (c *DiskCache) GetPalette(artworkURL string) (*artwork.Palette, error) {
+	key := paletteKey(artworkURL)
+	path := c.palettePath(key)
+	if path == "" {
+		return nil, ErrCacheMiss
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	var file paletteFile
+	err = json.Unmarshal(data, &file)
+	if err != nil || file.Palette == nil {
+		return nil, ErrCacheCorrupt
+	}
+	if file.Version != paletteVersion || file.Key != key {
+		return nil, ErrCacheMiss
+	}
+
+	return file.Palette, nil
+}
+
+// SetPalette saves the palette extracted from the artwork at a url
+func (c *DiskCache) SetPalette(artworkURL string, palette *artwork.Palette) error {
+	key := paletteKey(artworkURL)
+	path := c.palettePath(key)
+	if path == "" || palette == nil {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(paletteFile{Version: paletteVersion, Key: key, Palette: palette})
+	if err != nil {
+		return err
+	}
+
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// clearPalettes removes every saved palette
+func (c *DiskCache) clearPalettes() {
+	if c.basePath == "" {
+		return
+	}
+	_ = os.RemoveAll(filepath.Join(filepath.Dir(c.basePath), palettesCacheName))
+}
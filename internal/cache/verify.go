@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileProblem is a cache file that can't be used
+type FileProblem struct {
+	Path string
+	// Err is ErrCacheCorrupt when the file doesn't decode and ErrCacheVersion
+	// when it was written by another cache version
+	Err error
+	// Entry is what could be decoded, nil for corrupt files
+	Entry *LyricEntry
+}
+
+// VerifyResult summarizes a check of every cache file
+type VerifyResult struct {
+	Checked  int
+	Expired  int
+	Problems []FileProblem
+}
+
+// Verify decodes every cache file and reports the ones that are corrupt or
+// from another version. unlike reads, it leaves the files in place.
+func (c *DiskCache) Verify() (VerifyResult, error) {
+	var result VerifyResult
+	if c.basePath == "" {
+		return result, nil
+	}
+
+	dirEntries, err := os.ReadDir(c.basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	now := time.Now().Unix()
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ".bin") {
+			continue
+		}
+
+		result.Checked++
+		filePath := filepath.Join(c.basePath, name)
+		entry, err := decodeFile(filePath)
+		if err != nil {
+			result.Problems = append(result.Problems, FileProblem{Path: filePath, Err: err, Entry: entry})
+			continue
+		}
+		if entry.ExpiresAt <= now {
+			result.Expired++
+		}
+	}
+
+	return result, nil
+}
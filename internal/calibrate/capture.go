@@ -0,0 +1,102 @@
+package calibrate
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// SampleRate is the rate audio is captured at. vocals sit well below 8khz,
+// so 16khz mono keeps the analysis cheap.
+const SampleRate = 16000
+
+const (
+	// LeadIn is how much audio to record before the first lyric, the first
+	// second of it serves as the instrumental baseline
+	LeadIn = 4 * time.Second
+	// Tail is how much to record after the first lyric
+	Tail = 4 * time.Second
+)
+
+// Recording is a chunk of mono audio captured from the default output
+type Recording struct {
+	Samples []float64
+	// Started is the wall-clock time of the first captured sample
+	Started time.Time
+}
+
+// captureCommands are tried in order. both record the monitor of the default
+// sink, i.e. what is currently playing, as raw signed 16-bit little endian.
+var captureCommands = [][]string{
+	{"parec", "--raw", "--format=s16le", "--rate=" + strconv.Itoa(SampleRate), "--channels=1", "--device=@DEFAULT_MONITOR@"},
+	{"pw-record", "--raw", "--format=s16", "--rate=" + strconv.Itoa(SampleRate), "--channels=1", "-P", "{ stream.capture.sink = true }", "-"},
+}
+
+// Capture records the given duration of audio from pulseaudio or pipewire
+func Capture(ctx context.Context, duration time.Duration) (*Recording, error) {
+	var lastErr error
+	for _, args := range captureCommands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+
+		recording, err := captureWith(ctx, args, duration)
+		if err == nil {
+			return recording, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, errors.New("no audio capture tool found (install parec or pw-record)")
+}
+
+func captureWith(ctx context.Context, args []string, duration time.Duration) (*Recording, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		cancel()
+		_ = cmd.Wait()
+	}()
+
+	total := int(duration.Seconds() * SampleRate)
+	buf := make([]byte, total*2)
+
+	// the first read blocks until audio flows, which is when the recording starts
+	firstChunk := SampleRate / 100 * 2
+	_, err = io.ReadFull(stdout, buf[:firstChunk])
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now().Add(-10 * time.Millisecond)
+
+	_, err = io.ReadFull(stdout, buf[firstChunk:])
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]float64, total)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(buf[i*2:]))) / 32768
+	}
+
+	return &Recording{Samples: samples, Started: started}, nil
+}
@@ -0,0 +1,135 @@
+package calibrate
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	frameSeconds = 0.02
+	// the start of a recording is treated as the instrumental baseline
+	baselineSeconds = 1.0
+	// an onset has to stand out from the baseline by this many deviations
+	onsetDeviations = 4.0
+	// and carry at least this much more energy than the baseline average
+	onsetRatio = 2.0
+	// frames above the threshold needed within the confirmation window
+	confirmFrames = 4
+	confirmWindow = 6
+
+	butterworthQ = 1 / math.Sqrt2
+)
+
+// ErrNoOnset is returned when nothing in the recording looks like vocals starting
+var ErrNoOnset = errors.New("no vocal onset detected")
+
+// DetectOnset returns the time in seconds, relative to the start of the
+// samples, at which energy in the vocal band first rises clearly above the
+// baseline. it's a heuristic: loud instrumental entries can trigger it too.
+func DetectOnset(samples []float64, sampleRate int) (float64, error) {
+	filtered := vocalBand(samples, float64(sampleRate))
+
+	frameSize := int(frameSeconds * float64(sampleRate))
+	if frameSize == 0 {
+		return 0, errors.New("sample rate too low")
+	}
+
+	var energies []float64
+	for start := 0; start+frameSize <= len(filtered); start += frameSize {
+		sum := 0.0
+		for _, v := range filtered[start : start+frameSize] {
+			sum += v * v
+		}
+		energies = append(energies, sum/float64(frameSize))
+	}
+
+	baselineFrames := int(baselineSeconds / frameSeconds)
+	if len(energies) <= baselineFrames+confirmWindow {
+		return 0, errors.New("recording too short")
+	}
+
+	mean, deviation := meanAndDeviation(energies[:baselineFrames])
+	threshold := math.Max(mean+onsetDeviations*deviation, mean*onsetRatio)
+	// a silent baseline would make any noise count as an onset
+	threshold = math.Max(threshold, 1e-6)
+
+	for i := baselineFrames; i+confirmWindow <= len(energies); i++ {
+		if energies[i] < threshold {
+			continue
+		}
+
+		above := 0
+		for _, e := range energies[i : i+confirmWindow] {
+			if e >= threshold {
+				above++
+			}
+		}
+		if above >= confirmFrames {
+			return float64(i) * frameSeconds, nil
+		}
+	}
+
+	return 0, ErrNoOnset
+}
+
+// vocalBand keeps roughly 300hz-3.4khz where most of the voice energy sits,
+// using a high-pass followed by a low-pass biquad
+func vocalBand(samples []float64, sampleRate float64) []float64 {
+	out := biquad(samples, sampleRate, 300, true)
+	return biquad(out, sampleRate, 3400, false)
+}
+
+// biquad applies a butterworth high- or low-pass filter (rbj cookbook)
+func biquad(samples []float64, sampleRate float64, cutoff float64, highPass bool) []float64 {
+	w0 := 2 * math.Pi * cutoff / sampleRate
+	alpha := math.Sin(w0) / (2 * butterworthQ)
+	cosW0 := math.Cos(w0)
+
+	var b0, b1, b2 float64
+	if highPass {
+		b0 = (1 + cosW0) / 2
+		b1 = -(1 + cosW0)
+		b2 = (1 + cosW0) / 2
+	} else {
+		b0 = (1 - cosW0) / 2
+		b1 = 1 - cosW0
+		b2 = (1 - cosW0) / 2
+	}
+	a0 := 1 + alpha
+	a1 := -2 * cosW0
+	a2 := 1 - alpha
+
+	out := make([]float64, len(samples))
+	var x1, x2, y1, y2 float64
+	for i, x := range samples {
+		y := (b0*x + b1*x1 + b2*x2 - a1*y1 - a2*y2) / a0
+		x2, x1 = x1, x
+		y2, y1 = y1, y
+		out[i] = y
+	}
+
+	return out
+}
+
+func meanAndDeviation(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	return mean, math.Sqrt(variance)
+}
+
+// Offset returns the sync offset that moves a lyric timestamp onto the
+// detected onset. captureSecs is the playback position the recording started
+// at and onsetSecs the onset within the recording.
+func Offset(lyricSecs float64, captureSecs float64, onsetSecs float64) float64 {
+	return lyricSecs - (captureSecs + onsetSecs)
+}
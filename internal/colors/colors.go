@@ -431,6 +431,47 @@ func hslToRGB(h float64, s float64, l float64) (int, int, int) {
 	return clampInt(r, 0, 255), clampInt(g, 0, 255), clampInt(b, 0, 255)
 }
 
+// ContrastRatio returns the wcag contrast ratio between two colors, from 1
+// (identical) to 21 (black on white)
+func ContrastRatio(hex1 string, hex2 string) float64 {
+	l1 := relativeLuminance(hex1)
+	l2 := relativeLuminance(hex2)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+func relativeLuminance(hex string) float64 {
+	r, g, b := HexToRGB(hex)
+
+	linear := func(c int) float64 {
+		v := float64(c) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+// ParseHex validates a user supplied color, accepting #rrggbb and #rgb with or
+// without the hash, and returns it as #RRGGBB
+func ParseHex(s string) (string, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return "", fmt.Errorf("invalid color %q (want #rrggbb)", s)
+	}
+
+	return RGBToHex(int(value>>16), int(value>>8&0xFF), int(value&0xFF)), nil
+}
+
 func HexToRGB(hex string) (int, int, int) {
 	hex = strings.TrimPrefix(hex, "#")
 	if len(hex) != 6 {
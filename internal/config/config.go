@@ -1,8 +1,8 @@
 package config
 
 import (
-	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,37 +11,233 @@ const (
 	DefaultLrclibGetURL = "https://lrclib.net/api/get"
 	HTTPTimeoutSeconds  = 10
 	PollInterval        = 100 * time.Millisecond
+	PausedPollInterval  = time.Second
+	DefaultEndBehavior  = "idle"
+	DefaultLayout       = "stacked"
+	DefaultBackend      = "auto"
+	DefaultVolumeKeys   = "[,]"
+	DefaultScrollRelock = 5 * time.Second
+	DefaultPaletteBlend = 1.0
+	DefaultAnimation    = "default"
+	// DefaultContextLines picks the count from the terminal height
+	DefaultContextLines = -1
 )
 
 type Config struct {
-	MprisService string
-	LrclibURL    string
-	SyncOffset   float64
-	HideHeader   bool
+	MprisService  string
+	LrclibURL     string
+	SyncOffset    float64
+	HideHeader    bool
+	PlainText     bool
+	OneLine       bool
+	Karaoke       bool
+	Sections      bool
+	BgTint        bool
+	Backdrop      bool
+	StatusBar     bool
+	Visualizer    bool
+	ContextLines  int
+	Proxy         string
+	NoCache       string
+	EndBehavior   string
+	Layout        string
+	Follow        bool
+	Players       []string
+	IgnorePlayers []string
+	Backend       string
+	MPDHost       string
+	MPDPort       string
+
+	SpotifyClientID string
+	// VolumeKeys are the keys that lower and raise the volume
+	VolumeKeys []string
+	// ScrollRelock is how long the view stays where it was scrolled to
+	// before following the playing line again, 0 never re-locks on its own
+	ScrollRelock time.Duration
+	// IdleClock is how long playback stays paused before a clock replaces
+	// the lyrics, 0 never shows it
+	IdleClock time.Duration
+
+	// PrimaryColor, SecondaryColor and AccentColor override the colors taken
+	// from the artwork, PaletteBlend says how much (1 replaces them)
+	PrimaryColor   string
+	SecondaryColor string
+	AccentColor    string
+	PaletteBlend   float64
+
+	// Animation is the preset the animation starts from, the settings after
+	// it replace single values of the preset when set
+	Animation       string
+	TransitionTicks *int
+	RevealStep      *float64
+	Shimmer         *bool
+	Glow            *float64
+
+	// CacheMaintenance prunes the cache in the background on start: expired
+	// entries always, entries older than CacheMaxAge, the oldest entries
+	// past CacheMaxSize bytes, and files that don't decode with CacheRemoveCorrupt
+	CacheMaintenance   bool
+	CacheMaxAge        time.Duration
+	CacheMaxSize       int64
+	CacheRemoveCorrupt bool
+
+	// History records the tracks played in the viewer for lyrecho history
+	History bool
 }
 
 func Load() *Config {
-	syncOffsetStr := getEnvOrDefault("SYNC_OFFSET", "0")
+	// a broken config file only loses the lines that don't parse
+	values, _ := ReadFile()
+	src := source{file: values}
+
+	syncOffsetStr := src.getOr("SYNC_OFFSET", "0")
 	syncOffset, err := strconv.ParseFloat(syncOffsetStr, 64)
 	if err != nil {
 		syncOffset = 0
 	}
 
-	hideHeaderStr := getEnvOrDefault("HIDE_HEADER", "false")
-	hideHeader := hideHeaderStr == "1" || hideHeaderStr == "true" || hideHeaderStr == "yes"
+	scrollRelock := DefaultScrollRelock
+	relockSecs, err := strconv.ParseFloat(src.get("LYRECHO_SCROLL_RELOCK"), 64)
+	if err == nil && relockSecs >= 0 {
+		scrollRelock = time.Duration(relockSecs * float64(time.Second))
+	}
+
+	paletteBlend, err := strconv.ParseFloat(src.get("LYRECHO_PALETTE_BLEND"), 64)
+	if err != nil {
+		paletteBlend = DefaultPaletteBlend
+	}
+
+	contextLines := DefaultContextLines
+	if value := src.int("LYRECHO_CONTEXT_LINES"); value != nil {
+		contextLines = *value
+	}
+
+	var idleClock time.Duration
+	if minutes := src.float("LYRECHO_IDLE_CLOCK"); minutes != nil && *minutes > 0 {
+		idleClock = time.Duration(*minutes * float64(time.Minute))
+	}
 
-	return &Config{
-		MprisService: getEnvOrDefault("MPRIS_SERVICE", DefaultMprisService),
-		LrclibURL:    getEnvOrDefault("LRCLIB_GET_URL", DefaultLrclibGetURL),
-		SyncOffset:   syncOffset,
-		HideHeader:   hideHeader,
+	var cacheMaxAge time.Duration
+	if days := src.float("LYRECHO_CACHE_MAX_AGE"); days != nil && *days > 0 {
+		cacheMaxAge = time.Duration(*days * float64(24*time.Hour))
 	}
+
+	var cacheMaxSize int64
+	if megabytes := src.float("LYRECHO_CACHE_MAX_SIZE"); megabytes != nil && *megabytes > 0 {
+		cacheMaxSize = int64(*megabytes * 1024 * 1024)
+	}
+
+	cfg := &Config{
+		MprisService:  src.getOr("MPRIS_SERVICE", DefaultMprisService),
+		LrclibURL:     src.getOr("LRCLIB_GET_URL", DefaultLrclibGetURL),
+		SyncOffset:    syncOffset,
+		HideHeader:    src.boolOr("HIDE_HEADER", false),
+		PlainText:     src.boolOr("LYRECHO_PLAIN_TEXT", false),
+		OneLine:       src.boolOr("LYRECHO_ONELINE", false),
+		Karaoke:       src.boolOr("LYRECHO_KARAOKE", false),
+		Sections:      src.boolOr("LYRECHO_SECTIONS", false),
+		BgTint:        src.boolOr("LYRECHO_BG_TINT", false),
+		Backdrop:      src.boolOr("LYRECHO_BACKDROP", false),
+		StatusBar:     src.boolOr("LYRECHO_STATUS_BAR", false),
+		Visualizer:    src.boolOr("LYRECHO_VISUALIZER", false),
+		ContextLines:  contextLines,
+		Proxy:         src.get("LYRECHO_PROXY"),
+		NoCache:       src.get("LYRECHO_NO_CACHE"),
+		EndBehavior:   src.getOr("LYRECHO_END_BEHAVIOR", DefaultEndBehavior),
+		Layout:        src.getOr("LYRECHO_LAYOUT", DefaultLayout),
+		Follow:        src.boolOr("LYRECHO_FOLLOW", false),
+		Players:       splitList(src.get("LYRECHO_PLAYERS")),
+		IgnorePlayers: splitList(src.get("LYRECHO_IGNORE_PLAYERS")),
+		Backend:       src.getOr("LYRECHO_BACKEND", DefaultBackend),
+		MPDHost:       src.get("MPD_HOST"),
+		MPDPort:       src.get("MPD_PORT"),
+
+		SpotifyClientID: src.get("LYRECHO_SPOTIFY_CLIENT_ID"),
+		VolumeKeys:      splitList(src.getOr("LYRECHO_VOLUME_KEYS", DefaultVolumeKeys)),
+		ScrollRelock:    scrollRelock,
+		IdleClock:       idleClock,
+
+		PrimaryColor:   src.get("LYRECHO_PRIMARY"),
+		SecondaryColor: src.get("LYRECHO_SECONDARY"),
+		AccentColor:    src.get("LYRECHO_ACCENT"),
+		PaletteBlend:   paletteBlend,
+
+		Animation:       src.getOr("LYRECHO_ANIMATION", DefaultAnimation),
+		TransitionTicks: src.int("LYRECHO_TRANSITION_TICKS"),
+		RevealStep:      src.float("LYRECHO_REVEAL_STEP"),
+		Shimmer:         src.bool("LYRECHO_SHIMMER"),
+		Glow:            src.float("LYRECHO_GLOW"),
+
+		CacheMaintenance:   src.boolOr("LYRECHO_CACHE_MAINTENANCE", true),
+		CacheMaxAge:        cacheMaxAge,
+		CacheMaxSize:       cacheMaxSize,
+		CacheRemoveCorrupt: src.boolOr("LYRECHO_CACHE_REMOVE_CORRUPT", false),
+
+		History: src.boolOr("LYRECHO_HISTORY", true),
+	}
+
+	// a broken state file only loses the remembered view
+	if state, err := LoadState(); err == nil {
+		state.apply(cfg)
+	}
+
+	return cfg
 }
 
-func getEnvOrDefault(key string, fallback string) string {
-	value := os.Getenv(key)
+// int, float and bool read optional settings, nil when unset or unparsable
+func (s source) int(key string) *int {
+	value, err := strconv.Atoi(s.get(key))
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+func (s source) float(key string) *float64 {
+	value, err := strconv.ParseFloat(s.get(key), 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+func (s source) bool(key string) *bool {
+	var value bool
+	switch strings.ToLower(s.get(key)) {
+	case "1", "true", "yes":
+		value = true
+	case "0", "false", "no":
+		value = false
+	default:
+		return nil
+	}
+	return &value
+}
+
+// boolOr reads a switch, fallback when unset or unparsable
+func (s source) boolOr(key string, fallback bool) bool {
+	if value := s.bool(key); value != nil {
+		return *value
+	}
+	return fallback
+}
+
+func (s source) getOr(key string, fallback string) string {
+	value := s.get(key)
 	if value == "" {
 		return fallback
 	}
 	return value
 }
+
+// splitList parses a comma separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
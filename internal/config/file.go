@@ -0,0 +1,330 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const configFileName = "config"
+
+// KeyKind says what values a key takes
+type KeyKind int
+
+const (
+	KindString KeyKind = iota
+	KindBool
+	KindInt
+	KindFloat
+	// KindList is comma separated
+	KindList
+)
+
+// Key is a setting that can be put in the config file. the environment
+// variable overrides the file, the flag overrides both.
+type Key struct {
+	Name    string
+	Env     string
+	Flag    string
+	Kind    KeyKind
+	Default string
+	Help    string
+}
+
+// Keys are the settings of the config file, in the order it lists them
+var Keys = []Key{
+	{"mpris_service", "MPRIS_SERVICE", "mpris-service", KindString, DefaultMprisService, "mpris service name of the player to show"},
+	{"backend", "LYRECHO_BACKEND", "backend", KindString, DefaultBackend, "player backend: auto, mpris, smtc, mpd or spotify"},
+	{"follow", "LYRECHO_FOLLOW", "follow", KindBool, "false", "follow whichever mpris player starts playing"},
+	{"players", "LYRECHO_PLAYERS", "players", KindList, "", "players to follow in priority order, e.g. spotify,mpv"},
+	{"ignore_players", "LYRECHO_IGNORE_PLAYERS", "ignore-player", KindList, "", "globs of players to skip, e.g. *firefox*"},
+	{"mpd_host", "MPD_HOST", "mpd-host", KindString, "", "mpd server for the mpd backend (default localhost)"},
+	{"mpd_port", "MPD_PORT", "", KindString, "", "mpd port for the mpd backend (default 6600)"},
+	{"spotify_client_id", "LYRECHO_SPOTIFY_CLIENT_ID", "", KindString, "", "spotify app client id for lyrecho spotify login"},
+	{"lrclib_url", "LRCLIB_GET_URL", "lrclib-url", KindString, DefaultLrclibGetURL, "lrclib api endpoint"},
+	{"proxy", "LYRECHO_PROXY", "proxy", KindString, "", "proxy for lyrics and artwork requests (http://, socks5://)"},
+	{"no_cache", "LYRECHO_NO_CACHE", "no-cache", KindString, "", "skip the lyrics cache: read, write or all"},
+	{"sync_offset", "SYNC_OFFSET", "sync-offset", KindFloat, "0", "sync offset in seconds for songs without their own"},
+	{"layout", "LYRECHO_LAYOUT", "layout", KindString, DefaultLayout, "screen layout: stacked, side or columns"},
+	{"hide_header", "HIDE_HEADER", "hide-header", KindBool, "false", "hide the header section"},
+	{"context_lines", "LYRECHO_CONTEXT_LINES", "context-lines", KindInt, strconv.Itoa(DefaultContextLines), "lines shown around the current one, -1 to fit the terminal"},
+	{"plain_text", "LYRECHO_PLAIN_TEXT", "plain", KindBool, "false", "draw lyrics as plain text instead of the pixel font"},
+	{"oneline", "LYRECHO_ONELINE", "oneline", KindBool, "false", "show only the current lyric on a single row"},
+	{"karaoke", "LYRECHO_KARAOKE", "karaoke", KindBool, "false", "sweep the current line from dim to lit as it is sung"},
+	{"sections", "LYRECHO_SECTIONS", "sections", KindBool, "false", "tint the chorus and name the song sections"},
+	{"end_behavior", "LYRECHO_END_BEHAVIOR", "end-behavior", KindString, DefaultEndBehavior, "after the last lyric: hold, outro, idle or scroll"},
+	{"bg_tint", "LYRECHO_BG_TINT", "bg-tint", KindBool, "false", "tint the background with a darkened artwork color"},
+	{"backdrop", "LYRECHO_BACKDROP", "backdrop", KindBool, "false", "draw a blurred copy of the artwork behind the lyrics"},
+	{"status_bar", "LYRECHO_STATUS_BAR", "status-bar", KindBool, "false", "show player, sync offset and lyrics source in a bottom bar"},
+	{"visualizer", "LYRECHO_VISUALIZER", "visualizer", KindBool, "false", "draw the cava spectrum under the lyrics"},
+	{"idle_clock", "LYRECHO_IDLE_CLOCK", "idle-clock", KindFloat, "0", "minutes paused before a clock replaces the lyrics, 0 never"},
+	{"scroll_relock", "LYRECHO_SCROLL_RELOCK", "scroll-relock", KindFloat, "5", "seconds after scrolling before following the playing line again"},
+	{"volume_keys", "LYRECHO_VOLUME_KEYS", "volume-keys", KindList, DefaultVolumeKeys, "the keys that lower and raise the volume"},
+	{"primary", "LYRECHO_PRIMARY", "primary", KindString, "", "primary color (#rrggbb) used instead of the artwork's"},
+	{"secondary", "LYRECHO_SECONDARY", "secondary", KindString, "", "secondary color (#rrggbb) used instead of the artwork's"},
+	{"accent", "LYRECHO_ACCENT", "accent", KindString, "", "accent color (#rrggbb) used instead of the artwork's"},
+	{"palette_blend", "LYRECHO_PALETTE_BLEND", "palette-blend", KindFloat, "1", "how far to move towards the override colors, 0 to 1"},
+	{"animation", "LYRECHO_ANIMATION", "animation", KindString, DefaultAnimation, "animation preset: default, fast, slow, calm or none"},
+	{"transition_ticks", "LYRECHO_TRANSITION_TICKS", "transition-ticks", KindInt, "", "ticks a line change slides for, overrides the preset"},
+	{"reveal_step", "LYRECHO_REVEAL_STEP", "reveal-step", KindFloat, "", "how much of a new line fades in per tick, overrides the preset"},
+	{"shimmer", "LYRECHO_SHIMMER", "shimmer", KindBool, "", "shimmer across the current line, overrides the preset"},
+	{"glow", "LYRECHO_GLOW", "glow", KindFloat, "", "how bright a new line flashes, 0 to 2, overrides the preset"},
+	{"cache_maintenance", "LYRECHO_CACHE_MAINTENANCE", "", KindBool, "true", "prune the cache in the background on start"},
+	{"cache_max_age", "LYRECHO_CACHE_MAX_AGE", "", KindFloat, "0", "days an entry is kept, 0 until it expires"},
+	{"cache_max_size", "LYRECHO_CACHE_MAX_SIZE", "", KindFloat, "0", "megabytes the cache may use, 0 no limit"},
+	{"cache_remove_corrupt", "LYRECHO_CACHE_REMOVE_CORRUPT", "", KindBool, "false", "remove cache files that don't decode on start"},
+	{"history", "LYRECHO_HISTORY", "", KindBool, "true", "record the tracks played for lyrecho history"},
+}
+
+// LookupKey finds a key by its name, or by its environment variable
+func LookupKey(name string) (Key, bool) {
+	name = strings.ReplaceAll(strings.TrimSpace(name), "-", "_")
+	for _, key := range Keys {
+		if strings.EqualFold(key.Name, name) || strings.EqualFold(key.Env, name) {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Validate checks that a value parses the way the config reads it
+func (k Key) Validate(value string) error {
+	var err error
+	switch k.Kind {
+	case KindBool:
+		switch strings.ToLower(value) {
+		case "1", "true", "yes", "0", "false", "no":
+		default:
+			err = errors.New("expected true or false")
+		}
+	case KindInt:
+		_, err = strconv.Atoi(value)
+		if err != nil {
+			err = errors.New("expected a whole number")
+		}
+	case KindFloat:
+		_, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			err = errors.New("expected a number")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, k.Name, err)
+	}
+	return nil
+}
+
+// FilePath returns where the config file is read from
+func FilePath() (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configDir, "lyrecho", configFileName), nil
+}
+
+// ReadFile reads the values set in the config file, empty when there is
+// none. lines that don't parse are reported, the others are still returned.
+func ReadFile() (map[string]string, error) {
+	values := make(map[string]string)
+
+	path, err := FilePath()
+	if err != nil {
+		return values, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return values, nil
+		}
+		return values, err
+	}
+
+	var problems []string
+	for i, line := range strings.Split(string(data), "\n") {
+		name, value, ok, err := parseLine(line)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", i+1, err))
+			continue
+		}
+		if ok {
+			values[name] = value
+		}
+	}
+	if len(problems) > 0 {
+		return values, fmt.Errorf("invalid config file %s: %s", path, strings.Join(problems, "; "))
+	}
+	return values, nil
+}
+
+// parseLine reads a "name = value" line, ok is false for blank lines and
+// comments
+func parseLine(line string) (string, string, bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+
+	name, value, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", false, fmt.Errorf("expected name = value, got %q", line)
+	}
+	key, ok := LookupKey(name)
+	if !ok {
+		return "", "", false, fmt.Errorf("unknown key %q", strings.TrimSpace(name))
+	}
+	value = strings.TrimSpace(value)
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	return key.Name, value, true, nil
+}
+
+// DefaultFile is the config file written by lyrecho config init: every key
+// commented out with its default value
+func DefaultFile() string {
+	var b strings.Builder
+	b.WriteString("# lyrecho config\n")
+	b.WriteString("#\n")
+	b.WriteString("# uncomment a line to change the setting. environment variables override\n")
+	b.WriteString("# this file and command line flags override both.\n")
+	for _, key := range Keys {
+		fmt.Fprintf(&b, "\n# %s (%s", key.Help, key.Env)
+		if key.Flag != "" {
+			fmt.Fprintf(&b, ", --%s", key.Flag)
+		}
+		fmt.Fprintf(&b, ")\n%s\n", strings.TrimSpace("# "+key.Name+" = "+formatValue(key.Default)))
+	}
+	return b.String()
+}
+
+// SetValue writes one key to the config file, replacing its line or the
+// commented default, and creating the file when there is none
+func SetValue(key Key, value string) error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	entry := fmt.Sprintf("%s = %s", key.Name, formatValue(value))
+
+	set := -1
+	commented := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		uncommented := strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+		name, _, found := strings.Cut(uncommented, "=")
+		if !found {
+			continue
+		}
+		match, ok := LookupKey(name)
+		if !ok || match.Name != key.Name {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			if commented < 0 {
+				commented = i
+			}
+		} else if set < 0 {
+			set = i
+		}
+	}
+
+	switch {
+	case set >= 0:
+		lines[set] = entry
+	case commented >= 0:
+		lines[commented] = entry
+	default:
+		lines = append(lines, entry)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// formatValue quotes values that would otherwise lose spaces or start a
+// comment
+func formatValue(value string) string {
+	if value != strings.TrimSpace(value) || strings.HasPrefix(value, "#") || strings.HasPrefix(value, `"`) {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// Setting is a key with the value in effect and where it came from: the
+// default, the file, the environment or the remembered view state
+type Setting struct {
+	Key    Key
+	Value  string
+	Source string
+}
+
+// Settings resolves every key the way Load does, without the flags
+func Settings() ([]Setting, error) {
+	values, fileErr := ReadFile()
+	state, err := LoadState()
+	if err != nil {
+		state = &State{}
+	}
+	settings := make([]Setting, 0, len(Keys))
+	for _, key := range Keys {
+		setting := Setting{Key: key, Value: key.Default, Source: "default"}
+		if value, ok := values[key.Name]; ok && value != "" {
+			setting.Value = value
+			setting.Source = "file"
+		}
+		if value := os.Getenv(key.Env); value != "" {
+			setting.Value = value
+			setting.Source = "env"
+		}
+		if value, ok := state.value(key.Name); ok {
+			setting.Value = value
+			setting.Source = "state"
+		}
+		settings = append(settings, setting)
+	}
+	return settings, fileErr
+}
+
+// source reads settings from the environment, falling back to the config
+// file
+type source struct {
+	file map[string]string
+}
+
+// envKeys maps environment variables to config file keys
+var envKeys = func() map[string]string {
+	names := make(map[string]string, len(Keys))
+	for _, key := range Keys {
+		names[key.Env] = key.Name
+	}
+	return names
+}()
+
+func (s source) get(env string) string {
+	value := os.Getenv(env)
+	if value == "" {
+		value = s.file[envKeys[env]]
+	}
+	return value
+}
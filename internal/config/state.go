@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const stateFileName = "state.json"
+
+// State is what the viewer remembers between runs: view preferences changed
+// with keys. it overrides the environment, flags override it.
+type State struct {
+	HideHeader   *bool  `json:"hide_header,omitempty"`
+	Layout       string `json:"layout,omitempty"`
+	ContextLines *int   `json:"context_lines,omitempty"`
+}
+
+// StatePath returns where the state is stored
+func StatePath() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(stateDir, "lyrecho", stateFileName), nil
+}
+
+// LoadState reads the saved state, empty when nothing was saved yet
+func LoadState() (*State, error) {
+	path, err := StatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+
+	var state State
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+
+	return &state, nil
+}
+
+// SaveState writes the state, replacing what was saved before
+func SaveState(state *State) error {
+	path, err := StatePath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// ForgetState drops the saved preference for a config key, so the config
+// file and environment decide it again. it reports whether one was saved.
+func ForgetState(name string) (bool, error) {
+	state, err := LoadState()
+	if err != nil {
+		return false, err
+	}
+	if !state.forget(name) {
+		return false, nil
+	}
+	return true, SaveState(state)
+}
+
+// apply puts the saved preferences over the configured ones
+func (s *State) apply(cfg *Config) {
+	if s.HideHeader != nil {
+		cfg.HideHeader = *s.HideHeader
+	}
+	if s.Layout != "" {
+		cfg.Layout = s.Layout
+	}
+	if s.ContextLines != nil {
+		cfg.ContextLines = *s.ContextLines
+	}
+}
+
+// forget clears the preference for a config key, reporting whether one was set
+func (s *State) forget(name string) bool {
+	if _, ok := s.value(name); !ok {
+		return false
+	}
+	switch name {
+	case "hide_header":
+		s.HideHeader = nil
+	case "layout":
+		s.Layout = ""
+	case "context_lines":
+		s.ContextLines = nil
+	}
+	return true
+}
+
+// value is a saved preference as the config file would write it
+func (s *State) value(name string) (string, bool) {
+	switch {
+	case name == "hide_header" && s.HideHeader != nil:
+		return strconv.FormatBool(*s.HideHeader), true
+	case name == "layout" && s.Layout != "":
+		return s.Layout, true
+	case name == "context_lines" && s.ContextLines != nil:
+		return strconv.Itoa(*s.ContextLines), true
+	}
+	return "", false
+}
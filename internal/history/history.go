@@ -0,0 +1,125 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const historyFileName = "history.jsonl"
+
+// Play is one track listened to in the viewer
+type Play struct {
+	Artist   string `json:"artist"`
+	Title    string `json:"title"`
+	Album    string `json:"album,omitempty"`
+	Duration int64  `json:"duration,omitempty"`
+
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	// Listened is the seconds the track was playing
+	Listened float64 `json:"listened"`
+
+	// Source is where the lyrics came from, empty when none were found
+	Source string `json:"source,omitempty"`
+	// Lines is how many synced lines the lyrics have, LinesShown how many
+	// of them were the current line at some point
+	Lines      int `json:"lines"`
+	LinesShown int `json:"lines_shown"`
+	// LyricsTime is the seconds synced lyrics were on screen while playing
+	LyricsTime float64 `json:"lyrics_time"`
+}
+
+// Coverage is the share of the lyrics that was shown, from 0 to 1
+func (p Play) Coverage() float64 {
+	if p.Lines == 0 {
+		return 0
+	}
+	return float64(p.LinesShown) / float64(p.Lines)
+}
+
+// appends from the viewer may overlap when tracks change quickly
+var writeMu sync.Mutex
+
+// Path returns where the history is stored
+func Path() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(stateDir, "lyrecho", historyFileName), nil
+}
+
+// Append adds a play to the end of the history, one json object per line so
+// writing never rewrites what is there
+func Append(play Play) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(play)
+	if err != nil {
+		return err
+	}
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Load reads the plays started at or after since, oldest first. a zero since
+// reads everything, lines that don't parse are skipped.
+func Load(since time.Time) ([]Play, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var plays []Play
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var play Play
+		if json.Unmarshal(scanner.Bytes(), &play) != nil {
+			continue
+		}
+		if play.StartedAt.Before(since) {
+			continue
+		}
+		plays = append(plays, play)
+	}
+
+	return plays, scanner.Err()
+}
@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/config"
+)
+
+var (
+	client     *http.Client
+	clientOnce sync.Once
+
+	proxyMu  sync.RWMutex
+	proxyURL *url.URL
+
+	// allProxyWarning logs a bad ALL_PROXY once instead of on every request
+	allProxyWarning sync.Once
+)
+
+// SetProxy configures an explicit proxy for all outgoing requests.
+// it overrides HTTP_PROXY/HTTPS_PROXY/ALL_PROXY from the environment.
+// supported schemes are http, https, socks5 and socks5h.
+func SetProxy(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		proxyMu.Lock()
+		proxyURL = nil
+		proxyMu.Unlock()
+		return nil
+	}
+
+	parsed, err := parseProxyURL(raw)
+	if err != nil {
+		return err
+	}
+
+	proxyMu.Lock()
+	proxyURL = parsed
+	proxyMu.Unlock()
+
+	return nil
+}
+
+// Get returns the shared http client used for lyrics and artwork fetches
+func Get() *http.Client {
+	clientOnce.Do(func() {
+		transport := &http.Transport{
+			Proxy: proxyForRequest,
+			DialContext: (&net.Dialer{
+				Timeout:   2 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        10,
+			MaxIdleConnsPerHost: 5,
+			IdleConnTimeout:     60 * time.Second,
+			TLSHandshakeTimeout: 2 * time.Second,
+		}
+		client = &http.Client{
+			Transport: transport,
+			Timeout:   time.Duration(config.HTTPTimeoutSeconds) * time.Second,
+		}
+	})
+	return client
+}
+
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	proxyMu.RLock()
+	explicit := proxyURL
+	proxyMu.RUnlock()
+
+	if explicit != nil {
+		return explicit, nil
+	}
+
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY are handled by the standard library
+	envProxy, err := http.ProxyFromEnvironment(req)
+	if err != nil || envProxy != nil {
+		return envProxy, err
+	}
+
+	// ALL_PROXY is not covered by the standard library, but curl and most
+	// socks setups (tor, ssh -D) rely on it
+	allProxy := os.Getenv("ALL_PROXY")
+	if allProxy == "" {
+		allProxy = os.Getenv("all_proxy")
+	}
+	if allProxy == "" || isNoProxy(req.URL.Hostname()) {
+		return nil, nil
+	}
+
+	// a stray value shouldn't stop every fetch, requests go out directly
+	parsed, err := parseProxyURL(allProxy)
+	if err != nil {
+		allProxyWarning.Do(func() {
+			slog.Warn("ignoring ALL_PROXY", "err", err)
+		})
+		return nil, nil
+	}
+	return parsed, nil
+}
+
+func isNoProxy(host string) bool {
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	if noProxy == "" {
+		return false
+	}
+
+	host = strings.ToLower(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseProxyURL(raw string) (*url.URL, error) {
+	// bare host:port is treated as an http proxy, same as curl
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url %q: %w", raw, err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url %q: missing host", raw)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+const logFileName = "lyrecho.log"
+
+// a log bigger than this on start is moved to lyrecho.log.1, replacing the
+// one moved before
+const maxLogSize = 5 * 1024 * 1024
+
+// Dir returns the directory the log is written to
+func Dir() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(stateDir, "lyrecho", "log"), nil
+}
+
+// Path returns the log file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, logFileName), nil
+}
+
+// Open sends the default logger to the log file, keeping records at level
+// and above, for finding out why a lookup failed or a player was missed.
+// the file stays open until the process exits.
+func Open(level slog.Level) (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err == nil && info.Size() > maxLogSize {
+		_ = os.Rename(path, path+".1")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	// several commands may write at once, the pid tells them apart
+	handler := slog.NewTextHandler(file, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler).With("pid", os.Getpid()))
+	return path, nil
+}
+
+// Disable drops every record. the default logger would otherwise write to
+// stderr, right through the viewer.
+func Disable() {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+}
+
+// Debugging reports whether debug records are kept, for logs that cost
+// something to gather
+func Debugging() bool {
+	return slog.Default().Enabled(context.Background(), slog.LevelDebug)
+}
@@ -0,0 +1,110 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// ParseFile parses synced lyrics from a file, picking the format from the
+// file extension. unknown extensions are treated as lrc.
+func ParseFile(name string, data []byte) ([]TimedLine, error) {
+	var lines []TimedLine
+	var err error
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ttml", ".xml":
+		lines, err = ParseTTML(data)
+	case ".srt":
+		lines, err = ParseSRT(string(data))
+	case ".vtt":
+		lines, err = ParseVTT(string(data))
+	default:
+		lines = ParseSynced(string(data))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("no timed lines found")
+	}
+
+	// ttml and subtitle sources can be out of order just like lrc
+	lines, _ = RepairTimestamps(lines)
+	return GroupBlocks(lines), nil
+}
+
+// FormatLRC writes lines back out as lrc. lines with word timing are written
+// in the enhanced lrc format so the word timestamps survive a round trip.
+func FormatLRC(lines []TimedLine) string {
+	var b strings.Builder
+
+	for _, line := range lines {
+		timestamp := "[" + formatLrcTime(line.TimeSeconds) + "]"
+
+		if len(line.Words) == 0 {
+			// multi-line blocks are written as consecutive lines sharing a timestamp
+			for _, part := range strings.Split(line.Text, "\n") {
+				b.WriteString(timestamp)
+				b.WriteString(part)
+				b.WriteString("\n")
+			}
+			continue
+		}
+
+		b.WriteString(timestamp)
+		for _, word := range line.Words {
+			b.WriteString("<")
+			b.WriteString(formatLrcTime(word.TimeSeconds))
+			b.WriteString(">")
+			// a line break inside a block starts a new line with the same timestamp
+			b.WriteString(strings.ReplaceAll(word.Text, "\n", "\n"+timestamp))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// Shift moves every timestamp earlier by seconds, later for a negative
+// value, stopping at zero. it is how a sync offset is baked into the lines.
+func Shift(lines []TimedLine, seconds float64) []TimedLine {
+	shifted := make([]TimedLine, len(lines))
+	for i, line := range lines {
+		line.TimeSeconds = max(line.TimeSeconds-seconds, 0)
+		if len(line.Words) > 0 {
+			words := make([]TimedWord, len(line.Words))
+			for j, word := range line.Words {
+				word.TimeSeconds = max(word.TimeSeconds-seconds, 0)
+				words[j] = word
+			}
+			line.Words = words
+		}
+		shifted[i] = line
+	}
+	return shifted
+}
+
+// PlainText joins the line texts, used to fill the plain lyrics of imported entries
+func PlainText(lines []TimedLine) string {
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		texts = append(texts, line.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+func formatLrcTime(seconds float64) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	hundredths := int64(math.Round(seconds * 100))
+	minutes := hundredths / 6000
+	remaining := hundredths % 6000
+
+	return fmt.Sprintf("%02d:%02d.%02d", minutes, remaining/100, remaining%100)
+}
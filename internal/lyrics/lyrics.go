@@ -6,21 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
+	"unicode"
 
 	"karolbroda.com/lyrecho/internal/cache"
 	"karolbroda.com/lyrecho/internal/config"
-)
-
-var (
-	httpClient     *http.Client
-	httpClientOnce sync.Once
+	"karolbroda.com/lyrecho/internal/httpclient"
 )
 
 type LrclibResponse struct {
@@ -32,11 +29,90 @@ type LrclibResponse struct {
 	PlainLyrics  string  `json:"plainLyrics"`
 	SyncedLyrics string  `json:"syncedLyrics"`
 	SyncOffset   float64 `json:"-"`
+	// Translation is the layer picked for this song before, see Layer
+	Translation Layer `json:"-"`
+	// Source is where the lyrics came from, SourceCache or the provider's host
+	Source string `json:"-"`
 }
 
+// SourceCache marks lyrics read from the disk cache
+const SourceCache = "cache"
+
 type TimedLine struct {
 	TimeSeconds float64
 	Text        string
+	Words       []TimedWord
+}
+
+// TimedWord is a word (or syllable) with its own start time, used for
+// word-level highlighting when the source provides it (enhanced lrc, ttml)
+type TimedWord struct {
+	TimeSeconds float64
+	Text        string
+}
+
+// SungRunes returns how many non-space runes of the line have been reached at
+// positionSeconds, or -1 when the line has no word-level timing
+func (l TimedLine) SungRunes(positionSeconds float64) int {
+	if len(l.Words) == 0 {
+		return -1
+	}
+
+	count := 0
+	for _, word := range l.Words {
+		if word.TimeSeconds > positionSeconds {
+			break
+		}
+		count += visibleRunes(word.Text)
+	}
+
+	return count
+}
+
+// sweepSecondsPerRune caps how slowly SweptRunes moves through a word, so a
+// pause before the next word or line doesn't drag the fill out
+const sweepSecondsPerRune = 0.35
+
+// SweptRunes is like SungRunes but fractional: each word (or the whole line
+// without word timing) fills evenly from its start until the next word, or
+// endSeconds for the last one
+func (l TimedLine) SweptRunes(positionSeconds float64, endSeconds float64) float64 {
+	words := l.Words
+	if len(words) == 0 {
+		words = []TimedWord{{TimeSeconds: l.TimeSeconds, Text: l.Text}}
+	}
+
+	swept := 0.0
+	for i, word := range words {
+		if word.TimeSeconds > positionSeconds {
+			break
+		}
+
+		runes := float64(visibleRunes(word.Text))
+		end := endSeconds
+		if i+1 < len(words) {
+			end = words[i+1].TimeSeconds
+		}
+		end = min(end, word.TimeSeconds+runes*sweepSecondsPerRune)
+
+		if positionSeconds >= end {
+			swept += runes
+			continue
+		}
+		swept += runes * (positionSeconds - word.TimeSeconds) / (end - word.TimeSeconds)
+	}
+
+	return swept
+}
+
+func visibleRunes(text string) int {
+	count := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			count++
+		}
+	}
+	return count
 }
 
 type TrackParams struct {
@@ -44,26 +120,63 @@ type TrackParams struct {
 	Artist       string
 	Album        string
 	DurationSecs int64
+	// OnProgress is called before each search attempt, it may be nil
+	OnProgress func(Progress)
+	// SkipCache searches again even when the cache has the track, and
+	// replaces the cached entry with what is found
+	SkipCache bool
+	// Cache says whether the cache is read and written, by --no-cache
+	Cache CachePolicy
 }
 
-func getHTTPClient() *http.Client {
-	httpClientOnce.Do(func() {
-		transport := &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   2 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			MaxIdleConns:        10,
-			MaxIdleConnsPerHost: 5,
-			IdleConnTimeout:     60 * time.Second,
-			TLSHandshakeTimeout: 2 * time.Second,
-		}
-		httpClient = &http.Client{
-			Transport: transport,
-			Timeout:   time.Duration(config.HTTPTimeoutSeconds) * time.Second,
-		}
-	})
-	return httpClient
+// CachePolicy says how Fetch uses the lyrics cache
+type CachePolicy int
+
+const (
+	// CacheReadWrite answers from the cache and saves what is fetched
+	CacheReadWrite CachePolicy = iota
+	// CacheSkipRead always searches, and saves what it finds
+	CacheSkipRead
+	// CacheSkipWrite answers from the cache but saves nothing new
+	CacheSkipWrite
+	// CacheOff neither reads nor writes the cache
+	CacheOff
+)
+
+// ParseCachePolicy reads a --no-cache value, naming what to skip: read,
+// write or all. empty uses the cache normally, as do the false values
+// --no-cache=false and LYRECHO_NO_CACHE=0 took when it was a bool.
+func ParseCachePolicy(skip string) (CachePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(skip)) {
+	case "", "none", "0", "false", "no":
+		return CacheReadWrite, nil
+	case "read", "reads", "1", "true", "yes":
+		return CacheSkipRead, nil
+	case "write", "writes":
+		return CacheSkipWrite, nil
+	case "all", "both":
+		return CacheOff, nil
+	default:
+		return CacheReadWrite, fmt.Errorf("invalid cache skip %q (use read, write or all)", skip)
+	}
+}
+
+// Reads reports whether cached lyrics and palettes may be used
+func (p CachePolicy) Reads() bool {
+	return p == CacheReadWrite || p == CacheSkipWrite
+}
+
+// Writes reports whether fetched lyrics and tuned settings may be saved
+func (p CachePolicy) Writes() bool {
+	return p == CacheReadWrite || p == CacheSkipRead
+}
+
+// Progress describes the search attempt Fetch is about to make
+type Progress struct {
+	// Provider is the host being searched
+	Provider string
+	Attempt  int
+	Total    int
 }
 
 const maxRetries = 0
@@ -140,19 +253,23 @@ func Fetch(parentCtx context.Context, baseURL string, track *TrackParams) (*Lrcl
 
 	diskCache := cache.GetGlobalCache()
 
-	// normalize input for better matching
-	normalizedArtist := normalizeString(track.Artist)
-	normalizedTitle := normalizeString(track.Title)
-	strippedArtist := stripVersionInfo(track.Artist)
-	strippedTitle := stripVersionInfo(track.Title)
-
-	if normalizedTitle == "" || normalizedArtist == "" {
+	if normalizeString(track.Title) == "" || normalizeString(track.Artist) == "" {
 		return nil, errors.New("track title or artist is empty after normalization")
 	}
 
-	// check persistent cache first (use original values for cache key)
-	cached, err := diskCache.Get(track.Artist, track.Title)
-	if err == nil && cached != nil {
+	// check persistent cache first (use original values for cache key).
+	// a skipped read still looks, to keep what was tuned for the entry it
+	// replaces
+	var cached *cache.LyricEntry
+	if track.Cache != CacheOff {
+		entry, err := diskCache.Get(track.Artist, track.Title)
+		if err == nil {
+			cached = entry
+		}
+	}
+	if cached != nil && !track.SkipCache && track.Cache.Reads() {
+		slog.Info("lyrics cache hit", "artist", track.Artist, "title", track.Title,
+			"synced", cached.SyncedLyrics != "", "offset", cached.SyncOffset)
 		return &LrclibResponse{
 			TrackName:    cached.TrackName,
 			ArtistName:   cached.ArtistName,
@@ -162,74 +279,30 @@ func Fetch(parentCtx context.Context, baseURL string, track *TrackParams) (*Lrcl
 			PlainLyrics:  cached.PlainLyrics,
 			SyncedLyrics: cached.SyncedLyrics,
 			SyncOffset:   cached.SyncOffset,
+			Translation:  Layer(cached.Translation),
+			Source:       SourceCache,
 		}, nil
 	}
 
+	switch {
+	case !track.Cache.Reads():
+		slog.Info("lyrics cache skipped", "artist", track.Artist, "title", track.Title)
+	case track.SkipCache:
+		slog.Info("lyrics cache bypassed for a fresh search", "artist", track.Artist, "title", track.Title)
+	default:
+		slog.Info("lyrics cache miss", "artist", track.Artist, "title", track.Title)
+	}
+
 	parsedURL, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid lrclib url %q: %w", baseURL, err)
 	}
 
-	// build unique search strategies
-	searchStrategies := []struct {
-		artist   string
-		title    string
-		album    string
-		duration int64
-	}{
-		// strategy 1: normalized names with album and duration
-		{normalizedArtist, normalizedTitle, track.Album, track.DurationSecs},
-		// strategy 2: normalized names without album
-		{normalizedArtist, normalizedTitle, "", track.DurationSecs},
-		// strategy 3: normalized names without album or duration
-		{normalizedArtist, normalizedTitle, "", 0},
-		// strategy 4: stripped version info (no parens/brackets) without album
-		{strippedArtist, strippedTitle, "", 0},
-		// strategy 6: uppercase (some artists like SURF CURSE)
-		{strings.ToUpper(normalizedArtist), strings.ToUpper(normalizedTitle), "", 0},
-		// strategy 7: lowercase
-		{strings.ToLower(normalizedArtist), strings.ToLower(normalizedTitle), "", 0},
-		// strategy 8: title case
-		{toTitleCase(normalizedArtist), toTitleCase(normalizedTitle), "", 0},
-		// strategy 5: original names without album or duration (fallback)
-		{track.Artist, track.Title, "", 0},
-	}
-
-	// deduplicate strategies
-	seen := make(map[string]bool)
-	var uniqueStrategies []struct {
-		artist   string
-		title    string
-		album    string
-		duration int64
-	}
-
-	for _, strategy := range searchStrategies {
-		if strategy.artist == "" || strategy.title == "" {
-			continue
-		}
-
-		// create unique key for this search
-		key := fmt.Sprintf("%s|%s|%s|%d", strategy.artist, strategy.title, strategy.album, strategy.duration)
-		if !seen[key] {
-			seen[key] = true
-			uniqueStrategies = append(uniqueStrategies, strategy)
-		}
-	}
+	queries := SearchQueries(track)
 
 	var lastErr error
-	for strategyIdx, strategy := range uniqueStrategies {
-
-		query := parsedURL.Query()
-		query.Set("artist_name", strategy.artist)
-		query.Set("track_name", strategy.title)
-		if strategy.album != "" {
-			query.Set("album_name", strategy.album)
-		}
-		if strategy.duration > 0 {
-			query.Set("duration", fmt.Sprintf("%d", strategy.duration))
-		}
-		parsedURL.RawQuery = query.Encode()
+	for strategyIdx, query := range queries {
+		requestURL := query.URL(parsedURL)
 
 		// add small delay between strategies to avoid hammering the server
 		if strategyIdx > 0 {
@@ -240,13 +313,52 @@ func Fetch(parentCtx context.Context, baseURL string, track *TrackParams) (*Lrcl
 			}
 		}
 
-		payload, err := doFetchRequest(parentCtx, parsedURL.String())
+		if track.OnProgress != nil {
+			track.OnProgress(Progress{
+				Provider: parsedURL.Hostname(),
+				Attempt:  strategyIdx + 1,
+				Total:    len(queries),
+			})
+		}
+
+		attempt := []any{
+			"attempt", strategyIdx + 1, "of", len(queries), "strategy", query.Strategy,
+			"artist", query.Artist, "title", query.Title,
+			"album", query.Album, "duration", query.Duration,
+		}
+		slog.Debug("lyrics request", "url", requestURL)
+		requestStart := time.Now()
+
+		payload, err := doFetchRequest(parentCtx, requestURL)
+		attempt = append(attempt, "took", time.Since(requestStart).Round(time.Millisecond))
 		if err == nil {
 			if payload.PlainLyrics == "" && payload.SyncedLyrics == "" && !payload.Instrumental {
 				// no lyrics in response, try next strategy
 				lastErr = fmt.Errorf("no lyrics in response")
+				slog.Info("lyrics search empty", attempt...)
 				continue
 			}
+			slog.Info("lyrics search found", append(attempt,
+				"synced", payload.SyncedLyrics != "", "instrumental", payload.Instrumental)...)
+
+			// pick up an offset someone already tuned for this song
+			if track.Cache != CacheOff {
+				if offset, ok := diskCache.LookupOffset(payload.ArtistName, payload.TrackName, payload.Duration); ok {
+					payload.SyncOffset = offset
+				}
+			}
+
+			// a fresh search keeps what was tuned for the replaced entry
+			if cached != nil {
+				payload.SyncOffset = cached.SyncOffset
+				payload.Translation = Layer(cached.Translation)
+			}
+
+			payload.Source = parsedURL.Hostname()
+
+			if !track.Cache.Writes() {
+				return payload, nil
+			}
 
 			// found lyrics! persist to disk cache using original keys
 			_ = diskCache.Set(track.Artist, track.Title, &cache.LyricEntry{
@@ -258,12 +370,14 @@ func Fetch(parentCtx context.Context, baseURL string, track *TrackParams) (*Lrcl
 				PlainLyrics:  payload.PlainLyrics,
 				SyncedLyrics: payload.SyncedLyrics,
 				SyncOffset:   payload.SyncOffset,
+				Translation:  string(payload.Translation),
 			})
 
 			return payload, nil
 		}
 
 		lastErr = err
+		slog.Info("lyrics search failed", append(attempt, "err", err)...)
 
 		// if this is a 404 or similar, try next strategy quickly
 		// only give up immediately on actual network timeouts
@@ -273,12 +387,91 @@ func Fetch(parentCtx context.Context, baseURL string, track *TrackParams) (*Lrcl
 	}
 
 	// all strategies failed
+	slog.Info("lyrics not found", "artist", track.Artist, "title", track.Title,
+		"attempts", len(queries))
 	if lastErr != nil {
 		return nil, fmt.Errorf("no lyrics found for %s - %s: %w", track.Artist, track.Title, lastErr)
 	}
 	return nil, fmt.Errorf("no lyrics found for %s - %s (tried multiple search variations)", track.Artist, track.Title)
 }
 
+// SearchQuery is one way of asking lrclib for a track. names as the player
+// reports them often miss, so Fetch tries several spellings in turn.
+type SearchQuery struct {
+	// Strategy says how the names were changed
+	Strategy string
+	Artist   string
+	Title    string
+	Album    string
+	Duration int64
+}
+
+// URL builds the request for the query on the lrclib endpoint
+func (q SearchQuery) URL(base *url.URL) string {
+	u := *base
+	query := u.Query()
+	query.Set("artist_name", q.Artist)
+	query.Set("track_name", q.Title)
+	if q.Album != "" {
+		query.Set("album_name", q.Album)
+	}
+	if q.Duration > 0 {
+		query.Set("duration", strconv.FormatInt(q.Duration, 10))
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// SearchQueries lists the queries Fetch tries, in order and without the
+// ones that come out the same as an earlier query
+func SearchQueries(track *TrackParams) []SearchQuery {
+	normalizedArtist := normalizeString(track.Artist)
+	normalizedTitle := normalizeString(track.Title)
+	strippedArtist := stripVersionInfo(track.Artist)
+	strippedTitle := stripVersionInfo(track.Title)
+
+	strategies := []SearchQuery{
+		{"normalized, with album and duration", normalizedArtist, normalizedTitle, track.Album, track.DurationSecs},
+		{"normalized, without album", normalizedArtist, normalizedTitle, "", track.DurationSecs},
+		{"normalized, without album or duration", normalizedArtist, normalizedTitle, "", 0},
+		{"version info stripped", strippedArtist, strippedTitle, "", 0},
+		// some artists are written in capitals, like SURF CURSE
+		{"uppercase", strings.ToUpper(normalizedArtist), strings.ToUpper(normalizedTitle), "", 0},
+		{"lowercase", strings.ToLower(normalizedArtist), strings.ToLower(normalizedTitle), "", 0},
+		{"title case", toTitleCase(normalizedArtist), toTitleCase(normalizedTitle), "", 0},
+		{"original names", track.Artist, track.Title, "", 0},
+	}
+
+	seen := make(map[string]bool)
+	var queries []SearchQuery
+	for _, query := range strategies {
+		if query.Artist == "" || query.Title == "" {
+			continue
+		}
+		key := fmt.Sprintf("%s|%s|%s|%d", query.Artist, query.Title, query.Album, query.Duration)
+		if !seen[key] {
+			seen[key] = true
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+// Lookup sends a single query to lrclib, without the cache
+func Lookup(ctx context.Context, baseURL string, query SearchQuery) (*LrclibResponse, error) {
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lrclib url %q: %w", baseURL, err)
+	}
+	return doFetchRequest(ctx, query.URL(parsedURL))
+}
+
+// Normalize returns a name as the searches clean it up, and with the version
+// info (remastered, live, feat.) stripped
+func Normalize(name string) (string, string) {
+	return normalizeString(name), stripVersionInfo(name)
+}
+
 func isTimeoutError(err error) bool {
 	if err == nil {
 		return false
@@ -309,7 +502,7 @@ func doFetchRequest(parentCtx context.Context, requestURL string) (*LrclibRespon
 
 	req.Header.Set("User-Agent", "lyric-shower/1.0")
 
-	client := getHTTPClient()
+	client := httpclient.Get()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -340,8 +533,15 @@ func doFetchRequest(parentCtx context.Context, requestURL string) (*LrclibRespon
 }
 
 func ParseSynced(raw string) []TimedLine {
+	lines, _ := ParseSyncedWithReport(raw)
+	return lines
+}
+
+// ParseSyncedWithReport parses lrc like ParseSynced and also reports which
+// timestamp repairs were needed to get a sorted, monotonic result
+func ParseSyncedWithReport(raw string) ([]TimedLine, RepairReport) {
 	if raw == "" {
-		return nil
+		return nil, RepairReport{}
 	}
 
 	lines := strings.Split(raw, "\n")
@@ -353,41 +553,202 @@ func ParseSynced(raw string) []TimedLine {
 			continue
 		}
 
-		timePart, text := splitLrcLine(trimmed)
-		if timePart == "" || text == "" {
+		// compressed lrc repeats a line for several timestamps: [00:12.00][01:40.00]chorus
+		var timestamps []float64
+		text := trimmed
+		for {
+			timePart, rest := splitLrcLine(text)
+			if timePart == "" {
+				break
+			}
+			seconds, err := parseLrcTimeToSeconds(timePart)
+			if err != nil {
+				break
+			}
+			timestamps = append(timestamps, seconds)
+			text = rest
+		}
+		if len(timestamps) == 0 || text == "" {
 			continue
 		}
 
-		seconds, err := parseLrcTimeToSeconds(timePart)
-		if err != nil {
+		for _, seconds := range timestamps {
+			plain, words := parseWordTimings(text, seconds)
+			if plain == "" {
+				continue
+			}
+
+			result = append(result, TimedLine{
+				TimeSeconds: seconds,
+				Text:        plain,
+				Words:       words,
+			})
+		}
+	}
+
+	repaired, report := RepairTimestamps(result)
+	return GroupBlocks(repaired), report
+}
+
+// RepairReport records the fixes RepairTimestamps had to make
+type RepairReport struct {
+	// Reordered is the number of lines that appeared before an earlier timestamp
+	Reordered int
+	// WordsAdjusted is the number of word timings moved back into their line
+	WordsAdjusted int
+}
+
+func (r RepairReport) Repaired() bool {
+	return r.Reordered > 0 || r.WordsAdjusted > 0
+}
+
+// RepairTimestamps sorts lines by time (keeping the original order of equal
+// timestamps) and clamps word timings so they never run backwards or start
+// before their line. FindCurrentLineIndex and LineTracker rely on this.
+func RepairTimestamps(lines []TimedLine) ([]TimedLine, RepairReport) {
+	var report RepairReport
+
+	maxSeen := -1.0
+	for _, line := range lines {
+		if line.TimeSeconds < maxSeen {
+			report.Reordered++
 			continue
 		}
+		maxSeen = line.TimeSeconds
+	}
 
-		result = append(result, TimedLine{
-			TimeSeconds: seconds,
-			Text:        text,
+	if report.Reordered > 0 {
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].TimeSeconds < lines[j].TimeSeconds
 		})
 	}
 
-	return result
+	for i := range lines {
+		previous := lines[i].TimeSeconds
+		for j := range lines[i].Words {
+			word := &lines[i].Words[j]
+			if word.TimeSeconds < previous {
+				word.TimeSeconds = previous
+				report.WordsAdjusted++
+			}
+			previous = word.TimeSeconds
+		}
+	}
+
+	return lines, report
 }
 
+// GroupBlocks merges consecutive lines sharing the same timestamp into one
+// multi-line block (text joined with "\n"). some lrc sources encode stanzas
+// this way, and showing them as separate lines makes them compete for focus.
+func GroupBlocks(lines []TimedLine) []TimedLine {
+	if len(lines) < 2 {
+		return lines
+	}
+
+	grouped := make([]TimedLine, 0, len(lines))
+
+	for _, line := range lines {
+		if len(grouped) == 0 {
+			grouped = append(grouped, line)
+			continue
+		}
+
+		last := &grouped[len(grouped)-1]
+		if last.TimeSeconds != line.TimeSeconds {
+			grouped = append(grouped, line)
+			continue
+		}
+
+		if len(last.Words) > 0 || len(line.Words) > 0 {
+			words := wordsOrWhole(*last)
+			words[len(words)-1].Text += "\n"
+			last.Words = append(words, wordsOrWhole(line)...)
+		}
+		last.Text += "\n" + line.Text
+	}
+
+	return grouped
+}
+
+// wordsOrWhole returns the word timings of a line, or the whole line as a
+// single word when it has none
+func wordsOrWhole(line TimedLine) []TimedWord {
+	if len(line.Words) > 0 {
+		words := make([]TimedWord, len(line.Words))
+		copy(words, line.Words)
+		return words
+	}
+	return []TimedWord{{TimeSeconds: line.TimeSeconds, Text: line.Text}}
+}
+
+// FindCurrentLineIndex returns the index of the last line starting at or before
+// positionSeconds, or -1 if playback is before the first line. lines must be sorted.
 func FindCurrentLineIndex(lines []TimedLine, positionSeconds float64) int {
 	if len(lines) == 0 {
 		return -1
 	}
 
-	index := -1
+	// first line that starts after the position, the current one is right before it
+	next := sort.Search(len(lines), func(i int) bool {
+		return lines[i].TimeSeconds > positionSeconds
+	})
 
-	for i, line := range lines {
-		if line.TimeSeconds <= positionSeconds {
-			index = i
-			continue
+	return next - 1
+}
+
+// maxIncrementalSteps bounds the linear walk in LineTracker before it gives up
+// and falls back to a binary search (seeks, large offset changes)
+const maxIncrementalSteps = 8
+
+// LineTracker finds the current line incrementally. during normal playback the
+// position only moves a little between ticks, so it walks forward or backward
+// from the last known index instead of searching the whole slice every time.
+type LineTracker struct {
+	lines []TimedLine
+	last  int
+	valid bool
+}
+
+func NewLineTracker(lines []TimedLine) LineTracker {
+	return LineTracker{lines: lines}
+}
+
+func (t *LineTracker) Find(positionSeconds float64) int {
+	lines := t.lines
+	if len(lines) == 0 {
+		return -1
+	}
+
+	if !t.valid || t.last < -1 || t.last >= len(lines) {
+		t.last = FindCurrentLineIndex(lines, positionSeconds)
+		t.valid = true
+		return t.last
+	}
+
+	idx := t.last
+	steps := 0
+
+	for idx+1 < len(lines) && lines[idx+1].TimeSeconds <= positionSeconds {
+		idx++
+		steps++
+		if steps > maxIncrementalSteps {
+			idx = FindCurrentLineIndex(lines, positionSeconds)
+			break
 		}
-		break
 	}
 
-	return index
+	for idx >= 0 && lines[idx].TimeSeconds > positionSeconds {
+		idx--
+		steps++
+		if steps > maxIncrementalSteps {
+			idx = FindCurrentLineIndex(lines, positionSeconds)
+			break
+		}
+	}
+
+	t.last = idx
+	return idx
 }
 
 func splitLrcLine(line string) (string, string) {
@@ -409,6 +770,69 @@ func splitLrcLine(line string) (string, string) {
 	return timePart, textPart
 }
 
+// parseWordTimings handles enhanced lrc word tags like
+// "<00:12.00>beau<00:12.30>ti<00:12.50>ful". it returns the text without tags
+// and the timed words, or nil words when the line has no tags.
+func parseWordTimings(text string, lineSeconds float64) (string, []TimedWord) {
+	if !strings.Contains(text, "<") {
+		return text, nil
+	}
+
+	var words []TimedWord
+	var plain strings.Builder
+	current := TimedWord{TimeSeconds: lineSeconds}
+	rest := text
+	tagged := false
+
+	for rest != "" {
+		start := strings.Index(rest, "<")
+		if start < 0 {
+			current.Text += rest
+			break
+		}
+		end := strings.Index(rest[start:], ">")
+		if end < 0 {
+			current.Text += rest
+			break
+		}
+		end += start
+
+		seconds, err := parseLrcTimeToSeconds(rest[start+1 : end])
+		if err != nil {
+			// not a timestamp, keep it as literal text
+			current.Text += rest[:end+1]
+			rest = rest[end+1:]
+			continue
+		}
+
+		tagged = true
+		current.Text += rest[:start]
+		if strings.TrimSpace(current.Text) != "" {
+			words = append(words, current)
+		} else if len(words) > 0 {
+			words[len(words)-1].Text += current.Text
+		}
+		current = TimedWord{TimeSeconds: seconds}
+		rest = rest[end+1:]
+	}
+
+	if strings.TrimSpace(current.Text) != "" {
+		words = append(words, current)
+	} else if len(words) > 0 {
+		words[len(words)-1].Text += current.Text
+	}
+
+	if !tagged {
+		return text, nil
+	}
+
+	for _, word := range words {
+		plain.WriteString(word.Text)
+	}
+
+	return strings.TrimSpace(plain.String()), words
+}
+
 func parseLrcTimeToSeconds(raw string) (float64, error) {
 	if raw == "" {
 		return 0, errors.New("empty time value")
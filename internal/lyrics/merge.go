@@ -0,0 +1,218 @@
+package lyrics
+
+import (
+	"strings"
+	"unicode"
+)
+
+// alignment scores: a pair of lines scores between -0.5 (nothing in common)
+// and 1.5 (identical), skipping a line costs gapPenalty. two unrelated lines
+// still beat two gaps, so equal-length sources stay paired line by line.
+const (
+	gapPenalty  = -0.4
+	matchBase   = -0.5
+	matchWeight = 2.0
+)
+
+// MergeStats summarizes what Merge did
+type MergeStats struct {
+	// Replaced counts timing lines whose text was taken from the text source
+	Replaced int
+	// Kept counts timing lines with no counterpart in the text source
+	Kept int
+	// Inserted counts text lines with no timing counterpart, timed by interpolation
+	Inserted int
+}
+
+// Merge combines the timestamps of one source with the text of another. lines
+// are aligned by order and similarity (global alignment), timing lines take
+// the text of their counterpart, and text lines without a counterpart get a
+// timestamp interpolated from their neighbours.
+func Merge(timing []TimedLine, text []string) ([]TimedLine, MergeStats) {
+	var stats MergeStats
+
+	texts := make([]string, 0, len(text))
+	for _, t := range text {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			texts = append(texts, t)
+		}
+	}
+
+	if len(timing) == 0 || len(texts) == 0 {
+		stats.Kept = len(timing)
+		return timing, stats
+	}
+
+	pairs := alignLines(timing, texts)
+
+	type mergedLine struct {
+		line  TimedLine
+		timed bool
+	}
+
+	merged := make([]mergedLine, 0, len(pairs))
+	for _, pair := range pairs {
+		switch {
+		case pair.timing >= 0 && pair.text >= 0:
+			line := timing[pair.timing]
+			newText := texts[pair.text]
+			if line.Text != newText {
+				// word timings belong to the old text
+				line = TimedLine{TimeSeconds: line.TimeSeconds, Text: newText}
+				stats.Replaced++
+			}
+			merged = append(merged, mergedLine{line: line, timed: true})
+		case pair.timing >= 0:
+			merged = append(merged, mergedLine{line: timing[pair.timing], timed: true})
+			stats.Kept++
+		default:
+			merged = append(merged, mergedLine{line: TimedLine{Text: texts[pair.text]}})
+			stats.Inserted++
+		}
+	}
+
+	// interpolate timestamps for inserted lines between their timed neighbours
+	for i := 0; i < len(merged); i++ {
+		if merged[i].timed {
+			continue
+		}
+
+		runEnd := i
+		for runEnd < len(merged) && !merged[runEnd].timed {
+			runEnd++
+		}
+
+		start := 0.0
+		if i > 0 {
+			start = merged[i-1].line.TimeSeconds
+		}
+		end := start + float64(runEnd-i+1)*2
+		if runEnd < len(merged) {
+			end = merged[runEnd].line.TimeSeconds
+		}
+
+		step := (end - start) / float64(runEnd-i+1)
+		for j := i; j < runEnd; j++ {
+			merged[j].line.TimeSeconds = start + step*float64(j-i+1)
+		}
+		i = runEnd - 1
+	}
+
+	result := make([]TimedLine, len(merged))
+	for i, m := range merged {
+		result[i] = m.line
+	}
+
+	return result, stats
+}
+
+// PlainLines splits plain lyrics into lines, dropping blank lines and
+// section markers like "[Chorus]"
+func PlainLines(raw string) []string {
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+type alignedPair struct {
+	timing int
+	text   int
+}
+
+// alignLines runs a needleman-wunsch alignment between the two sources
+func alignLines(timing []TimedLine, texts []string) []alignedPair {
+	rows := len(timing) + 1
+	cols := len(texts) + 1
+
+	score := make([][]float64, rows)
+	for i := range score {
+		score[i] = make([]float64, cols)
+		score[i][0] = float64(i) * gapPenalty
+	}
+	for j := 0; j < cols; j++ {
+		score[0][j] = float64(j) * gapPenalty
+	}
+
+	for i := 1; i < rows; i++ {
+		for j := 1; j < cols; j++ {
+			match := score[i-1][j-1] + matchBase + matchWeight*lineSimilarity(timing[i-1].Text, texts[j-1])
+			skipTiming := score[i-1][j] + gapPenalty
+			skipText := score[i][j-1] + gapPenalty
+			score[i][j] = max(match, skipTiming, skipText)
+		}
+	}
+
+	// walk back from the bottom-right corner
+	var pairs []alignedPair
+	i, j := len(timing), len(texts)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && score[i][j] == score[i-1][j-1]+matchBase+matchWeight*lineSimilarity(timing[i-1].Text, texts[j-1]):
+			pairs = append(pairs, alignedPair{timing: i - 1, text: j - 1})
+			i--
+			j--
+		case i > 0 && score[i][j] == score[i-1][j]+gapPenalty:
+			pairs = append(pairs, alignedPair{timing: i - 1, text: -1})
+			i--
+		default:
+			pairs = append(pairs, alignedPair{timing: -1, text: j - 1})
+			j--
+		}
+	}
+
+	for left, right := 0, len(pairs)-1; left < right; left, right = left+1, right-1 {
+		pairs[left], pairs[right] = pairs[right], pairs[left]
+	}
+
+	return pairs
+}
+
+// lineSimilarity is the dice coefficient of the character bigrams of both
+// lines, ignoring case, punctuation and spacing. 1 means identical.
+func lineSimilarity(a string, b string) float64 {
+	na := similarityKey(a)
+	nb := similarityKey(b)
+
+	if string(na) == string(nb) {
+		return 1
+	}
+	if len(na) < 2 || len(nb) < 2 {
+		return 0
+	}
+
+	bigrams := make(map[string]int)
+	for i := 0; i < len(na)-1; i++ {
+		bigrams[string(na[i:i+2])]++
+	}
+
+	shared := 0
+	for i := 0; i < len(nb)-1; i++ {
+		key := string(nb[i : i+2])
+		if bigrams[key] > 0 {
+			bigrams[key]--
+			shared++
+		}
+	}
+
+	return 2 * float64(shared) / float64(len(na)-1+len(nb)-1)
+}
+
+func similarityKey(s string) []rune {
+	var key []rune
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			key = append(key, r)
+		}
+	}
+	return key
+}
@@ -0,0 +1,96 @@
+package lyrics
+
+import "fmt"
+
+// minChorusLines is how many lines in a row have to repeat to count as a
+// chorus, single repeated lines are usually just a hook
+const minChorusLines = 2
+
+type SectionKind int
+
+const (
+	SectionVerse SectionKind = iota
+	SectionChorus
+)
+
+// Section is a run of lines from Start up to End, exclusive
+type Section struct {
+	Kind  SectionKind
+	Start int
+	End   int
+	// Number counts verses from 1, choruses are all the same
+	Number int
+}
+
+func (s Section) Label() string {
+	if s.Kind == SectionChorus {
+		return "chorus"
+	}
+	return fmt.Sprintf("verse %d", s.Number)
+}
+
+// DetectSections marks groups of lines that repeat elsewhere in the song as
+// chorus and the runs in between as verses. empty lines belong to no section.
+func DetectSections(lines []TimedLine) []Section {
+	keys := make([]string, len(lines))
+	for i, line := range lines {
+		keys[i] = string(similarityKey(line.Text))
+	}
+
+	chorus := make([]bool, len(lines))
+	for i := range lines {
+		if keys[i] == "" {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			// the repeat may not overlap the group it repeats
+			run := 0
+			for i+run < j && j+run < len(lines) && keys[i+run] != "" && keys[i+run] == keys[j+run] {
+				run++
+			}
+			if run < minChorusLines {
+				continue
+			}
+			for k := 0; k < run; k++ {
+				chorus[i+k] = true
+				chorus[j+k] = true
+			}
+		}
+	}
+
+	var sections []Section
+	verses := 0
+	for i := 0; i < len(lines); {
+		if keys[i] == "" {
+			i++
+			continue
+		}
+
+		section := Section{Kind: SectionVerse, Start: i}
+		if chorus[i] {
+			section.Kind = SectionChorus
+		}
+		for i < len(lines) && keys[i] != "" && chorus[i] == chorus[section.Start] {
+			i++
+		}
+		section.End = i
+
+		if section.Kind == SectionVerse {
+			verses++
+			section.Number = verses
+		}
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+// SectionAt returns the section holding a line
+func SectionAt(sections []Section, index int) (Section, bool) {
+	for _, section := range sections {
+		if index >= section.Start && index < section.End {
+			return section, true
+		}
+	}
+	return Section{}, false
+}
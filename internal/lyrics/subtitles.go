@@ -0,0 +1,114 @@
+package lyrics
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	// formatting tags like <i>, </b>, <c.yellow>, <v Singer> (timestamps are handled separately)
+	subtitleTagPattern = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	// ass-style overrides some srt files carry, e.g. {\an8}
+	subtitleAssPattern = regexp.MustCompile(`\{\\[^}]*\}`)
+)
+
+var subtitleEntities = strings.NewReplacer(
+	"&amp;", "&",
+	"&lt;", "<",
+	"&gt;", ">",
+	"&nbsp;", " ",
+	"&lrm;", "",
+	"&rlm;", "",
+)
+
+// ParseSRT converts SubRip subtitles into timed lines, using each cue's start time
+func ParseSRT(raw string) ([]TimedLine, error) {
+	return parseSubtitleCues(raw)
+}
+
+// ParseVTT converts WebVTT captions into timed lines, using each cue's start
+// time. inline cue timestamps (<00:00:12.500>) become word timings.
+func ParseVTT(raw string) ([]TimedLine, error) {
+	raw = strings.TrimPrefix(raw, "\ufeff")
+	if !strings.HasPrefix(strings.TrimSpace(raw), "WEBVTT") {
+		return nil, errors.New("missing WEBVTT header")
+	}
+	return parseSubtitleCues(raw)
+}
+
+// parseSubtitleCues handles the block structure shared by srt and vtt: cues are
+// separated by blank lines, with an optional identifier line, a timing line
+// ("start --> end [settings]") and one or more text lines
+func parseSubtitleCues(raw string) ([]TimedLine, error) {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	raw = strings.TrimPrefix(raw, "\ufeff")
+
+	var result []TimedLine
+
+	for _, block := range strings.Split(raw, "\n\n") {
+		rows := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		timingRow := -1
+		for i, row := range rows {
+			if strings.Contains(row, "-->") {
+				timingRow = i
+				break
+			}
+		}
+		// header, NOTE, STYLE and REGION blocks have no timing line
+		if timingRow < 0 {
+			continue
+		}
+
+		startRaw := strings.TrimSpace(strings.SplitN(rows[timingRow], "-->", 2)[0])
+		start, err := parseSubtitleTime(startRaw)
+		if err != nil {
+			continue
+		}
+
+		var textRows []string
+		for _, row := range rows[timingRow+1:] {
+			row = cleanSubtitleText(row)
+			if row != "" {
+				textRows = append(textRows, row)
+			}
+		}
+		if len(textRows) == 0 {
+			continue
+		}
+
+		text, words := parseWordTimings(strings.Join(textRows, "\n"), start)
+		text = normalizeLineBreaks(text)
+		if text == "" {
+			continue
+		}
+
+		result = append(result, TimedLine{
+			TimeSeconds: start,
+			Text:        text,
+			Words:       words,
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("no subtitle cues found")
+	}
+
+	return result, nil
+}
+
+// cleanSubtitleText strips markup and the music-note decorations lyric
+// captions are usually wrapped in, keeping inline timestamps intact
+func cleanSubtitleText(row string) string {
+	row = subtitleAssPattern.ReplaceAllString(row, "")
+	row = subtitleTagPattern.ReplaceAllString(row, "")
+	row = subtitleEntities.Replace(row)
+	row = strings.Trim(row, " \t♪♫")
+	return row
+}
+
+// parseSubtitleTime accepts "00:01:02,500" (srt) and "01:02.500" / "00:01:02.500" (vtt)
+func parseSubtitleTime(raw string) (float64, error) {
+	return parseLrcTimeToSeconds(strings.ReplaceAll(raw, ",", "."))
+}
@@ -0,0 +1,85 @@
+package lyrics
+
+import "strings"
+
+// Layer picks which rows of translated lyrics to show
+type Layer string
+
+const (
+	LayerBoth       Layer = ""
+	LayerOriginal   Layer = "original"
+	LayerTranslated Layer = "translated"
+)
+
+// NextLayer cycles original, translated, both
+func NextLayer(layer Layer) Layer {
+	switch layer {
+	case LayerOriginal:
+		return LayerTranslated
+	case LayerTranslated:
+		return LayerBoth
+	default:
+		return LayerOriginal
+	}
+}
+
+// HasTranslation reports whether the lyrics carry a translation: translated
+// lrc files repeat each timestamp with the translation, which GroupBlocks
+// turns into two-row blocks. most sung lines need one to count.
+func HasTranslation(lines []TimedLine) bool {
+	sung := 0
+	translated := 0
+	for _, line := range lines {
+		if line.Text == "" {
+			continue
+		}
+		sung++
+		if strings.Count(line.Text, "\n") == 1 {
+			translated++
+		}
+	}
+	return translated > 0 && translated*2 >= sung
+}
+
+// SelectLayer keeps only the original or the translated row of each
+// two-row block, lines without a translation stay as they are
+func SelectLayer(lines []TimedLine, layer Layer) []TimedLine {
+	if layer == LayerBoth {
+		return lines
+	}
+
+	result := make([]TimedLine, len(lines))
+	for i, line := range lines {
+		original, translation, ok := strings.Cut(line.Text, "\n")
+		if !ok || strings.Contains(translation, "\n") {
+			result[i] = line
+			continue
+		}
+
+		// the first row's last word carries the row break
+		split := len(line.Words)
+		for j, word := range line.Words {
+			if strings.HasSuffix(word.Text, "\n") {
+				split = j + 1
+				break
+			}
+		}
+
+		selected := TimedLine{TimeSeconds: line.TimeSeconds}
+		if layer == LayerOriginal {
+			selected.Text = original
+			if split < len(line.Words) {
+				selected.Words = append([]TimedWord(nil), line.Words[:split]...)
+				last := &selected.Words[len(selected.Words)-1]
+				last.Text = strings.TrimSuffix(last.Text, "\n")
+			}
+		} else {
+			selected.Text = translation
+			if split < len(line.Words) {
+				selected.Words = append([]TimedWord(nil), line.Words[split:]...)
+			}
+		}
+		result[i] = selected
+	}
+	return result
+}
@@ -0,0 +1,245 @@
+package lyrics
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseTTML parses timed text markup (the format apple music tooling exports
+// synced lyrics in). every <p> becomes a line and every timed <span> inside
+// it becomes a word, so word-level ("syllable") timing is kept when present.
+func ParseTTML(data []byte) ([]TimedLine, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var lines []TimedLine
+	var current *ttmlLine
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ttml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				current = &ttmlLine{}
+				if begin, ok := ttmlAttr(t, "begin"); ok {
+					seconds, err := parseTTMLTime(begin)
+					if err == nil {
+						current.begin = seconds
+						current.hasBegin = true
+					}
+				}
+			case "span":
+				if current == nil {
+					continue
+				}
+				if begin, ok := ttmlAttr(t, "begin"); ok {
+					seconds, err := parseTTMLTime(begin)
+					if err == nil {
+						current.startWord(seconds)
+					}
+				}
+			case "br":
+				if current != nil {
+					current.appendRaw("\n")
+				}
+			}
+
+		case xml.EndElement:
+			if t.Name.Local == "p" && current != nil {
+				if line, ok := current.finish(); ok {
+					lines = append(lines, line)
+				}
+				current = nil
+			}
+
+		case xml.CharData:
+			if current != nil {
+				current.appendText(string(t))
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("no timed <p> elements found in ttml")
+	}
+
+	return lines, nil
+}
+
+type ttmlLine struct {
+	begin    float64
+	hasBegin bool
+	lead     string
+	words    []TimedWord
+}
+
+func (l *ttmlLine) startWord(seconds float64) {
+	l.words = append(l.words, TimedWord{TimeSeconds: seconds})
+}
+
+// appendText adds character data, collapsing the indentation whitespace of
+// pretty-printed files into single spaces
+func (l *ttmlLine) appendText(text string) {
+	if text == "" {
+		return
+	}
+
+	collapsed := strings.Join(strings.Fields(text), " ")
+	if strings.TrimSpace(text) == "" {
+		collapsed = " "
+	} else {
+		if isSpaceByte(text[0]) {
+			collapsed = " " + collapsed
+		}
+		if isSpaceByte(text[len(text)-1]) {
+			collapsed += " "
+		}
+	}
+
+	l.appendRaw(collapsed)
+}
+
+func (l *ttmlLine) appendRaw(text string) {
+	if len(l.words) == 0 {
+		l.lead += text
+		return
+	}
+	l.words[len(l.words)-1].Text += text
+}
+
+func (l *ttmlLine) finish() (TimedLine, bool) {
+	words := l.words
+
+	// text before the first timed span belongs to the line start
+	if strings.TrimSpace(l.lead) != "" && len(words) > 0 {
+		words = append([]TimedWord{{TimeSeconds: l.begin, Text: l.lead}}, words...)
+	}
+
+	// drop words that only carry whitespace, keeping the spacing on the previous word
+	cleaned := make([]TimedWord, 0, len(words))
+	for _, word := range words {
+		if strings.TrimSpace(word.Text) == "" {
+			if len(cleaned) > 0 {
+				cleaned[len(cleaned)-1].Text += word.Text
+			}
+			continue
+		}
+		cleaned = append(cleaned, word)
+	}
+
+	var raw strings.Builder
+	if len(cleaned) == 0 {
+		raw.WriteString(l.lead)
+	}
+	for _, word := range cleaned {
+		raw.WriteString(word.Text)
+	}
+
+	text := normalizeLineBreaks(raw.String())
+	if text == "" {
+		return TimedLine{}, false
+	}
+
+	begin := l.begin
+	if !l.hasBegin {
+		if len(cleaned) == 0 {
+			return TimedLine{}, false
+		}
+		begin = cleaned[0].TimeSeconds
+	}
+
+	line := TimedLine{
+		TimeSeconds: begin,
+		Text:        text,
+	}
+	if len(cleaned) > 0 {
+		line.Words = cleaned
+	}
+
+	return line, true
+}
+
+// normalizeLineBreaks trims every line of a (possibly multi-line) text and
+// drops empty ones
+func normalizeLineBreaks(text string) string {
+	parts := strings.Split(text, "\n")
+	kept := parts[:0]
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func ttmlAttr(el xml.StartElement, name string) (string, bool) {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// parseTTMLTime handles clock times ("1:02:03.5", "02:03.500") and offset
+// times ("12.5s", "1500ms", "2m", "1h")
+func parseTTMLTime(raw string) (float64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, errors.New("empty time value")
+	}
+
+	if strings.Contains(raw, ":") {
+		parts := strings.Split(raw, ":")
+		// hh:mm:ss:ff frame-based clock times, frames are dropped
+		if len(parts) == 4 {
+			raw = strings.Join(parts[:3], ":")
+		}
+		return parseLrcTimeToSeconds(raw)
+	}
+
+	multiplier := 1.0
+	number := raw
+
+	switch {
+	case strings.HasSuffix(raw, "ms"):
+		multiplier = 0.001
+		number = strings.TrimSuffix(raw, "ms")
+	case strings.HasSuffix(raw, "s"):
+		number = strings.TrimSuffix(raw, "s")
+	case strings.HasSuffix(raw, "m"):
+		multiplier = 60
+		number = strings.TrimSuffix(raw, "m")
+	case strings.HasSuffix(raw, "h"):
+		multiplier = 3600
+		number = strings.TrimSuffix(raw, "h")
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttml time %q: %w", raw, err)
+	}
+	if value < 0 {
+		return 0, errors.New("negative time not allowed")
+	}
+
+	return value * multiplier, nil
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
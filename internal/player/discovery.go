@@ -0,0 +1,106 @@
+package player
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const mprisNamePrefix = "org.mpris.MediaPlayer2."
+
+// ListPlayers returns the bus names of all running mpris players, sorted
+func ListPlayers(bus *dbus.Conn) ([]string, error) {
+	var names []string
+	err := bus.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names)
+	if err != nil {
+		return nil, err
+	}
+
+	var players []string
+	for _, name := range names {
+		if strings.HasPrefix(name, mprisNamePrefix) {
+			players = append(players, name)
+		}
+	}
+	sort.Strings(players)
+
+	return players, nil
+}
+
+// Identity returns the human readable name a player reports, e.g. "Spotify"
+func Identity(bus *dbus.Conn, serviceName string) string {
+	obj := bus.Object(serviceName, mprisPath)
+	variant, err := obj.GetProperty("org.mpris.MediaPlayer2.Identity")
+	if err != nil {
+		return ""
+	}
+
+	identity, ok := variant.Value().(string)
+	if !ok {
+		return ""
+	}
+
+	return identity
+}
+
+// PlaybackStatus returns "Playing", "Paused" or "Stopped", or "" when the
+// player can't be reached
+func PlaybackStatus(bus *dbus.Conn, serviceName string) string {
+	obj := bus.Object(serviceName, mprisPath)
+	variant, err := obj.GetProperty(mprisPlayerIface + ".PlaybackStatus")
+	if err != nil {
+		return ""
+	}
+
+	status, ok := variant.Value().(string)
+	if !ok {
+		return ""
+	}
+
+	return status
+}
+
+// IgnoreList holds glob patterns ("*firefox*", "chromium.instance*",
+// "Firefox") matched case-insensitively against a player's bus name, its
+// short name without the mpris prefix, and its identity
+type IgnoreList []string
+
+// Ignores reports whether a player should be skipped by discovery
+func (l IgnoreList) Ignores(bus *dbus.Conn, serviceName string) bool {
+	if len(l) == 0 {
+		return false
+	}
+
+	lower := strings.ToLower(serviceName)
+	if l.matches(lower) || l.matches(strings.TrimPrefix(lower, strings.ToLower(mprisNamePrefix))) {
+		return true
+	}
+
+	// the identity costs a dbus call, so it's only checked when the names don't match
+	identity := Identity(bus, serviceName)
+	return identity != "" && l.matches(strings.ToLower(identity))
+}
+
+func (l IgnoreList) matches(candidate string) bool {
+	for _, pattern := range l {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if matched, _ := path.Match(pattern, candidate); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func nameOwner(bus *dbus.Conn, name string) string {
+	var owner string
+	err := bus.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, name).Store(&owner)
+	if err != nil {
+		return ""
+	}
+	return owner
+}
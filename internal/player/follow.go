@@ -0,0 +1,229 @@
+package player
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// SetFollow makes the service switch to whichever player most recently
+// started playing, and away from players that quit. must be called before Start.
+func (s *MPRIS) SetFollow(enabled bool) {
+	s.follow = enabled
+}
+
+// SetPriority sets the order players are preferred in while following.
+// entries are bus names or their short form ("spotify" for
+// org.mpris.MediaPlayer2.spotify) and also match instance suffixes like
+// org.mpris.MediaPlayer2.spotify.instance123. unlisted players rank last.
+func (s *MPRIS) SetPriority(players []string) {
+	s.priority = nil
+	for _, name := range players {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !strings.HasPrefix(name, mprisNamePrefix) {
+			name = mprisNamePrefix + name
+		}
+		s.priority = append(s.priority, name)
+	}
+}
+
+// SetIgnore sets players that follow mode never switches to
+func (s *MPRIS) SetIgnore(patterns []string) {
+	s.ignore = IgnoreList(patterns)
+}
+
+// rank returns the priority of a player, lower is preferred
+func (s *MPRIS) rank(name string) int {
+	for i, entry := range s.priority {
+		if name == entry || strings.HasPrefix(name, entry+".") {
+			return i
+		}
+	}
+	return len(s.priority)
+}
+
+// ServiceName returns the bus name of the player currently followed
+func (s *MPRIS) ServiceName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.service
+}
+
+// startFollowing listens to every mpris player instead of a single one.
+// signals only carry the sender's unique name, so the owners of the
+// well-known player names are tracked to tell the players apart.
+func (s *MPRIS) startFollowing() error {
+	matches := []string{
+		fmt.Sprintf("type='signal',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged',path='%s'", mprisPath),
+		fmt.Sprintf("type='signal',interface='%s',member='Seeked',path='%s'", mprisPlayerIface, mprisPath),
+		"type='signal',sender='org.freedesktop.DBus',interface='org.freedesktop.DBus',member='NameOwnerChanged',arg0namespace='org.mpris.MediaPlayer2'",
+	}
+
+	for _, match := range matches {
+		err := s.conn().BusObject().Call("org.freedesktop.DBus.AddMatch", 0, match).Err
+		if err != nil {
+			return fmt.Errorf("failed to add match: %w", err)
+		}
+	}
+
+	players, err := ListPlayers(s.conn())
+	if err != nil {
+		return fmt.Errorf("failed to list players: %w", err)
+	}
+
+	s.owners = make(map[string]string)
+	for _, name := range players {
+		if owner := nameOwner(s.conn(), name); owner != "" {
+			s.owners[owner] = name
+		}
+	}
+
+	// start on the best player that is playing right now, keeping the
+	// configured one when nothing better is
+	current := s.ServiceName()
+	if active := s.findPlaying(players); active != "" {
+		if PlaybackStatus(s.conn(), current) != "Playing" || s.rank(active) < s.rank(current) {
+			s.switchTo(active)
+		}
+	}
+
+	return nil
+}
+
+// fromFollowedPlayer reports whether a signal was sent by the bound player
+func (s *MPRIS) fromFollowedPlayer(sig *dbus.Signal) bool {
+	if !s.follow {
+		// the match rules already filter by sender
+		return true
+	}
+	return s.owners[sig.Sender] == s.ServiceName()
+}
+
+func (s *MPRIS) handleNameOwnerChanged(sig *dbus.Signal) {
+	if len(sig.Body) < 3 {
+		return
+	}
+
+	name, _ := sig.Body[0].(string)
+	oldOwner, _ := sig.Body[1].(string)
+	newOwner, _ := sig.Body[2].(string)
+
+	if oldOwner != "" {
+		delete(s.owners, oldOwner)
+	}
+	if newOwner != "" {
+		s.owners[newOwner] = name
+
+		current := s.ServiceName()
+		switch {
+		case name == current:
+			// the followed player came back
+			s.rebind()
+		case nameOwner(s.conn(), current) == "" && !s.ignore.Ignores(s.conn(), name):
+			// nothing is bound since the last player quit, take the new one
+			s.switchTo(name)
+		}
+		return
+	}
+
+	// the followed player quit, move on to another one that is playing or
+	// wait for one to show up
+	if name == s.ServiceName() && !s.handoff() {
+		s.playerGone()
+	}
+}
+
+// handleOtherPlayerSignal switches to a player that just started playing
+func (s *MPRIS) handleOtherPlayerSignal(sig *dbus.Signal) {
+	if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+		return
+	}
+
+	name, ok := s.owners[sig.Sender]
+	if !ok || s.ignore.Ignores(s.conn(), name) {
+		return
+	}
+
+	changedProps, ok := sig.Body[1].(map[string]dbus.Variant)
+	if !ok {
+		return
+	}
+
+	statusVariant, exists := changedProps["PlaybackStatus"]
+	if !exists {
+		return
+	}
+
+	status, ok := statusVariant.Value().(string)
+	if !ok || status != "Playing" {
+		return
+	}
+
+	// a player takes over when it ranks at least as high as the current
+	// one, or when the current one isn't playing anyway
+	current := s.ServiceName()
+	if s.rank(name) <= s.rank(current) || PlaybackStatus(s.conn(), current) != "Playing" {
+		s.switchTo(name)
+	}
+}
+
+// handoff moves to the best other player that is still playing after the
+// followed player paused or stopped, reporting whether it found one
+func (s *MPRIS) handoff() bool {
+	players, err := ListPlayers(s.conn())
+	if err != nil {
+		return false
+	}
+	active := s.findPlaying(players)
+	if active == "" || active == s.ServiceName() {
+		return false
+	}
+	s.switchTo(active)
+	return true
+}
+
+// findPlaying returns the highest priority player that is playing
+func (s *MPRIS) findPlaying(players []string) string {
+	best := ""
+	for _, name := range players {
+		if PlaybackStatus(s.conn(), name) != "Playing" || s.ignore.Ignores(s.conn(), name) {
+			continue
+		}
+		if best == "" || s.rank(name) < s.rank(best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// switchTo rebinds the service to another player and announces its track
+func (s *MPRIS) switchTo(name string) {
+	s.mu.Lock()
+	if s.service == name {
+		s.mu.Unlock()
+		return
+	}
+	s.service = name
+	s.state.Track = nil
+	s.state.Playing = PlaybackStatus(s.conn(), name) == "Playing"
+	s.state.lastPositionUpdate = time.Time{}
+	s.mu.Unlock()
+
+	s.emitEvent(EventData{Type: EventPlayerChanged, Player: name})
+
+	trk, err := s.GetCurrentTrack()
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.state.Track = trk
+	s.mu.Unlock()
+
+	s.emitEvent(EventData{Type: EventTrackChanged, Track: trk})
+}
@@ -0,0 +1,557 @@
+package player
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+const (
+	DefaultMPDPort = "6600"
+	mpdDialTimeout = 2 * time.Second
+	// mpdCommandTimeout bounds the greeting and every answer, a stalled mpd
+	// would otherwise hold the shared connection and freeze every poll
+	mpdCommandTimeout = 5 * time.Second
+	// mpd drops idle clients after its connection_timeout, so the idle
+	// loop waits a bit before reconnecting after a failure
+	mpdRetryDelay = 2 * time.Second
+)
+
+// MPD talks the mpd protocol directly instead of going through an mpris bridge
+type MPD struct {
+	address  string
+	network  string
+	password string
+
+	connMu sync.Mutex
+	conn   *mpdConn
+
+	eventChan chan EventData
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+
+	mu    sync.RWMutex
+	state *State
+}
+
+// NewMPD creates an mpd backend. host uses the MPD_HOST conventions:
+// "host", "host:port", "password@host" or an absolute unix socket path.
+func NewMPD(host string, port string) (*MPD, error) {
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = DefaultMPDPort
+	}
+
+	password := ""
+	if at := strings.LastIndex(host, "@"); at > 0 {
+		password = host[:at]
+		host = host[at+1:]
+	}
+
+	s := &MPD{
+		password:  password,
+		eventChan: make(chan EventData, 16),
+		stopChan:  make(chan struct{}),
+		state:     &State{},
+	}
+
+	if strings.HasPrefix(host, "/") {
+		s.network = "unix"
+		s.address = host
+	} else {
+		s.network = "tcp"
+		if _, _, err := net.SplitHostPort(host); err == nil {
+			s.address = host
+		} else {
+			s.address = net.JoinHostPort(host, port)
+		}
+	}
+
+	return s, nil
+}
+
+var _ Service = (*MPD)(nil)
+
+// mpdAckError is an error response from mpd
+type mpdAckError string
+
+func (e mpdAckError) Error() string {
+	return "mpd: " + string(e)
+}
+
+// mpdConn is a single protocol connection
+type mpdConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (s *MPD) dial() (*mpdConn, error) {
+	conn, err := net.DialTimeout(s.network, s.address, mpdDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mpd at %s: %w", s.address, err)
+	}
+
+	c := &mpdConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	_ = conn.SetDeadline(time.Now().Add(mpdCommandTimeout))
+	greeting, err := c.reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(greeting, "OK MPD") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected mpd greeting %q", strings.TrimSpace(greeting))
+	}
+
+	if s.password != "" {
+		_, err = c.command("password " + quoteMPD(s.password))
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// command sends one command and collects the key/value response, giving up
+// when mpd doesn't answer in time
+func (c *mpdConn) command(cmd string) (map[string]string, error) {
+	_ = c.conn.SetDeadline(time.Now().Add(mpdCommandTimeout))
+	return c.roundTrip(cmd)
+}
+
+// idle sends an idle command, which only answers once something changes
+func (c *mpdConn) idle(cmd string) (map[string]string, error) {
+	_ = c.conn.SetDeadline(time.Time{})
+	return c.roundTrip(cmd)
+}
+
+func (c *mpdConn) roundTrip(cmd string) (map[string]string, error) {
+	_, err := c.conn.Write([]byte(cmd + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\n")
+
+		if line == "OK" {
+			return result, nil
+		}
+		if strings.HasPrefix(line, "ACK ") {
+			return nil, mpdAckError(strings.TrimPrefix(line, "ACK "))
+		}
+
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		// repeated tags (several artists) keep the first value
+		if _, exists := result[key]; !exists {
+			result[key] = value
+		}
+	}
+}
+
+func (c *mpdConn) close() {
+	_ = c.conn.Close()
+}
+
+// command runs a command on the shared connection, reconnecting once when
+// mpd has closed it in the meantime
+func (s *MPD) command(cmd string) (map[string]string, error) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			conn, err := s.dial()
+			if err != nil {
+				return nil, err
+			}
+			s.conn = conn
+		}
+
+		result, err := s.conn.command(cmd)
+		if err == nil {
+			return result, nil
+		}
+
+		// mpd rejecting a command doesn't need a new connection
+		var ack mpdAckError
+		if errors.As(err, &ack) {
+			return nil, err
+		}
+
+		s.conn.close()
+		s.conn = nil
+
+		// a stalled mpd would keep the caller waiting again on a new
+		// connection
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, fmt.Errorf("mpd didn't answer: %w", err)
+		}
+	}
+
+	return nil, errors.New("lost connection to mpd")
+}
+
+func (s *MPD) Start() error {
+	_, err := s.command("ping")
+	if err != nil {
+		return err
+	}
+
+	go s.idleLoop()
+
+	return nil
+}
+
+func (s *MPD) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+
+		s.connMu.Lock()
+		if s.conn != nil {
+			s.conn.close()
+			s.conn = nil
+		}
+		s.connMu.Unlock()
+	})
+}
+
+func (s *MPD) Events() <-chan EventData {
+	return s.eventChan
+}
+
+// idleLoop keeps a second connection in "idle player" so track changes,
+// pauses and seeks arrive without waiting for the next poll
+func (s *MPD) idleLoop() {
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		default:
+		}
+
+		conn, err := s.dial()
+		if err != nil {
+			if !s.sleep(mpdRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		// unblock the read when the service stops
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-s.stopChan:
+				conn.close()
+			case <-done:
+			}
+		}()
+
+		for {
+			_, err = conn.idle("idle player")
+			if err != nil {
+				break
+			}
+			_ = s.refresh(true)
+		}
+
+		close(done)
+		conn.close()
+
+		if !s.sleep(mpdRetryDelay) {
+			return
+		}
+	}
+}
+
+func (s *MPD) sleep(d time.Duration) bool {
+	select {
+	case <-s.stopChan:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+type mpdStatus struct {
+	track   *track.Info
+	playing bool
+	elapsed float64
+	shuffle bool
+	loop    string
+}
+
+func (s *MPD) fetchStatus() (*mpdStatus, error) {
+	status, err := s.command("status")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &mpdStatus{
+		playing: status["state"] == "play",
+		shuffle: status["random"] == "1",
+		loop:    LoopNone,
+	}
+	result.elapsed, _ = strconv.ParseFloat(status["elapsed"], 64)
+
+	// single mode on its own stops after the song, only with repeat it loops
+	if status["repeat"] == "1" {
+		result.loop = LoopPlaylist
+		if status["single"] == "1" {
+			result.loop = LoopTrack
+		}
+	}
+
+	if status["state"] == "stop" {
+		return result, nil
+	}
+
+	song, err := s.command("currentsong")
+	if err != nil {
+		return nil, err
+	}
+
+	duration, _ := strconv.ParseFloat(song["duration"], 64)
+	if duration == 0 {
+		duration, _ = strconv.ParseFloat(song["Time"], 64)
+	}
+
+	info := &track.Info{
+		Title:        song["Title"],
+		Artist:       song["Artist"],
+		Album:        song["Album"],
+		DurationSecs: int64(math.Round(duration)),
+		TrackID:      song["Id"],
+	}
+	if info.Artist == "" {
+		info.Artist = song["AlbumArtist"]
+	}
+	if info.IsValid() {
+		result.track = info
+	}
+
+	return result, nil
+}
+
+// refresh reads the player state and emits events for what changed.
+// fromIdle marks changes mpd reported, where an unchanged track and
+// playback state means the position jumped.
+func (s *MPD) refresh(fromIdle bool) error {
+	status, err := s.fetchStatus()
+	if err != nil {
+		// mpd went away, wait for it without showing the old song
+		s.mu.Lock()
+		hadTrack := s.state.forget()
+		s.mu.Unlock()
+		if hadTrack {
+			s.emitEvent(EventData{Type: EventTrackChanged})
+		}
+		return err
+	}
+
+	micros := int64(status.elapsed * 1_000_000)
+	pos := micros / 1_000_000
+
+	s.mu.Lock()
+	trackChanged := !status.track.IsSameTrack(s.state.Track)
+	playingChanged := status.playing != s.state.Playing
+	// a paused position doesn't advance, which would look like a seek
+	seekDetected := status.playing && s.state.Playing && s.state.DetectSeek(pos)
+
+	s.state.Track = status.track
+	s.state.Playing = status.playing
+	// mpd has no playback rate
+	s.state.Rate = 1
+	s.state.Shuffle = status.shuffle
+	s.state.Loop = status.loop
+	s.state.UpdatePosition(pos)
+	s.mu.Unlock()
+
+	switch {
+	case trackChanged:
+		s.emitEvent(EventData{Type: EventTrackChanged, Track: status.track, PositionMicros: micros})
+	case playingChanged:
+		s.emitEvent(EventData{Type: EventPlaybackStateChanged, Playing: status.playing})
+	case seekDetected || fromIdle:
+		s.emitEvent(EventData{Type: EventSeeked, PositionMicros: micros})
+	}
+
+	return nil
+}
+
+func (s *MPD) Poll() error {
+	return s.refresh(false)
+}
+
+func (s *MPD) GetState() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stateCopy := State{
+		PositionSecs: s.state.PositionSecs,
+		Playing:      s.state.Playing,
+		Rate:         s.state.Rate,
+		Shuffle:      s.state.Shuffle,
+		Loop:         s.state.Loop,
+	}
+	if s.state.Track != nil {
+		trackCopy := *s.state.Track
+		stateCopy.Track = &trackCopy
+	}
+
+	return stateCopy
+}
+
+func (s *MPD) GetCurrentTrack() (*track.Info, error) {
+	status, err := s.fetchStatus()
+	if err != nil {
+		return nil, err
+	}
+	if status.track == nil {
+		return nil, errors.New("mpd is not playing a song with title and artist tags")
+	}
+	return status.track, nil
+}
+
+func (s *MPD) GetCurrentPosition() (int64, error) {
+	micros, err := s.GetPositionMicros()
+	if err != nil {
+		return 0, err
+	}
+	return micros / 1_000_000, nil
+}
+
+func (s *MPD) GetPositionMicros() (int64, error) {
+	status, err := s.command("status")
+	if err != nil {
+		return 0, err
+	}
+
+	elapsed, _ := strconv.ParseFloat(status["elapsed"], 64)
+	return int64(elapsed * 1_000_000), nil
+}
+
+func (s *MPD) PlayPause() error {
+	status, err := s.command("status")
+	if err != nil {
+		return err
+	}
+
+	if status["state"] == "play" {
+		_, err = s.command("pause 1")
+	} else {
+		_, err = s.command("play")
+	}
+	return err
+}
+
+func (s *MPD) Next() error {
+	_, err := s.command("next")
+	return err
+}
+
+func (s *MPD) Previous() error {
+	_, err := s.command("previous")
+	return err
+}
+
+func (s *MPD) Volume() (float64, error) {
+	status, err := s.command("status")
+	if err != nil {
+		return 0, err
+	}
+
+	// mpd reports -1 or leaves volume out without a mixer
+	volume, err := strconv.Atoi(status["volume"])
+	if err != nil || volume < 0 {
+		return 0, fmt.Errorf("mpd volume: %w", ErrUnsupported)
+	}
+
+	return float64(volume) / 100, nil
+}
+
+func (s *MPD) SetVolume(volume float64) error {
+	percent := int(math.Round(max(0, min(volume, 1)) * 100))
+	_, err := s.command("setvol " + strconv.Itoa(percent))
+	return err
+}
+
+func (s *MPD) SetShuffle(enabled bool) error {
+	_, err := s.command("random " + mpdBool(enabled))
+	return err
+}
+
+func (s *MPD) SetLoop(loop string) error {
+	_, err := s.command("repeat " + mpdBool(loop != LoopNone))
+	if err != nil {
+		return err
+	}
+	_, err = s.command("single " + mpdBool(loop == LoopTrack))
+	return err
+}
+
+func mpdBool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (s *MPD) SetPosition(trackID string, positionMicros int64) error {
+	if positionMicros < 0 {
+		positionMicros = 0
+	}
+	seconds := strconv.FormatFloat(float64(positionMicros)/1_000_000, 'f', 3, 64)
+
+	var err error
+	if trackID != "" {
+		_, err = s.command("seekid " + trackID + " " + seconds)
+	} else {
+		_, err = s.command("seekcur " + seconds)
+	}
+	return err
+}
+
+// UpNext is not implemented for mpd yet
+func (s *MPD) UpNext(limit int) ([]track.Info, error) {
+	return nil, fmt.Errorf("mpd queue: %w", ErrUnsupported)
+}
+
+func (s *MPD) ServiceName() string {
+	return "mpd@" + s.address
+}
+
+func (s *MPD) emitEvent(event EventData) {
+	select {
+	case s.eventChan <- event:
+		logEvent("mpd", event)
+	default:
+		slog.Debug("player event dropped", "backend", "mpd", "event", event.Type)
+	}
+}
+
+func quoteMPD(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
@@ -1,8 +1,10 @@
 package player
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -12,8 +14,17 @@ import (
 )
 
 const (
-	mprisPath        = "/org/mpris/MediaPlayer2"
-	mprisPlayerIface = "org.mpris.MediaPlayer2.Player"
+	mprisPath           = "/org/mpris/MediaPlayer2"
+	mprisRootIface      = "org.mpris.MediaPlayer2"
+	mprisPlayerIface    = "org.mpris.MediaPlayer2.Player"
+	mprisTrackListIface = "org.mpris.MediaPlayer2.TrackList"
+)
+
+// loop modes, named like the mpris LoopStatus values
+const (
+	LoopNone     = "None"
+	LoopTrack    = "Track"
+	LoopPlaylist = "Playlist"
 )
 
 type Event int
@@ -23,21 +34,50 @@ const (
 	EventPositionChanged
 	EventSeeked
 	EventPlaybackStateChanged
+	EventPlayerChanged
+	EventRateChanged
 )
 
+func (e Event) String() string {
+	switch e {
+	case EventTrackChanged:
+		return "track"
+	case EventPositionChanged:
+		return "position"
+	case EventSeeked:
+		return "seeked"
+	case EventPlaybackStateChanged:
+		return "playback"
+	case EventPlayerChanged:
+		return "player"
+	default:
+		return "rate"
+	}
+}
+
 type EventData struct {
-	Type     Event
-	Track    *track.Info
-	Position int64
-	Playing  bool
+	Type  Event
+	Track *track.Info
+	// PositionMicros is the playback position in microseconds
+	PositionMicros int64
+	Playing        bool
+	// Rate is the playback speed for EventRateChanged, 1 is normal
+	Rate float64
+	// Player is the bus name of the new player for EventPlayerChanged
+	Player string
 }
 
 type State struct {
-	Track               *track.Info
-	PositionSecs        int64
-	Playing             bool
-	lastPositionUpdate  time.Time
-	lastPositionSecs    int64
+	Track        *track.Info
+	PositionSecs int64
+	Playing      bool
+	// Rate is the playback speed, 0 until it is known
+	Rate    float64
+	Shuffle bool
+	// Loop is one of the Loop constants, empty when the player has none
+	Loop               string
+	lastPositionUpdate time.Time
+	lastPositionSecs   int64
 }
 
 func (s *State) DetectSeek(newPosition int64) bool {
@@ -62,8 +102,26 @@ func (s *State) UpdatePosition(pos int64) {
 	s.lastPositionUpdate = time.Now()
 }
 
-type Service struct {
+// forget drops the state of a player that went away and reports whether a
+// track was known, in which case the viewer has to be told
+func (s *State) forget() bool {
+	hadTrack := s.Track != nil
+	s.Track = nil
+	s.Playing = false
+	s.Rate = 0
+	s.Shuffle = false
+	s.Loop = ""
+	s.PositionSecs = 0
+	s.lastPositionSecs = 0
+	s.lastPositionUpdate = time.Time{}
+	return hadTrack
+}
+
+type MPRIS struct {
+	busMu      sync.RWMutex
 	bus        *dbus.Conn
+	ownsBus    bool
+	dial       func() (*dbus.Conn, error)
 	service    string
 	signalChan chan *dbus.Signal
 	stopChan   chan struct{}
@@ -71,9 +129,18 @@ type Service struct {
 	eventChan  chan EventData
 	state      *State
 	mu         sync.RWMutex
+
+	// follow mode, owners maps unique bus names to player names and is
+	// only touched from the signal loop once started
+	follow   bool
+	priority []string
+	ignore   IgnoreList
+	owners   map[string]string
+	// owner is the unique name of the bound player outside follow mode
+	owner string
 }
 
-func NewService(bus *dbus.Conn, mprisService string) (*Service, error) {
+func NewMPRIS(bus *dbus.Conn, mprisService string) (*MPRIS, error) {
 	if bus == nil {
 		return nil, errors.New("nil dbus connection")
 	}
@@ -81,7 +148,7 @@ func NewService(bus *dbus.Conn, mprisService string) (*Service, error) {
 		return nil, errors.New("empty mpris service name")
 	}
 
-	s := &Service{
+	s := &MPRIS{
 		bus:       bus,
 		service:   mprisService,
 		eventChan: make(chan EventData, 16),
@@ -91,57 +158,99 @@ func NewService(bus *dbus.Conn, mprisService string) (*Service, error) {
 	return s, nil
 }
 
-func (s *Service) Start() error {
+func (s *MPRIS) Start() error {
+	s.stopChan = make(chan struct{})
+
+	err := s.subscribe()
+	if err != nil {
+		return err
+	}
+
+	go s.signalLoop()
+
+	return nil
+}
+
+// subscribe registers for signals on the current connection. it runs again
+// after a reconnect, since match rules live on the connection.
+func (s *MPRIS) subscribe() error {
+	bus := s.conn()
+
 	signalChan := make(chan *dbus.Signal, 10)
 	s.signalChan = signalChan
-	s.stopChan = make(chan struct{})
+	bus.Signal(signalChan)
 
-	s.bus.Signal(signalChan)
+	if s.follow {
+		return s.startFollowing()
+	}
 
+	service := s.ServiceName()
 	matchPropertiesChanged := fmt.Sprintf(
 		"type='signal',sender='%s',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged',path='%s'",
-		s.service, mprisPath,
+		service, mprisPath,
 	)
 	matchSeeked := fmt.Sprintf(
 		"type='signal',sender='%s',interface='%s',member='Seeked',path='%s'",
-		s.service, mprisPlayerIface, mprisPath,
+		service, mprisPlayerIface, mprisPath,
+	)
+	matchOwner := fmt.Sprintf(
+		"type='signal',sender='org.freedesktop.DBus',interface='org.freedesktop.DBus',member='NameOwnerChanged',arg0='%s'",
+		service,
 	)
 
-	err := s.bus.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchPropertiesChanged).Err
+	err := bus.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchPropertiesChanged).Err
 	if err != nil {
 		return fmt.Errorf("failed to add properties match: %w", err)
 	}
 
-	err = s.bus.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchSeeked).Err
+	err = bus.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchSeeked).Err
 	if err != nil {
 		return fmt.Errorf("failed to add seeked match: %w", err)
 	}
 
-	go s.signalLoop()
+	err = bus.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchOwner).Err
+	if err != nil {
+		return fmt.Errorf("failed to add name owner match: %w", err)
+	}
+
+	s.owner = nameOwner(bus, service)
 
 	return nil
 }
 
-func (s *Service) Stop() {
+func (s *MPRIS) Stop() {
 	s.stopOnce.Do(func() {
 		if s.stopChan != nil {
 			close(s.stopChan)
 		}
+
+		s.busMu.Lock()
+		if s.ownsBus {
+			_ = s.bus.Close()
+		}
+		s.busMu.Unlock()
 	})
 }
 
-func (s *Service) Events() <-chan EventData {
+// conn returns the current bus connection, which changes on reconnect
+func (s *MPRIS) conn() *dbus.Conn {
+	s.busMu.RLock()
+	defer s.busMu.RUnlock()
+	return s.bus
+}
+
+func (s *MPRIS) Events() <-chan EventData {
 	return s.eventChan
 }
 
-func (s *Service) State() *State {
+func (s *MPRIS) State() *State {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.state
 }
 
-func (s *Service) GetCurrentTrack() (*track.Info, error) {
-	obj := s.bus.Object(s.service, mprisPath)
+func (s *MPRIS) GetCurrentTrack() (*track.Info, error) {
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
 	if obj == nil {
 		return nil, errors.New("nil dbus object")
 	}
@@ -161,24 +270,96 @@ func (s *Service) GetCurrentTrack() (*track.Info, error) {
 		return nil, fmt.Errorf("unexpected metadata type %T", value)
 	}
 
-	info := &track.Info{
+	info := trackFromMetadata(metadata)
+	if !info.IsValid() {
+		return nil, fmt.Errorf("missing title or artist in metadata (title=%q, artist=%q)", info.Title, info.Artist)
+	}
+
+	return info, nil
+}
+
+func trackFromMetadata(metadata map[string]dbus.Variant) *track.Info {
+	return &track.Info{
 		Title:        extractString(metadata, "xesam:title"),
 		Artist:       extractArtist(metadata, "xesam:artist"),
 		Album:        extractString(metadata, "xesam:album"),
 		ArtworkURL:   extractString(metadata, "mpris:artUrl"),
 		TrackID:      extractString(metadata, "mpris:trackid"),
 		DurationSecs: extractDurationSeconds(metadata, "mpris:length"),
+		BPM:          extractInt(metadata, "xesam:audioBPM"),
 	}
+}
 
-	if !info.IsValid() {
-		return nil, fmt.Errorf("missing title or artist in metadata (title=%q, artist=%q)", info.Title, info.Artist)
+// UpNext reads the tracks after the current one from the TrackList
+// interface, which only some players implement
+func (s *MPRIS) UpNext(limit int) ([]track.Info, error) {
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
+
+	prop, err := obj.GetProperty(mprisRootIface + ".HasTrackList")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track list support: %w", err)
+	}
+	if hasTrackList, _ := prop.Value().(bool); !hasTrackList {
+		return nil, fmt.Errorf("mpris track list: %w", ErrUnsupported)
 	}
 
-	return info, nil
+	prop, err = obj.GetProperty(mprisTrackListIface + ".Tracks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks property: %w", err)
+	}
+	ids, ok := prop.Value().([]dbus.ObjectPath)
+	if !ok {
+		return nil, fmt.Errorf("unexpected tracks type %T", prop.Value())
+	}
+
+	s.mu.RLock()
+	currentID := ""
+	if s.state.Track != nil {
+		currentID = s.state.Track.TrackID
+	}
+	s.mu.RUnlock()
+
+	// without the current track in the list there is no telling what's next
+	start := -1
+	for i, id := range ids {
+		if string(id) == currentID {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 || start >= len(ids) {
+		return nil, nil
+	}
+	upcoming := ids[start:min(start+limit, len(ids))]
+
+	var metadata []map[string]dbus.Variant
+	err = obj.Call(mprisTrackListIface+".GetTracksMetadata", 0, upcoming).Store(&metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks metadata: %w", err)
+	}
+
+	tracks := make([]track.Info, 0, len(metadata))
+	for _, meta := range metadata {
+		info := trackFromMetadata(meta)
+		if info.IsValid() {
+			tracks = append(tracks, *info)
+		}
+	}
+
+	return tracks, nil
 }
 
-func (s *Service) GetCurrentPosition() (int64, error) {
-	obj := s.bus.Object(s.service, mprisPath)
+func (s *MPRIS) GetCurrentPosition() (int64, error) {
+	positionMicroseconds, err := s.GetPositionMicros()
+	if err != nil {
+		return 0, err
+	}
+	return positionMicroseconds / 1_000_000, nil
+}
+
+// GetPositionMicros returns the playback position at full mpris precision
+func (s *MPRIS) GetPositionMicros() (int64, error) {
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
 	if obj == nil {
 		return 0, errors.New("nil dbus object")
 	}
@@ -201,46 +382,163 @@ func (s *Service) GetCurrentPosition() (int64, error) {
 		return 0, nil
 	}
 
-	return positionMicroseconds / 1_000_000, nil
+	return positionMicroseconds, nil
+}
+
+// PlayPause toggles between playing and paused
+func (s *MPRIS) PlayPause() error {
+	return s.callPlayer("PlayPause")
+}
+
+// Next skips to the next track
+func (s *MPRIS) Next() error {
+	return s.callPlayer("Next")
 }
 
-func (s *Service) Poll() error {
+// Previous goes back to the previous track
+func (s *MPRIS) Previous() error {
+	return s.callPlayer("Previous")
+}
+
+// SetPosition jumps to an absolute position in the current track. mpris needs
+// the track id for this; players that don't report one get a relative seek.
+func (s *MPRIS) SetPosition(trackID string, positionMicros int64) error {
+	if positionMicros < 0 {
+		positionMicros = 0
+	}
+
+	if trackID != "" && dbus.ObjectPath(trackID).IsValid() {
+		return s.callPlayer("SetPosition", dbus.ObjectPath(trackID), positionMicros)
+	}
+
+	current, err := s.GetPositionMicros()
+	if err != nil {
+		return err
+	}
+	return s.callPlayer("Seek", positionMicros-current)
+}
+
+// Volume returns the player volume, 1 is full volume
+func (s *MPRIS) Volume() (float64, error) {
+	prop, err := s.conn().Object(s.ServiceName(), mprisPath).GetProperty(mprisPlayerIface + ".Volume")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get volume property: %w", err)
+	}
+
+	volume, ok := prop.Value().(float64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected volume type %T", prop.Value())
+	}
+
+	return volume, nil
+}
+
+// SetVolume changes the player volume, clamped to 0..1
+func (s *MPRIS) SetVolume(volume float64) error {
+	volume = max(0, min(volume, 1))
+
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
+	err := obj.SetProperty(mprisPlayerIface+".Volume", dbus.MakeVariant(volume))
+	if err != nil {
+		return fmt.Errorf("failed to set volume: %w", err)
+	}
+
+	return nil
+}
+
+func (s *MPRIS) callPlayer(method string, args ...interface{}) error {
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
+	if obj == nil {
+		return errors.New("nil dbus object")
+	}
+
+	call := obj.Call(mprisPlayerIface+"."+method, 0, args...)
+	if call.Err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, call.Err)
+	}
+
+	return nil
+}
+
+func (s *MPRIS) Poll() error {
+	// signals only report changes, the initial status has to be read
+	playing := PlaybackStatus(s.conn(), s.ServiceName()) == "Playing"
+
+	// while paused only the status is checked, track changes still arrive
+	// through PropertiesChanged
+	s.mu.RLock()
+	paused := s.state.Track != nil && !s.state.Playing
+	s.mu.RUnlock()
+	if paused && !playing {
+		return nil
+	}
+
 	trk, err := s.GetCurrentTrack()
 	if err != nil {
+		// a missed NameOwnerChanged still ends up here
+		if nameOwner(s.conn(), s.ServiceName()) == "" {
+			s.playerGone()
+		}
 		return err
 	}
 
-	pos, err := s.GetCurrentPosition()
+	micros, err := s.GetPositionMicros()
 	if err != nil {
 		return err
 	}
+	pos := micros / 1_000_000
+
+	s.mu.RLock()
+	optionsKnown := s.state.Rate != 0
+	s.mu.RUnlock()
+	// rate, shuffle and loop changes arrive as signals, they only have to
+	// be read once per player
+	if !optionsKnown {
+		rate := s.getRate()
+		shuffle, loop := s.getOptions()
+		s.mu.Lock()
+		s.state.Rate = rate
+		s.state.Shuffle = shuffle
+		s.state.Loop = loop
+		s.mu.Unlock()
+	}
 
 	s.mu.Lock()
 	currentTrack := s.state.Track
-	seekDetected := s.state.DetectSeek(pos)
+	playingChanged := playing != s.state.Playing
+	s.state.Playing = playing
+	// the last position is from before the pause, not a seek
+	seekDetected := !playingChanged && s.state.DetectSeek(pos)
 	s.state.UpdatePosition(pos)
 
 	if !trk.IsSameTrack(currentTrack) {
 		s.state.Track = trk
 		s.mu.Unlock()
-		s.emitEvent(EventData{Type: EventTrackChanged, Track: trk, Position: pos})
+		s.emitEvent(EventData{Type: EventTrackChanged, Track: trk, PositionMicros: micros})
 		return nil
 	}
 	s.mu.Unlock()
 
+	if playingChanged {
+		s.emitEvent(EventData{Type: EventPlaybackStateChanged, Playing: playing})
+	}
 	if seekDetected {
-		s.emitEvent(EventData{Type: EventSeeked, Position: pos})
+		s.emitEvent(EventData{Type: EventSeeked, PositionMicros: micros})
 	}
 
 	return nil
 }
 
-func (s *Service) signalLoop() {
+func (s *MPRIS) signalLoop() {
 	for {
 		select {
 		case sig, ok := <-s.signalChan:
 			if !ok {
-				return
+				// godbus closes signal channels when the connection drops
+				if !s.reconnect() {
+					return
+				}
+				continue
 			}
 			s.handleSignal(sig)
 		case <-s.stopChan:
@@ -249,11 +547,25 @@ func (s *Service) signalLoop() {
 	}
 }
 
-func (s *Service) handleSignal(sig *dbus.Signal) {
+func (s *MPRIS) handleSignal(sig *dbus.Signal) {
 	if sig == nil {
 		return
 	}
 
+	if sig.Name == "org.freedesktop.DBus.NameOwnerChanged" {
+		if s.follow {
+			s.handleNameOwnerChanged(sig)
+		} else {
+			s.handleOwnerChanged(sig)
+		}
+		return
+	}
+
+	if !s.fromFollowedPlayer(sig) {
+		s.handleOtherPlayerSignal(sig)
+		return
+	}
+
 	switch sig.Name {
 	case "org.freedesktop.DBus.Properties.PropertiesChanged":
 		s.handlePropertiesChanged(sig)
@@ -262,7 +574,7 @@ func (s *Service) handleSignal(sig *dbus.Signal) {
 	}
 }
 
-func (s *Service) handlePropertiesChanged(sig *dbus.Signal) {
+func (s *MPRIS) handlePropertiesChanged(sig *dbus.Signal) {
 	if len(sig.Body) < 2 {
 		return
 	}
@@ -283,15 +595,7 @@ func (s *Service) handlePropertiesChanged(sig *dbus.Signal) {
 			return
 		}
 
-		info := &track.Info{
-			Title:        extractString(metadata, "xesam:title"),
-			Artist:       extractArtist(metadata, "xesam:artist"),
-			Album:        extractString(metadata, "xesam:album"),
-			ArtworkURL:   extractString(metadata, "mpris:artUrl"),
-			TrackID:      extractString(metadata, "mpris:trackid"),
-			DurationSecs: extractDurationSeconds(metadata, "mpris:length"),
-		}
-
+		info := trackFromMetadata(metadata)
 		if info.IsValid() {
 			s.mu.Lock()
 			s.state.Track = info
@@ -313,11 +617,103 @@ func (s *Service) handlePropertiesChanged(sig *dbus.Signal) {
 			s.mu.Unlock()
 
 			s.emitEvent(EventData{Type: EventPlaybackStateChanged, Playing: playing})
+
+			if s.follow && !playing {
+				s.handoff()
+			}
+		}
+	}
+
+	if rateVariant, exists := changedProps["Rate"]; exists {
+		rate, ok := rateVariant.Value().(float64)
+		if ok && rate > 0 {
+			s.mu.Lock()
+			s.state.Rate = rate
+			s.mu.Unlock()
+
+			s.emitEvent(EventData{Type: EventRateChanged, Rate: rate})
+		}
+	}
+
+	if shuffleVariant, exists := changedProps["Shuffle"]; exists {
+		if shuffle, ok := shuffleVariant.Value().(bool); ok {
+			s.mu.Lock()
+			s.state.Shuffle = shuffle
+			s.mu.Unlock()
+		}
+	}
+
+	if loopVariant, exists := changedProps["LoopStatus"]; exists {
+		if loop, ok := loopVariant.Value().(string); ok {
+			s.mu.Lock()
+			s.state.Loop = loop
+			s.mu.Unlock()
 		}
 	}
 }
 
-func (s *Service) handleSeeked(sig *dbus.Signal) {
+// getRate reads the playback rate, players without one play at normal speed
+func (s *MPRIS) getRate() float64 {
+	prop, err := s.conn().Object(s.ServiceName(), mprisPath).GetProperty(mprisPlayerIface + ".Rate")
+	if err != nil {
+		return 1
+	}
+	rate, ok := prop.Value().(float64)
+	if !ok || rate <= 0 {
+		return 1
+	}
+	return rate
+}
+
+// getOptions reads shuffle and loop status, both are optional in mpris
+func (s *MPRIS) getOptions() (bool, string) {
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
+
+	shuffle := false
+	if prop, err := obj.GetProperty(mprisPlayerIface + ".Shuffle"); err == nil {
+		shuffle, _ = prop.Value().(bool)
+	}
+
+	loop := ""
+	if prop, err := obj.GetProperty(mprisPlayerIface + ".LoopStatus"); err == nil {
+		loop, _ = prop.Value().(string)
+	}
+
+	return shuffle, loop
+}
+
+// SetShuffle turns shuffle on or off
+func (s *MPRIS) SetShuffle(enabled bool) error {
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
+	err := obj.SetProperty(mprisPlayerIface+".Shuffle", dbus.MakeVariant(enabled))
+	if err != nil {
+		return fmt.Errorf("failed to set shuffle: %w", err)
+	}
+
+	// don't wait for PropertiesChanged, some players never send it
+	s.mu.Lock()
+	s.state.Shuffle = enabled
+	s.mu.Unlock()
+
+	return nil
+}
+
+// SetLoop changes the loop status
+func (s *MPRIS) SetLoop(loop string) error {
+	obj := s.conn().Object(s.ServiceName(), mprisPath)
+	err := obj.SetProperty(mprisPlayerIface+".LoopStatus", dbus.MakeVariant(loop))
+	if err != nil {
+		return fmt.Errorf("failed to set loop status: %w", err)
+	}
+
+	s.mu.Lock()
+	s.state.Loop = loop
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *MPRIS) handleSeeked(sig *dbus.Signal) {
 	if len(sig.Body) < 1 {
 		return
 	}
@@ -333,16 +729,46 @@ func (s *Service) handleSeeked(sig *dbus.Signal) {
 	s.state.UpdatePosition(pos)
 	s.mu.Unlock()
 
-	s.emitEvent(EventData{Type: EventSeeked, Position: pos})
+	s.emitEvent(EventData{Type: EventSeeked, PositionMicros: positionMicroseconds})
 }
 
-func (s *Service) emitEvent(event EventData) {
+func (s *MPRIS) emitEvent(event EventData) {
 	select {
 	case s.eventChan <- event:
+		logEvent("mpris", event)
 	default:
+		slog.Debug("player event dropped", "backend", "mpris", "event", event.Type)
 	}
 }
 
+// logEvent records a player event, track and player changes and playback
+// state at info, the rest at debug
+func logEvent(backend string, event EventData) {
+	attrs := []any{"backend", backend, "event", event.Type}
+	level := slog.LevelDebug
+
+	switch event.Type {
+	case EventTrackChanged:
+		level = slog.LevelInfo
+		if event.Track != nil {
+			attrs = append(attrs, "artist", event.Track.Artist, "title", event.Track.Title,
+				"album", event.Track.Album, "duration", event.Track.DurationSecs)
+		}
+	case EventPlaybackStateChanged:
+		level = slog.LevelInfo
+		attrs = append(attrs, "playing", event.Playing)
+	case EventPlayerChanged:
+		level = slog.LevelInfo
+		attrs = append(attrs, "player", event.Player)
+	case EventRateChanged:
+		attrs = append(attrs, "rate", event.Rate)
+	default:
+		attrs = append(attrs, "position", time.Duration(event.PositionMicros)*time.Microsecond)
+	}
+
+	slog.Log(context.Background(), level, "player event", attrs...)
+}
+
 func extractString(metadata map[string]dbus.Variant, key string) string {
 	if metadata == nil {
 		return ""
@@ -358,12 +784,15 @@ func extractString(metadata map[string]dbus.Variant, key string) string {
 		return ""
 	}
 
-	text, ok := raw.(string)
-	if ok {
-		return text
+	switch typed := raw.(type) {
+	case string:
+		return typed
+	case dbus.ObjectPath:
+		// mpris:trackid is an object path
+		return string(typed)
+	default:
+		return ""
 	}
-
-	return ""
 }
 
 func extractArtist(metadata map[string]dbus.Variant, key string) string {
@@ -425,7 +854,29 @@ func extractDurationSeconds(metadata map[string]dbus.Variant, key string) int64
 	}
 }
 
-func (s *Service) GetState() State {
+func extractInt(metadata map[string]dbus.Variant, key string) int {
+	variant, exists := metadata[key]
+	if !exists {
+		return 0
+	}
+
+	switch typed := variant.Value().(type) {
+	case int32:
+		return int(typed)
+	case int64:
+		return int(typed)
+	case uint32:
+		return int(typed)
+	case uint64:
+		return int(typed)
+	case float64:
+		return int(typed)
+	default:
+		return 0
+	}
+}
+
+func (s *MPRIS) GetState() State {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -433,6 +884,9 @@ func (s *Service) GetState() State {
 	stateCopy := State{
 		PositionSecs: s.state.PositionSecs,
 		Playing:      s.state.Playing,
+		Rate:         s.state.Rate,
+		Shuffle:      s.state.Shuffle,
+		Loop:         s.state.Loop,
 	}
 
 	// copy track info if it exists
@@ -0,0 +1,95 @@
+package player
+
+import (
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const reconnectDelay = 2 * time.Second
+
+// SetDialer lets the service open a new session bus connection when the
+// current one drops. without a dialer the service stops at that point.
+func (s *MPRIS) SetDialer(dial func() (*dbus.Conn, error)) {
+	s.dial = dial
+}
+
+// reconnect waits for the session bus to come back and subscribes again.
+// it reports false once the service is stopped.
+func (s *MPRIS) reconnect() bool {
+	if s.dial == nil {
+		return false
+	}
+
+	for {
+		select {
+		case <-s.stopChan:
+			return false
+		case <-time.After(reconnectDelay):
+		}
+
+		bus, err := s.dial()
+		if err != nil {
+			continue
+		}
+
+		s.busMu.Lock()
+		s.bus = bus
+		s.ownsBus = true
+		s.busMu.Unlock()
+
+		err = s.subscribe()
+		if err != nil {
+			_ = bus.Close()
+			continue
+		}
+
+		// anything could have changed while disconnected
+		s.rebind()
+		return true
+	}
+}
+
+// handleOwnerChanged notices the bound player quitting or restarting under
+// a new unique name outside follow mode
+func (s *MPRIS) handleOwnerChanged(sig *dbus.Signal) {
+	if len(sig.Body) < 3 {
+		return
+	}
+
+	name, _ := sig.Body[0].(string)
+	newOwner, _ := sig.Body[2].(string)
+
+	if name != s.ServiceName() || newOwner == s.owner {
+		return
+	}
+
+	s.owner = newOwner
+	if newOwner == "" {
+		s.playerGone()
+		return
+	}
+	s.rebind()
+}
+
+// playerGone clears the track of a player that quit, so the viewer goes back
+// to waiting instead of showing the last track forever
+func (s *MPRIS) playerGone() {
+	s.mu.Lock()
+	hadTrack := s.state.forget()
+	s.mu.Unlock()
+
+	if hadTrack {
+		s.emitEvent(EventData{Type: EventTrackChanged})
+	}
+}
+
+// rebind forgets the known state and reads it again, so the current track
+// is announced as if it had just changed
+func (s *MPRIS) rebind() {
+	s.mu.Lock()
+	s.state.forget()
+	s.mu.Unlock()
+
+	_ = s.Poll()
+}
@@ -0,0 +1,47 @@
+package player
+
+import (
+	"errors"
+
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+// ErrUnsupported is returned by backends for features they can't provide
+var ErrUnsupported = errors.New("not supported by this player backend")
+
+// Service is a source of playback state. the viewer only talks to players
+// through this interface, so each platform or protocol gets its own backend.
+type Service interface {
+	// Start begins watching the player, events are delivered on Events
+	Start() error
+	Stop()
+	Events() <-chan EventData
+
+	// Poll refreshes the state and emits events for changes signals missed
+	Poll() error
+	GetState() State
+	GetCurrentTrack() (*track.Info, error)
+	// GetCurrentPosition returns the position in whole seconds
+	GetCurrentPosition() (int64, error)
+	GetPositionMicros() (int64, error)
+
+	PlayPause() error
+	Next() error
+	Previous() error
+	// Volume is the player volume from 0 to 1
+	Volume() (float64, error)
+	SetVolume(volume float64) error
+	SetShuffle(enabled bool) error
+	// SetLoop takes one of LoopNone, LoopTrack or LoopPlaylist
+	SetLoop(loop string) error
+	// SetPosition jumps to an absolute position, trackID guards against
+	// seeking in a track that changed in the meantime where supported
+	SetPosition(trackID string, positionMicros int64) error
+	// UpNext returns up to limit queued tracks after the current one
+	UpNext(limit int) ([]track.Info, error)
+
+	// ServiceName identifies the player currently bound, for display
+	ServiceName() string
+}
+
+var _ Service = (*MPRIS)(nil)
@@ -0,0 +1,105 @@
+# helper for the smtc backend. reads one command per line from stdin and
+# answers each with one line of json on stdout.
+#
+#   state            current session, track and timeline
+#   playpause        toggle playback
+#   next / previous  skip tracks
+#   seek <ticks>     jump to an absolute position in 100ns ticks
+#   shuffle <0|1>    turn shuffle off or on
+#   loop <mode>      None, Track or List
+
+$ErrorActionPreference = 'Stop'
+[Console]::OutputEncoding = [System.Text.Encoding]::UTF8
+
+Add-Type -AssemblyName System.Runtime.WindowsRuntime
+
+$asTask = [System.WindowsRuntimeSystemExtensions].GetMethods() | Where-Object {
+    $_.Name -eq 'AsTask' -and $_.GetParameters().Count -eq 1 -and
+    $_.GetParameters()[0].ParameterType.Name -eq 'IAsyncOperation`1'
+} | Select-Object -First 1
+
+function Await($operation, [Type]$resultType) {
+    $task = $asTask.MakeGenericMethod($resultType).Invoke($null, @($operation))
+    $task.Wait(-1) | Out-Null
+    $task.Result
+}
+
+$managerType = [Windows.Media.Control.GlobalSystemMediaTransportControlsSessionManager, Windows.Media.Control, ContentType = WindowsRuntime]
+$propertiesType = [Windows.Media.Control.GlobalSystemMediaTransportControlsSessionMediaProperties, Windows.Media.Control, ContentType = WindowsRuntime]
+$manager = Await ($managerType::RequestAsync()) $managerType
+
+function Reply($value) {
+    [Console]::Out.WriteLine(($value | ConvertTo-Json -Compress))
+    [Console]::Out.Flush()
+}
+
+function Get-State($session) {
+    $props = Await ($session.TryGetMediaPropertiesAsync()) $propertiesType
+    $timeline = $session.GetTimelineProperties()
+    $playback = $session.GetPlaybackInfo()
+
+    $playing = $playback.PlaybackStatus.ToString() -eq 'Playing'
+    $position = $timeline.Position.TotalSeconds
+
+    # named like the mpris loop status, winrt says List for Playlist
+    $loop = ''
+    if ($playback.AutoRepeatMode -ne $null) {
+        $loop = $playback.AutoRepeatMode.ToString()
+        if ($loop -eq 'List') {
+            $loop = 'Playlist'
+        }
+    }
+
+    $rate = 1.0
+    if ($playback.PlaybackRate -ne $null -and $playback.PlaybackRate -gt 0) {
+        $rate = [double]$playback.PlaybackRate
+    }
+
+    # the timeline is only refreshed now and then, extrapolate while playing
+    if ($playing -and $timeline.LastUpdatedTime.Year -gt 1601) {
+        $position += ([DateTimeOffset]::Now - $timeline.LastUpdatedTime).TotalSeconds * $rate
+    }
+
+    @{
+        ok       = $true
+        app      = $session.SourceAppUserModelId
+        title    = $props.Title
+        artist   = $props.Artist
+        album    = $props.AlbumTitle
+        duration = ($timeline.EndTime - $timeline.StartTime).TotalSeconds
+        position = $position
+        playing  = $playing
+        rate     = $rate
+        shuffle  = [bool]$playback.IsShuffleActive
+        loop     = $loop
+    }
+}
+
+while ($true) {
+    $line = [Console]::In.ReadLine()
+    if ($line -eq $null) {
+        break
+    }
+
+    $parts = $line.Trim().Split(' ')
+    try {
+        $session = $manager.GetCurrentSession()
+        if ($session -eq $null) {
+            Reply @{ ok = $false; error = 'no media session' }
+            continue
+        }
+
+        switch ($parts[0]) {
+            'state' { Reply (Get-State $session) }
+            'playpause' { Reply @{ ok = (Await ($session.TryTogglePlayPauseAsync()) ([bool])) } }
+            'next' { Reply @{ ok = (Await ($session.TrySkipNextAsync()) ([bool])) } }
+            'previous' { Reply @{ ok = (Await ($session.TrySkipPreviousAsync()) ([bool])) } }
+            'shuffle' { Reply @{ ok = (Await ($session.TryChangeShuffleActiveAsync($parts[1] -eq '1')) ([bool])) } }
+            'loop' { Reply @{ ok = (Await ($session.TryChangeAutoRepeatModeAsync([Windows.Media.MediaPlaybackAutoRepeatMode]$parts[1])) ([bool])) } }
+            'seek' { Reply @{ ok = (Await ($session.TryChangePlaybackPositionAsync([long]$parts[1])) ([bool])) } }
+            default { Reply @{ ok = $false; error = "unknown command $($parts[0])" } }
+        }
+    } catch {
+        Reply @{ ok = $false; error = $_.Exception.Message }
+    }
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package player
+
+import "fmt"
+
+// NewSMTC creates the windows media session backend, only available on windows
+func NewSMTC() (Service, error) {
+	return nil, fmt.Errorf("smtc backend: %w on this platform", ErrUnsupported)
+}
@@ -0,0 +1,390 @@
+//go:build windows
+
+package player
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+//go:embed smtc.ps1
+var smtcScript string
+
+// smtcStateMaxAge lets GetCurrentPosition reuse the state Poll just fetched
+// instead of asking powershell twice per tick
+const smtcStateMaxAge = 50 * time.Millisecond
+
+// SMTC follows the windows GlobalSystemMediaTransportControls session, the
+// one the volume flyout shows. winrt is driven through a powershell helper
+// so no cgo or com bindings are needed.
+type SMTC struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	reqMu  sync.Mutex
+
+	eventChan chan EventData
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+
+	mu        sync.RWMutex
+	state     *State
+	app       string
+	last      smtcState
+	lastFetch time.Time
+}
+
+type smtcState struct {
+	OK       bool    `json:"ok"`
+	Error    string  `json:"error"`
+	App      string  `json:"app"`
+	Title    string  `json:"title"`
+	Artist   string  `json:"artist"`
+	Album    string  `json:"album"`
+	Duration float64 `json:"duration"`
+	Position float64 `json:"position"`
+	Playing  bool    `json:"playing"`
+	Rate     float64 `json:"rate"`
+	Shuffle  bool    `json:"shuffle"`
+	Loop     string  `json:"loop"`
+}
+
+// NewSMTC creates the windows media session backend
+func NewSMTC() (Service, error) {
+	if _, err := exec.LookPath("powershell.exe"); err != nil {
+		return nil, errors.New("powershell.exe not found")
+	}
+
+	return &SMTC{
+		eventChan: make(chan EventData, 16),
+		stopChan:  make(chan struct{}),
+		state:     &State{},
+	}, nil
+}
+
+func (s *SMTC) Start() error {
+	cmd := exec.Command("powershell.exe",
+		"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass",
+		"-EncodedCommand", encodePowerShell(smtcScript),
+	)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start media session helper: %w", err)
+	}
+
+	s.cmd = cmd
+	s.stdin = stdin
+	s.stdout = bufio.NewScanner(stdout)
+	s.stdout.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return nil
+}
+
+func (s *SMTC) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		if s.stdin != nil {
+			_ = s.stdin.Close()
+		}
+		if s.cmd != nil && s.cmd.Process != nil {
+			_ = s.cmd.Process.Kill()
+			_ = s.cmd.Wait()
+		}
+	})
+}
+
+func (s *SMTC) Events() <-chan EventData {
+	return s.eventChan
+}
+
+// request sends one command to the helper and decodes its reply
+func (s *SMTC) request(command string, reply interface{}) error {
+	s.reqMu.Lock()
+	defer s.reqMu.Unlock()
+
+	if s.stdin == nil {
+		return errors.New("media session helper not started")
+	}
+
+	_, err := io.WriteString(s.stdin, command+"\n")
+	if err != nil {
+		return fmt.Errorf("media session helper: %w", err)
+	}
+
+	if !s.stdout.Scan() {
+		if err := s.stdout.Err(); err != nil {
+			return fmt.Errorf("media session helper: %w", err)
+		}
+		return errors.New("media session helper exited")
+	}
+
+	return json.Unmarshal(s.stdout.Bytes(), reply)
+}
+
+func (s *SMTC) fetchState() (smtcState, error) {
+	s.mu.RLock()
+	if time.Since(s.lastFetch) < smtcStateMaxAge {
+		last := s.last
+		s.mu.RUnlock()
+		return last, nil
+	}
+	s.mu.RUnlock()
+
+	var state smtcState
+	err := s.request("state", &state)
+	if err != nil {
+		return state, err
+	}
+	if !state.OK {
+		return state, errors.New(state.Error)
+	}
+
+	s.mu.Lock()
+	s.last = state
+	s.lastFetch = time.Now()
+	s.app = state.App
+	s.mu.Unlock()
+
+	return state, nil
+}
+
+func (s *SMTC) action(command string) error {
+	var reply smtcState
+	err := s.request(command, &reply)
+	if err != nil {
+		return err
+	}
+	if !reply.OK {
+		if reply.Error != "" {
+			return errors.New(reply.Error)
+		}
+		return fmt.Errorf("player refused %s", command)
+	}
+
+	// the next read has to see the effect
+	s.mu.Lock()
+	s.lastFetch = time.Time{}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *SMTC) GetCurrentTrack() (*track.Info, error) {
+	state, err := s.fetchState()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &track.Info{
+		Title:        state.Title,
+		Artist:       state.Artist,
+		Album:        state.Album,
+		DurationSecs: int64(state.Duration),
+	}
+
+	if !info.IsValid() {
+		return nil, fmt.Errorf("missing title or artist in media session (title=%q, artist=%q)", info.Title, info.Artist)
+	}
+
+	return info, nil
+}
+
+func (s *SMTC) GetCurrentPosition() (int64, error) {
+	micros, err := s.GetPositionMicros()
+	if err != nil {
+		return 0, err
+	}
+	return micros / 1_000_000, nil
+}
+
+func (s *SMTC) GetPositionMicros() (int64, error) {
+	state, err := s.fetchState()
+	if err != nil {
+		return 0, err
+	}
+	if state.Position < 0 {
+		return 0, nil
+	}
+	return int64(state.Position * 1_000_000), nil
+}
+
+func (s *SMTC) Poll() error {
+	trk, err := s.GetCurrentTrack()
+	if err != nil {
+		// the session closed, wait for the next one without the old track
+		s.mu.Lock()
+		hadTrack := s.state.forget()
+		s.mu.Unlock()
+		if hadTrack {
+			s.emitEvent(EventData{Type: EventTrackChanged})
+		}
+		return err
+	}
+
+	micros, err := s.GetPositionMicros()
+	if err != nil {
+		return err
+	}
+	pos := micros / 1_000_000
+
+	s.mu.Lock()
+	playing := s.last.Playing
+	playingChanged := playing != s.state.Playing
+	s.state.Playing = playing
+
+	rate := s.last.Rate
+	if rate <= 0 {
+		rate = 1
+	}
+	rateChanged := s.state.Rate != 0 && rate != s.state.Rate
+	s.state.Rate = rate
+	s.state.Shuffle = s.last.Shuffle
+	s.state.Loop = s.last.Loop
+
+	currentTrack := s.state.Track
+	seekDetected := s.state.DetectSeek(pos)
+	s.state.UpdatePosition(pos)
+
+	if !trk.IsSameTrack(currentTrack) {
+		s.state.Track = trk
+		s.mu.Unlock()
+		s.emitEvent(EventData{Type: EventTrackChanged, Track: trk, PositionMicros: micros})
+		return nil
+	}
+	s.mu.Unlock()
+
+	// there are no signals here, so state changes are detected by polling
+	if playingChanged {
+		s.emitEvent(EventData{Type: EventPlaybackStateChanged, Playing: playing})
+	}
+	if rateChanged {
+		s.emitEvent(EventData{Type: EventRateChanged, Rate: rate})
+	}
+	if seekDetected {
+		s.emitEvent(EventData{Type: EventSeeked, PositionMicros: micros})
+	}
+
+	return nil
+}
+
+func (s *SMTC) GetState() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stateCopy := State{
+		PositionSecs: s.state.PositionSecs,
+		Playing:      s.state.Playing,
+		Rate:         s.state.Rate,
+		Shuffle:      s.state.Shuffle,
+		Loop:         s.state.Loop,
+	}
+	if s.state.Track != nil {
+		trackCopy := *s.state.Track
+		stateCopy.Track = &trackCopy
+	}
+
+	return stateCopy
+}
+
+func (s *SMTC) PlayPause() error {
+	return s.action("playpause")
+}
+
+func (s *SMTC) Next() error {
+	return s.action("next")
+}
+
+func (s *SMTC) Previous() error {
+	return s.action("previous")
+}
+
+// Volume isn't part of the media session, it belongs to the app's audio
+// session which powershell can't reach without com
+func (s *SMTC) Volume() (float64, error) {
+	return 0, fmt.Errorf("smtc volume: %w", ErrUnsupported)
+}
+
+func (s *SMTC) SetVolume(volume float64) error {
+	return fmt.Errorf("smtc volume: %w", ErrUnsupported)
+}
+
+func (s *SMTC) SetShuffle(enabled bool) error {
+	if enabled {
+		return s.action("shuffle 1")
+	}
+	return s.action("shuffle 0")
+}
+
+func (s *SMTC) SetLoop(loop string) error {
+	// winrt calls the playlist mode List
+	if loop == LoopPlaylist {
+		loop = "List"
+	}
+	return s.action("loop " + loop)
+}
+
+// SetPosition seeks within the current session, smtc has no track ids
+func (s *SMTC) SetPosition(trackID string, positionMicros int64) error {
+	if positionMicros < 0 {
+		positionMicros = 0
+	}
+	// smtc positions are in 100ns ticks
+	return s.action(fmt.Sprintf("seek %d", positionMicros*10))
+}
+
+// UpNext is not exposed by the media session api
+func (s *SMTC) UpNext(limit int) ([]track.Info, error) {
+	return nil, fmt.Errorf("smtc queue: %w", ErrUnsupported)
+}
+
+func (s *SMTC) ServiceName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.app == "" {
+		return "smtc"
+	}
+	return s.app
+}
+
+func (s *SMTC) emitEvent(event EventData) {
+	select {
+	case s.eventChan <- event:
+		logEvent("smtc", event)
+	default:
+		slog.Debug("player event dropped", "backend", "smtc", "event", event.Type)
+	}
+}
+
+// encodePowerShell encodes a script for -EncodedCommand, which expects
+// base64 of utf-16le
+func encodePowerShell(script string) string {
+	units := utf16.Encode([]rune(script))
+	buf := make([]byte, len(units)*2)
+	for i, unit := range units {
+		binary.LittleEndian.PutUint16(buf[i*2:], unit)
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
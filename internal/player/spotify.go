@@ -0,0 +1,335 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/spotify"
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+const (
+	// the web api is rate limited, positions in between are extrapolated
+	spotifyPollInterval = time.Second
+	spotifyIdleInterval = 5 * time.Second
+	spotifyTimeout      = 5 * time.Second
+)
+
+// SpotifyWeb follows playback through the spotify web api, which covers
+// phones and connect speakers where no local mpris player exists
+type SpotifyWeb struct {
+	client *spotify.Client
+
+	eventChan chan EventData
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+
+	mu        sync.RWMutex
+	state     *State
+	progress  time.Duration
+	fetchedAt time.Time
+	lastErr   error
+}
+
+// NewSpotifyWeb creates the web api backend from the token saved by
+// "lyrecho spotify login"
+func NewSpotifyWeb() (*SpotifyWeb, error) {
+	client, err := spotify.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SpotifyWeb{
+		client:    client,
+		eventChan: make(chan EventData, 16),
+		stopChan:  make(chan struct{}),
+		state:     &State{},
+	}, nil
+}
+
+var _ Service = (*SpotifyWeb)(nil)
+
+func (s *SpotifyWeb) Start() error {
+	err := s.refresh()
+	if err != nil {
+		return err
+	}
+
+	go s.pollLoop()
+
+	return nil
+}
+
+func (s *SpotifyWeb) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+func (s *SpotifyWeb) Events() <-chan EventData {
+	return s.eventChan
+}
+
+// pollLoop fetches in the background so a slow request never stalls the ui
+func (s *SpotifyWeb) pollLoop() {
+	for {
+		interval := spotifyPollInterval
+
+		err := s.refresh()
+		var rateLimit *spotify.RateLimitError
+		switch {
+		case errors.As(err, &rateLimit):
+			interval = rateLimit.RetryAfter
+		case err != nil:
+			interval = spotifyIdleInterval
+		default:
+			s.mu.RLock()
+			if s.state.Track == nil {
+				interval = spotifyIdleInterval
+			}
+			s.mu.RUnlock()
+		}
+
+		select {
+		case <-s.stopChan:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *SpotifyWeb) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), spotifyTimeout)
+	defer cancel()
+
+	playback, err := s.client.CurrentlyPlaying(ctx)
+
+	s.mu.Lock()
+	s.lastErr = err
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	var trk *track.Info
+	playing := false
+	shuffle := false
+	loop := ""
+	progress := time.Duration(0)
+	if playback != nil {
+		playing = playback.Playing
+		shuffle = playback.Shuffle
+		loop = spotifyLoops[playback.Repeat]
+		progress = time.Duration(playback.ProgressMs) * time.Millisecond
+		if item := playback.Item; item != nil {
+			trk = &track.Info{
+				Title:        item.Title,
+				Artist:       item.Artist,
+				Album:        item.Album,
+				DurationSecs: item.DurationMs / 1000,
+				ArtworkURL:   item.ArtworkURL,
+				TrackID:      item.ID,
+			}
+			if !trk.IsValid() {
+				trk = nil
+			}
+		}
+	}
+
+	pos := int64(progress.Seconds())
+	trackChanged := !trk.IsSameTrack(s.state.Track)
+	playingChanged := playing != s.state.Playing
+	seekDetected := playing && s.state.Playing && s.state.DetectSeek(pos)
+
+	s.state.Track = trk
+	s.state.Playing = playing
+	// the web api doesn't report a playback rate
+	s.state.Rate = 1
+	s.state.Shuffle = shuffle
+	s.state.Loop = loop
+	s.state.UpdatePosition(pos)
+	s.progress = progress
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	switch {
+	case trackChanged:
+		s.emitEvent(EventData{Type: EventTrackChanged, Track: trk, PositionMicros: progress.Microseconds()})
+	case playingChanged:
+		s.emitEvent(EventData{Type: EventPlaybackStateChanged, Playing: playing})
+	case seekDetected:
+		s.emitEvent(EventData{Type: EventSeeked, PositionMicros: progress.Microseconds()})
+	}
+
+	return nil
+}
+
+// Poll reports the last background fetch error, the fetching itself happens
+// in pollLoop
+func (s *SpotifyWeb) Poll() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErr
+}
+
+func (s *SpotifyWeb) GetState() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stateCopy := State{
+		PositionSecs: s.state.PositionSecs,
+		Playing:      s.state.Playing,
+		Rate:         s.state.Rate,
+		Shuffle:      s.state.Shuffle,
+		Loop:         s.state.Loop,
+	}
+	if s.state.Track != nil {
+		trackCopy := *s.state.Track
+		stateCopy.Track = &trackCopy
+	}
+
+	return stateCopy
+}
+
+func (s *SpotifyWeb) GetCurrentTrack() (*track.Info, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.state.Track == nil {
+		return nil, errors.New("nothing is playing on spotify")
+	}
+	trackCopy := *s.state.Track
+	return &trackCopy, nil
+}
+
+func (s *SpotifyWeb) GetCurrentPosition() (int64, error) {
+	micros, err := s.GetPositionMicros()
+	if err != nil {
+		return 0, err
+	}
+	return micros / 1_000_000, nil
+}
+
+// GetPositionMicros extrapolates from the last fetched progress
+func (s *SpotifyWeb) GetPositionMicros() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	progress := s.progress
+	if s.state.Playing && !s.fetchedAt.IsZero() {
+		progress += time.Since(s.fetchedAt)
+	}
+	if s.state.Track != nil && s.state.Track.DurationSecs > 0 {
+		progress = min(progress, time.Duration(s.state.Track.DurationSecs)*time.Second)
+	}
+
+	return progress.Microseconds(), nil
+}
+
+func (s *SpotifyWeb) PlayPause() error {
+	s.mu.RLock()
+	playing := s.state.Playing
+	s.mu.RUnlock()
+
+	if playing {
+		return s.control(s.client.Pause)
+	}
+	return s.control(s.client.Play)
+}
+
+func (s *SpotifyWeb) Next() error {
+	return s.control(s.client.Next)
+}
+
+func (s *SpotifyWeb) Previous() error {
+	return s.control(s.client.Previous)
+}
+
+// Volume returns the volume of the active device
+func (s *SpotifyWeb) Volume() (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), spotifyTimeout)
+	defer cancel()
+
+	percent, err := s.client.Volume(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return float64(percent) / 100, nil
+}
+
+func (s *SpotifyWeb) SetVolume(volume float64) error {
+	percent := int(max(0, min(volume, 1))*100 + 0.5)
+	return s.control(func(ctx context.Context) error {
+		return s.client.SetVolume(ctx, percent)
+	})
+}
+
+// spotifyLoops maps spotify repeat states to loop modes
+var spotifyLoops = map[string]string{
+	"off":     LoopNone,
+	"track":   LoopTrack,
+	"context": LoopPlaylist,
+}
+
+func (s *SpotifyWeb) SetShuffle(enabled bool) error {
+	return s.control(func(ctx context.Context) error {
+		return s.client.SetShuffle(ctx, enabled)
+	})
+}
+
+func (s *SpotifyWeb) SetLoop(loop string) error {
+	for state, mode := range spotifyLoops {
+		if mode == loop {
+			return s.control(func(ctx context.Context) error {
+				return s.client.SetRepeat(ctx, state)
+			})
+		}
+	}
+	return fmt.Errorf("unknown loop mode %q", loop)
+}
+
+// SetPosition seeks the active device, the web api has no track guard
+func (s *SpotifyWeb) SetPosition(trackID string, positionMicros int64) error {
+	if positionMicros < 0 {
+		positionMicros = 0
+	}
+	return s.control(func(ctx context.Context) error {
+		return s.client.Seek(ctx, positionMicros/1000)
+	})
+}
+
+// control runs a playback command and refreshes right after, so the change
+// shows without waiting for the next poll
+func (s *SpotifyWeb) control(action func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), spotifyTimeout)
+	defer cancel()
+
+	err := action(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.refresh()
+}
+
+// UpNext is not implemented for the web api yet
+func (s *SpotifyWeb) UpNext(limit int) ([]track.Info, error) {
+	return nil, fmt.Errorf("spotify queue: %w", ErrUnsupported)
+}
+
+func (s *SpotifyWeb) ServiceName() string {
+	return "spotify web api"
+}
+
+func (s *SpotifyWeb) emitEvent(event EventData) {
+	select {
+	case s.eventChan <- event:
+		logEvent("spotify", event)
+	default:
+		slog.Debug("player event dropped", "backend", "spotify", "event", event.Type)
+	}
+}
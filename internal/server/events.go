@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"karolbroda.com/lyrecho/internal/session"
+)
+
+// clientBuffer is how many events a websocket may fall behind before it is
+// dropped, a client that stops reading shouldn't hold the others up
+const clientBuffer = 64
+
+type wsClient struct {
+	conn *wsConn
+	send chan []byte
+}
+
+// the events pushed over /ws: a type and the same fields the matching
+// endpoint answers with
+type statusEventJSON struct {
+	Type string `json:"type"`
+	statusJSON
+}
+
+type lyricsEventJSON struct {
+	Type string `json:"type"`
+	lyricsJSON
+}
+
+type lineEventJSON struct {
+	Type string `json:"type"`
+	currentJSON
+}
+
+func newEventJSON(eventType session.EventType, snap session.Snapshot) any {
+	switch eventType {
+	case session.EventLyrics:
+		return lyricsEventJSON{Type: eventType.String(), lyricsJSON: newLyricsJSON(snap)}
+	case session.EventLine:
+		return lineEventJSON{Type: eventType.String(), currentJSON: newCurrentJSON(snap)}
+	default:
+		return statusEventJSON{Type: eventType.String(), statusJSON: newStatusJSON(snap)}
+	}
+}
+
+// Publish pushes an event to every connected websocket
+func (s *Server) Publish(event session.Event) {
+	data, err := json.Marshal(newEventJSON(event.Type, event.Snapshot))
+	if err != nil {
+		return
+	}
+
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	for client := range s.clients {
+		select {
+		case client.send <- data:
+		default:
+			s.dropLocked(client)
+		}
+	}
+}
+
+// Close ends every websocket. they were taken over from net/http, so
+// shutting down the http server leaves them open.
+func (s *Server) Close() {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	s.closed = true
+	for client := range s.clients {
+		s.dropLocked(client)
+		client.conn.closeGoingAway()
+	}
+}
+
+func (s *Server) dropLocked(client *wsClient) {
+	if _, ok := s.clients[client]; !ok {
+		return
+	}
+	delete(s.clients, client)
+	close(client.send)
+}
+
+// handleWebsocket streams events to the client, starting with the state
+// it joins in on
+func (s *Server) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r) {
+		writeError(w, http.StatusForbidden, errors.New("origin not allowed"))
+		return
+	}
+
+	conn, err := upgradeWebsocket(w, r)
+	if err != nil {
+		return
+	}
+
+	client := &wsClient{conn: conn, send: make(chan []byte, clientBuffer)}
+
+	snap := s.session.Snapshot()
+	for _, eventType := range []session.EventType{session.EventTrack, session.EventLyrics, session.EventLine} {
+		data, err := json.Marshal(newEventJSON(eventType, snap))
+		if err == nil {
+			client.send <- data
+		}
+	}
+
+	s.clientsMu.Lock()
+	if s.closed {
+		s.clientsMu.Unlock()
+		conn.closeGoingAway()
+		return
+	}
+	s.clients[client] = struct{}{}
+	s.clientsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		conn.readLoop()
+		close(done)
+	}()
+
+	defer func() {
+		s.clientsMu.Lock()
+		s.dropLocked(client)
+		s.clientsMu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-client.send:
+			if !ok || conn.writeFrame(opText, data) != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"karolbroda.com/lyrecho/internal/session"
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+// Server answers http requests about a session: the track playing, its
+// lyrics and the sync offset
+type Server struct {
+	session *session.Session
+	mux     *http.ServeMux
+	// listenHost is the name or address the server was asked to listen on,
+	// empty for every interface
+	listenHost string
+	// allowedOrigins are the web pages besides localhost ones that may
+	// connect from a browser
+	allowedOrigins []string
+
+	// clients are the websockets events are pushed to, closed once the
+	// server is
+	clientsMu sync.Mutex
+	clients   map[*wsClient]struct{}
+	closed    bool
+}
+
+type trackJSON struct {
+	Artist     string `json:"artist"`
+	Title      string `json:"title"`
+	Album      string `json:"album,omitempty"`
+	Duration   int64  `json:"duration,omitempty"`
+	ArtworkURL string `json:"artwork_url,omitempty"`
+}
+
+type lineJSON struct {
+	Index int     `json:"index"`
+	Time  float64 `json:"time"`
+	Text  string  `json:"text"`
+}
+
+type statusJSON struct {
+	Track    *trackJSON `json:"track"`
+	Playing  bool       `json:"playing"`
+	Position float64    `json:"position"`
+}
+
+type currentJSON struct {
+	Position   float64   `json:"position"`
+	SyncOffset float64   `json:"sync_offset"`
+	Current    *lineJSON `json:"current"`
+	Next       *lineJSON `json:"next"`
+}
+
+type lyricsJSON struct {
+	Track      *trackJSON `json:"track"`
+	Loading    bool       `json:"loading"`
+	Error      string     `json:"error,omitempty"`
+	Source     string     `json:"source,omitempty"`
+	SyncOffset float64    `json:"sync_offset"`
+	Lines      []lineJSON `json:"lines"`
+	Plain      string     `json:"plain,omitempty"`
+}
+
+type offsetJSON struct {
+	SyncOffset float64 `json:"sync_offset"`
+}
+
+// offsetRequest sets the offset, or moves it by adjust
+type offsetRequest struct {
+	Offset *float64 `json:"offset"`
+	Adjust *float64 `json:"adjust"`
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+// New serves the session on listenAddr. allowedOrigins lists the origins,
+// like https://example.com, that browsers may connect from besides localhost.
+func New(sess *session.Session, listenAddr string, allowedOrigins []string) *Server {
+	listenHost, _, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		listenHost = listenAddr
+	}
+
+	s := &Server{
+		session:        sess,
+		mux:            http.NewServeMux(),
+		listenHost:     listenHost,
+		allowedOrigins: allowedOrigins,
+		clients:        make(map[*wsClient]struct{}),
+	}
+	s.mux.HandleFunc("GET /track", s.handleTrack)
+	s.mux.HandleFunc("GET /line", s.handleLine)
+	s.mux.HandleFunc("GET /lyrics", s.handleLyrics)
+	s.mux.HandleFunc("GET /offset", s.handleOffset)
+	s.mux.HandleFunc("POST /offset", s.handleSetOffset)
+	s.mux.HandleFunc("GET /ws", s.handleWebsocket)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.hostAllowed(r) {
+		writeError(w, http.StatusForbidden, errors.New("host not allowed"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleTrack(w http.ResponseWriter, r *http.Request) {
+	snap := s.session.Snapshot()
+	writeJSON(w, http.StatusOK, newStatusJSON(snap))
+}
+
+func (s *Server) handleLine(w http.ResponseWriter, r *http.Request) {
+	snap := s.session.Snapshot()
+	writeJSON(w, http.StatusOK, newCurrentJSON(snap))
+}
+
+func (s *Server) handleLyrics(w http.ResponseWriter, r *http.Request) {
+	snap := s.session.Snapshot()
+	if snap.Track == nil {
+		writeError(w, http.StatusNotFound, errors.New("no track is playing"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newLyricsJSON(snap))
+}
+
+func (s *Server) handleOffset(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, offsetJSON{SyncOffset: s.session.Snapshot().SyncOffset})
+}
+
+func (s *Server) handleSetOffset(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r) {
+		writeError(w, http.StatusForbidden, errors.New("origin not allowed"))
+		return
+	}
+	// a page can post plain text anywhere without asking, json needs a
+	// preflight the server never answers
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("content type must be application/json"))
+		return
+	}
+
+	var req offsetRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if (req.Offset == nil) == (req.Adjust == nil) {
+		writeError(w, http.StatusBadRequest, errors.New("give either offset or adjust"))
+		return
+	}
+
+	snap := s.session.Snapshot()
+	if snap.Track == nil {
+		writeError(w, http.StatusNotFound, errors.New("no track is playing"))
+		return
+	}
+
+	offset := snap.SyncOffset
+	if req.Offset != nil {
+		offset = *req.Offset
+	} else {
+		offset += *req.Adjust
+	}
+	s.session.SetSyncOffset(offset)
+
+	writeJSON(w, http.StatusOK, offsetJSON{SyncOffset: s.session.Snapshot().SyncOffset})
+}
+
+func newTrackJSON(trk *track.Info) *trackJSON {
+	if trk == nil {
+		return nil
+	}
+	return &trackJSON{
+		Artist:     trk.Artist,
+		Title:      trk.Title,
+		Album:      trk.Album,
+		Duration:   trk.DurationSecs,
+		ArtworkURL: trk.ArtworkURL,
+	}
+}
+
+func newStatusJSON(snap session.Snapshot) statusJSON {
+	return statusJSON{
+		Track:    newTrackJSON(snap.Track),
+		Playing:  snap.Playing,
+		Position: snap.Position,
+	}
+}
+
+func newLyricsJSON(snap session.Snapshot) lyricsJSON {
+	out := lyricsJSON{
+		Track:      newTrackJSON(snap.Track),
+		Loading:    snap.Loading,
+		Source:     snap.Source,
+		SyncOffset: snap.SyncOffset,
+		Lines:      make([]lineJSON, len(snap.Lines)),
+		Plain:      snap.Plain,
+	}
+	if snap.Err != nil {
+		out.Error = snap.Err.Error()
+	}
+	for i, line := range snap.Lines {
+		out.Lines[i] = lineJSON{Index: i, Time: line.TimeSeconds, Text: line.Text}
+	}
+	return out
+}
+
+func newCurrentJSON(snap session.Snapshot) currentJSON {
+	out := currentJSON{Position: snap.Position, SyncOffset: snap.SyncOffset}
+	if line, ok := snap.Line(0); ok {
+		out.Current = &lineJSON{Index: snap.Index, Time: line.TimeSeconds, Text: line.Text}
+	}
+	if line, ok := snap.Line(1); ok {
+		out.Next = &lineJSON{Index: snap.Index + 1, Time: line.TimeSeconds, Text: line.Text}
+	}
+	return out
+}
+
+// originAllowed reports whether a request may be answered. browsers send the
+// page a request comes from as Origin, so any web page could otherwise read
+// what is playing. requests without one don't come from a page.
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range s.allowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	return isLoopbackHost(strings.ToLower(parsed.Hostname()))
+}
+
+// hostAllowed reports whether a request is addressed to this server. a web
+// page can point its own name at 127.0.0.1 and read the answers as its own,
+// but the browser still sends that name as Host.
+func (s *Server) hostAllowed(r *http.Request) bool {
+	if r.Host == "" {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+
+	if isLoopbackHost(host) || strings.EqualFold(host, strings.Trim(s.listenHost, "[]")) {
+		return true
+	}
+	for _, allowed := range s.allowedOrigins {
+		parsed, err := url.Parse(allowed)
+		if err == nil && strings.EqualFold(parsed.Hostname(), host) {
+			return true
+		}
+	}
+
+	// listening on every interface, clients on the network connect by
+	// address. pointing a name at one is what the check is about.
+	listenIP := net.ParseIP(strings.Trim(s.listenHost, "[]"))
+	if s.listenHost == "" || (listenIP != nil && listenIP.IsUnspecified()) {
+		return net.ParseIP(host) != nil
+	}
+	return false
+}
+
+// isLoopbackHost reports whether a host name or address is this machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorJSON{Error: err.Error()})
+}
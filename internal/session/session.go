@@ -0,0 +1,392 @@
+package session
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/lyrics"
+	"karolbroda.com/lyrecho/internal/player"
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+// the position is extrapolated between reads from the player, and reported
+// to listeners about this often while playing
+const positionInterval = time.Second
+
+// EventType says what changed
+type EventType int
+
+const (
+	// EventTrack is sent when the track changes, its lyrics still loading
+	EventTrack EventType = iota
+	// EventLyrics is sent when the lyrics arrive or fail to
+	EventLyrics
+	// EventLine is sent when another line becomes current
+	EventLine
+	// EventPlayback is sent when playback pauses or resumes
+	EventPlayback
+	// EventPosition is sent about once a second while playing, and on seeks
+	EventPosition
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventTrack:
+		return "track"
+	case EventLyrics:
+		return "lyrics"
+	case EventLine:
+		return "line"
+	case EventPlayback:
+		return "playback"
+	default:
+		return "position"
+	}
+}
+
+// Event is a change along with the state right after it
+type Event struct {
+	Type     EventType
+	Snapshot Snapshot
+}
+
+// Snapshot is the state of the session at one moment
+type Snapshot struct {
+	Track   *track.Info
+	Playing bool
+	// Position is the playback position in seconds
+	Position   float64
+	SyncOffset float64
+	Lines      []lyrics.TimedLine
+	// Plain holds the lyrics without timing, shown when none are synced
+	Plain string
+	// Source is where the lyrics came from
+	Source string
+	// Index is the current line, -1 before the first one
+	Index   int
+	Loading bool
+	Err     error
+}
+
+// Line returns the line offset lines after the current one, a negative
+// offset looks back
+func (s Snapshot) Line(offset int) (lyrics.TimedLine, bool) {
+	i := s.Index + offset
+	if s.Index < 0 && offset <= 0 || i < 0 || i >= len(s.Lines) {
+		return lyrics.TimedLine{}, false
+	}
+	return s.Lines[i], true
+}
+
+// Config is what a session needs
+type Config struct {
+	Player    player.Service
+	LrclibURL string
+	Cache     lyrics.CachePolicy
+	// SyncOffset applies to tracks without an offset of their own
+	SyncOffset float64
+}
+
+// Session follows a player without the viewer: it fetches the lyrics of
+// each track and works out the current line, for pipe, status and serve
+type Session struct {
+	player        player.Service
+	lrclibURL     string
+	cachePolicy   lyrics.CachePolicy
+	defaultOffset float64
+
+	mu      sync.RWMutex
+	snap    Snapshot
+	tracker lyrics.LineTracker
+	// fetchGen tells the lyrics of the current track from those of a track
+	// that changed while they were fetched
+	fetchGen int
+
+	// the position is read at baseAt and extrapolated from there
+	baseMicros int64
+	baseAt     time.Time
+	rate       float64
+}
+
+type fetchResult struct {
+	gen    int
+	lines  []lyrics.TimedLine
+	plain  string
+	offset float64
+	source string
+	err    error
+}
+
+func New(cfg Config) *Session {
+	return &Session{
+		player:        cfg.Player,
+		lrclibURL:     cfg.LrclibURL,
+		cachePolicy:   cfg.Cache,
+		defaultOffset: cfg.SyncOffset,
+		snap:          Snapshot{Index: -1},
+	}
+}
+
+// Snapshot returns the current state
+func (s *Session) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshotLocked()
+}
+
+func (s *Session) snapshotLocked() Snapshot {
+	snap := s.snap
+	if snap.Track != nil {
+		trackCopy := *snap.Track
+		snap.Track = &trackCopy
+	}
+	snap.Position = s.positionLocked()
+	return snap
+}
+
+// SetSyncOffset changes the offset of the current track and saves it with
+// its cached lyrics
+func (s *Session) SetSyncOffset(offset float64) {
+	s.mu.Lock()
+	s.snap.SyncOffset = math.Round(offset*100) / 100
+	trk := s.snap.Track
+	offset = s.snap.SyncOffset
+	s.mu.Unlock()
+
+	if trk == nil || !s.cachePolicy.Writes() {
+		return
+	}
+	_, _ = cache.GetGlobalCache().SetOffset(trk.Artist, trk.Title, float64(trk.DurationSecs), offset)
+}
+
+// Run follows the player until ctx is done, calling emit for every change
+// from the calling goroutine
+func (s *Session) Run(ctx context.Context, emit func(Event)) error {
+	fetched := make(chan fetchResult)
+	ticker := time.NewTicker(config.PollInterval)
+	defer ticker.Stop()
+
+	var lastPosition time.Time
+	resync := true
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case result := <-fetched:
+			if s.applyLyrics(result) {
+				emit(Event{Type: EventLyrics, Snapshot: s.Snapshot()})
+				if s.updateLine() {
+					emit(Event{Type: EventLine, Snapshot: s.Snapshot()})
+				}
+			}
+			continue
+
+		case <-s.player.Events():
+			// the state is read on the next tick, any event may have moved
+			// the position
+			resync = true
+			continue
+
+		case <-ticker.C:
+		}
+
+		if s.player.Poll() != nil {
+			continue
+		}
+		state := s.player.GetState()
+
+		s.mu.Lock()
+		trackChanged := !state.Track.IsSameTrack(s.snap.Track)
+		playingChanged := state.Playing != s.snap.Playing
+		if trackChanged {
+			s.resetLocked(state.Track)
+			if state.Track != nil {
+				go s.fetch(ctx, state.Track, s.fetchGen, fetched)
+			}
+		}
+		s.snap.Playing = state.Playing
+		s.rate = state.Rate
+		s.mu.Unlock()
+
+		if trackChanged {
+			emit(Event{Type: EventTrack, Snapshot: s.Snapshot()})
+		}
+		if playingChanged {
+			emit(Event{Type: EventPlayback, Snapshot: s.Snapshot()})
+		}
+
+		seeked := resync || trackChanged || playingChanged
+		if seeked || time.Since(s.baseAt) >= positionInterval {
+			micros, err := s.player.GetPositionMicros()
+			if err == nil {
+				s.mu.Lock()
+				s.baseMicros = micros
+				s.baseAt = time.Now()
+				s.mu.Unlock()
+			}
+			resync = false
+		}
+
+		if s.updateLine() {
+			emit(Event{Type: EventLine, Snapshot: s.Snapshot()})
+		}
+		if state.Playing && (seeked || time.Since(lastPosition) >= positionInterval) {
+			lastPosition = time.Now()
+			emit(Event{Type: EventPosition, Snapshot: s.Snapshot()})
+		}
+	}
+}
+
+// Once reads the player and the lyrics of its track a single time, for
+// commands that print the state and exit
+func (s *Session) Once(ctx context.Context) (Snapshot, error) {
+	err := s.player.Poll()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	state := s.player.GetState()
+
+	s.mu.Lock()
+	s.resetLocked(state.Track)
+	s.snap.Playing = state.Playing
+	s.rate = state.Rate
+	gen := s.fetchGen
+	s.mu.Unlock()
+
+	if state.Track == nil {
+		return s.Snapshot(), nil
+	}
+
+	result := s.load(ctx, state.Track)
+	result.gen = gen
+	s.applyLyrics(result)
+
+	// read the position after the fetch, which may have taken a while
+	micros, err := s.player.GetPositionMicros()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	s.mu.Lock()
+	s.baseMicros = micros
+	s.baseAt = time.Now()
+	s.mu.Unlock()
+
+	s.updateLine()
+	return s.Snapshot(), nil
+}
+
+// resetLocked forgets the previous track
+func (s *Session) resetLocked(trk *track.Info) {
+	if trk != nil {
+		trackCopy := *trk
+		trk = &trackCopy
+	}
+	s.fetchGen++
+	s.snap = Snapshot{
+		Track:   trk,
+		Playing: s.snap.Playing,
+		Index:   -1,
+		Loading: trk != nil,
+	}
+	s.tracker = lyrics.LineTracker{}
+}
+
+func (s *Session) fetch(ctx context.Context, trk *track.Info, gen int, fetched chan<- fetchResult) {
+	result := s.load(ctx, trk)
+	result.gen = gen
+
+	select {
+	case fetched <- result:
+	case <-ctx.Done():
+	}
+}
+
+// load fetches the lyrics of a track
+func (s *Session) load(ctx context.Context, trk *track.Info) fetchResult {
+	var result fetchResult
+
+	data, err := lyrics.Fetch(ctx, s.lrclibURL, &lyrics.TrackParams{
+		Title:        trk.Title,
+		Artist:       trk.Artist,
+		Album:        trk.Album,
+		DurationSecs: trk.DurationSecs,
+		Cache:        s.cachePolicy,
+	})
+	if err != nil {
+		result.err = err
+	} else {
+		result.lines = lyrics.SelectLayer(lyrics.ParseSynced(data.SyncedLyrics), data.Translation)
+		result.plain = data.PlainLyrics
+		result.offset = data.SyncOffset
+		result.source = data.Source
+	}
+
+	return result
+}
+
+// applyLyrics takes fetched lyrics, unless the track changed meanwhile
+func (s *Session) applyLyrics(result fetchResult) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if result.gen != s.fetchGen {
+		return false
+	}
+
+	s.snap.Loading = false
+	s.snap.Err = result.err
+	s.snap.Lines = result.lines
+	s.snap.Plain = result.plain
+	s.snap.SyncOffset = result.offset
+	if result.offset == 0 {
+		s.snap.SyncOffset = s.defaultOffset
+	}
+	s.snap.Source = result.source
+	s.snap.Index = -1
+	s.tracker = lyrics.NewLineTracker(result.lines)
+	return true
+}
+
+// updateLine finds the current line and reports whether it changed
+func (s *Session) updateLine() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.snap.Lines) == 0 {
+		return false
+	}
+
+	offset := s.snap.SyncOffset
+	if s.rate > 0 {
+		offset *= s.rate
+	}
+	index := s.tracker.Find(s.positionLocked() + offset)
+	if index == s.snap.Index {
+		return false
+	}
+	s.snap.Index = index
+	return true
+}
+
+func (s *Session) positionLocked() float64 {
+	micros := s.baseMicros
+	if s.snap.Playing && !s.baseAt.IsZero() {
+		elapsed := time.Since(s.baseAt).Microseconds()
+		if s.rate > 0 {
+			elapsed = int64(float64(elapsed) * s.rate)
+		}
+		micros += elapsed
+	}
+
+	pos := float64(micros) / 1_000_000
+	if s.snap.Track != nil && s.snap.Track.DurationSecs > 0 {
+		pos = min(pos, float64(s.snap.Track.DurationSecs))
+	}
+	return max(pos, 0)
+}
@@ -0,0 +1,280 @@
+package spotify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/httpclient"
+)
+
+const (
+	authorizeURL = "https://accounts.spotify.com/authorize"
+	tokenURL     = "https://accounts.spotify.com/api/token"
+
+	// DefaultRedirectURI has to be registered in the spotify app settings
+	DefaultRedirectURI = "http://127.0.0.1:8898/callback"
+
+	scopes = "user-read-currently-playing user-read-playback-state user-modify-playback-state"
+
+	tokenFileName = "spotify-token.json"
+	// refresh a little before the token actually expires
+	tokenExpiryMargin = time.Minute
+)
+
+// Token is the oauth token pair, persisted between runs
+type Token struct {
+	ClientID     string    `json:"client_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// ErrNotLoggedIn is returned when no token has been saved yet
+var ErrNotLoggedIn = errors.New("not logged in to spotify (run: lyrecho spotify login)")
+
+// TokenPath returns where the token is stored
+func TokenPath() (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configDir, "lyrecho", tokenFileName), nil
+}
+
+// LoadToken reads the saved token
+func LoadToken() (*Token, error) {
+	path, err := TokenPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotLoggedIn
+		}
+		return nil, err
+	}
+
+	var token Token
+	err = json.Unmarshal(data, &token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token file %s: %w", path, err)
+	}
+
+	return &token, nil
+}
+
+// SaveToken writes the token, readable only by the current user
+func SaveToken(token *Token) error {
+	path, err := TokenPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// DeleteToken forgets the saved token
+func DeleteToken() error {
+	path, err := TokenPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Login runs the authorization code flow with pkce, so no client secret is
+// needed. openURL is called with the url the user has to visit; the code
+// comes back through a short-lived local server on the redirect uri.
+func Login(ctx context.Context, clientID string, redirectURI string, openURL func(string)) (*Token, error) {
+	if clientID == "" {
+		return nil, errors.New("spotify client id is required")
+	}
+	if redirectURI == "" {
+		redirectURI = DefaultRedirectURI
+	}
+
+	redirect, err := url.Parse(redirectURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect uri: %w", err)
+	}
+
+	verifier, err := randomString(64)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	query := url.Values{}
+	query.Set("client_id", clientID)
+	query.Set("response_type", "code")
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", scopes)
+	query.Set("state", state)
+	query.Set("code_challenge_method", "S256")
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", redirect.Host, err)
+	}
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+	var once sync.Once
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		var result callbackResult
+		switch {
+		case params.Get("state") != state:
+			result.err = errors.New("state mismatch in spotify callback")
+		case params.Get("error") != "":
+			result.err = fmt.Errorf("spotify denied access: %s", params.Get("error"))
+		default:
+			result.code = params.Get("code")
+		}
+
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "lyrecho is connected to spotify, you can close this tab.")
+		}
+
+		once.Do(func() { results <- result })
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	openURL(authorizeURL + "?" + query.Encode())
+
+	var result callbackResult
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-results:
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", result.code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", clientID)
+	form.Set("code_verifier", verifier)
+
+	return requestToken(ctx, clientID, form)
+}
+
+// Refresh exchanges the refresh token for a new access token
+func Refresh(ctx context.Context, token *Token) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", token.RefreshToken)
+	form.Set("client_id", token.ClientID)
+
+	refreshed, err := requestToken(ctx, token.ClientID, form)
+	if err != nil {
+		return nil, err
+	}
+
+	// spotify may or may not rotate the refresh token
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+
+	return refreshed, nil
+}
+
+func requestToken(ctx context.Context, clientID string, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpclient.Get().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed: %s %s", payload.Error, payload.ErrorDescription)
+	}
+
+	return &Token{
+		ClientID:     clientID,
+		AccessToken:  payload.AccessToken,
+		RefreshToken: payload.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func randomString(length int) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)[:length], nil
+}
@@ -0,0 +1,293 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/httpclient"
+)
+
+const apiBaseURL = "https://api.spotify.com/v1"
+
+// Client calls the web api with the saved token, refreshing it as needed
+type Client struct {
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewClient creates a client from the saved login
+func NewClient() (*Client, error) {
+	token, err := LoadToken()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{token: token}, nil
+}
+
+// Playback is what the currently-playing endpoint reports
+type Playback struct {
+	Playing    bool
+	ProgressMs int64
+	Shuffle    bool
+	// Repeat is off, track or context
+	Repeat string
+	Item   *Item
+}
+
+// Item is a track, episodes and ads have no item
+type Item struct {
+	ID         string
+	Title      string
+	Artist     string
+	Album      string
+	DurationMs int64
+	ArtworkURL string
+}
+
+type currentlyPlayingResponse struct {
+	IsPlaying    bool   `json:"is_playing"`
+	ProgressMs   int64  `json:"progress_ms"`
+	Type         string `json:"currently_playing_type"`
+	ShuffleState bool   `json:"shuffle_state"`
+	RepeatState  string `json:"repeat_state"`
+	Item         *struct {
+		ID         string `json:"id"`
+		URI        string `json:"uri"`
+		Name       string `json:"name"`
+		DurationMs int64  `json:"duration_ms"`
+		Artists    []struct {
+			Name string `json:"name"`
+		} `json:"artists"`
+		Album struct {
+			Name   string `json:"name"`
+			Images []struct {
+				URL string `json:"url"`
+			} `json:"images"`
+		} `json:"album"`
+	} `json:"item"`
+}
+
+// CurrentlyPlaying returns the current playback, nil when nothing is playing
+// on any device
+func (c *Client) CurrentlyPlaying(ctx context.Context) (*Playback, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/me/player/currently-playing", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var payload currentlyPlayingResponse
+	err = json.NewDecoder(resp.Body).Decode(&payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode playback: %w", err)
+	}
+
+	playback := &Playback{
+		Playing:    payload.IsPlaying,
+		ProgressMs: payload.ProgressMs,
+		Shuffle:    payload.ShuffleState,
+		Repeat:     payload.RepeatState,
+	}
+
+	if payload.Item != nil && payload.Type == "track" {
+		item := &Item{
+			ID:         payload.Item.URI,
+			Title:      payload.Item.Name,
+			Album:      payload.Item.Album.Name,
+			DurationMs: payload.Item.DurationMs,
+		}
+		if item.ID == "" {
+			item.ID = payload.Item.ID
+		}
+		if len(payload.Item.Artists) > 0 {
+			item.Artist = payload.Item.Artists[0].Name
+		}
+		// images are sorted largest first
+		if len(payload.Item.Album.Images) > 0 {
+			item.ArtworkURL = payload.Item.Album.Images[0].URL
+		}
+		playback.Item = item
+	}
+
+	return playback, nil
+}
+
+func (c *Client) Play(ctx context.Context) error {
+	return c.action(ctx, http.MethodPut, "/me/player/play", nil)
+}
+
+func (c *Client) Pause(ctx context.Context) error {
+	return c.action(ctx, http.MethodPut, "/me/player/pause", nil)
+}
+
+func (c *Client) Next(ctx context.Context) error {
+	return c.action(ctx, http.MethodPost, "/me/player/next", nil)
+}
+
+func (c *Client) Previous(ctx context.Context) error {
+	return c.action(ctx, http.MethodPost, "/me/player/previous", nil)
+}
+
+// Seek jumps to an absolute position in the current track
+func (c *Client) Seek(ctx context.Context, positionMs int64) error {
+	query := url.Values{}
+	query.Set("position_ms", strconv.FormatInt(positionMs, 10))
+	return c.action(ctx, http.MethodPut, "/me/player/seek", query)
+}
+
+// Volume returns the volume of the active device in percent
+func (c *Client) Volume(ctx context.Context) (int, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/me/player", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return 0, errors.New("no active spotify device")
+	}
+
+	var payload struct {
+		Device struct {
+			VolumePercent *int `json:"volume_percent"`
+		} `json:"device"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode player: %w", err)
+	}
+
+	// some devices, like phones, don't allow remote volume control
+	if payload.Device.VolumePercent == nil {
+		return 0, errors.New("spotify device has no volume control")
+	}
+
+	return *payload.Device.VolumePercent, nil
+}
+
+// SetVolume sets the volume of the active device in percent
+func (c *Client) SetVolume(ctx context.Context, percent int) error {
+	query := url.Values{}
+	query.Set("volume_percent", strconv.Itoa(percent))
+	return c.action(ctx, http.MethodPut, "/me/player/volume", query)
+}
+
+func (c *Client) SetShuffle(ctx context.Context, enabled bool) error {
+	query := url.Values{}
+	query.Set("state", strconv.FormatBool(enabled))
+	return c.action(ctx, http.MethodPut, "/me/player/shuffle", query)
+}
+
+// SetRepeat takes off, track or context
+func (c *Client) SetRepeat(ctx context.Context, state string) error {
+	query := url.Values{}
+	query.Set("state", state)
+	return c.action(ctx, http.MethodPut, "/me/player/repeat", query)
+}
+
+func (c *Client) action(ctx context.Context, method string, path string, query url.Values) error {
+	resp, err := c.do(ctx, method, path, query)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// accessToken returns a valid access token, refreshing and saving it when it
+// is about to expire
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Until(c.token.Expiry) > tokenExpiryMargin {
+		return c.token.AccessToken, nil
+	}
+
+	refreshed, err := Refresh(ctx, c.token)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh spotify token: %w", err)
+	}
+	c.token = refreshed
+
+	// a failed save only means refreshing again next run
+	_ = SaveToken(refreshed)
+
+	return refreshed.AccessToken, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values) (*http.Response, error) {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := apiBaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpclient.Get().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("spotify request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, apiError(resp)
+	}
+
+	return resp, nil
+}
+
+// RateLimitError is returned on 429, RetryAfter says how long to back off
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("spotify rate limit, retry after %s", e.RetryAfter)
+}
+
+func apiError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if seconds <= 0 {
+			seconds = 1
+		}
+		return &RateLimitError{RetryAfter: time.Duration(seconds) * time.Second}
+	}
+
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(body, &payload) == nil && payload.Error.Message != "" {
+		return fmt.Errorf("spotify api: %s (%d)", payload.Error.Message, resp.StatusCode)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("spotify api: unauthorized (run: lyrecho spotify login)")
+	}
+	return fmt.Errorf("spotify api: unexpected status %d", resp.StatusCode)
+}
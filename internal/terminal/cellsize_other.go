@@ -0,0 +1,22 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// queryCellSize asks the tty for its size in pixels, which most terminals
+// fill in alongside rows and columns. zero means unknown.
+func queryCellSize() (int, int) {
+	for _, f := range []*os.File{os.Stdout, os.Stdin} {
+		ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+		if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+			continue
+		}
+		return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row)
+	}
+	return 0, 0
+}
@@ -0,0 +1,108 @@
+package terminal
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// ColorDepth is how many colors the terminal can show
+type ColorDepth int
+
+const (
+	ColorsNone ColorDepth = iota
+	Colors16
+	Colors256
+	ColorsTrue
+)
+
+func (d ColorDepth) String() string {
+	switch d {
+	case ColorsTrue:
+		return "truecolor"
+	case Colors256:
+		return "256"
+	case Colors16:
+		return "16"
+	default:
+		return "none"
+	}
+}
+
+// profile is the termenv profile lipgloss maps every hex color through,
+// picking the nearest color the terminal has
+func (d ColorDepth) profile() termenv.Profile {
+	switch d {
+	case ColorsTrue:
+		return termenv.TrueColor
+	case Colors256:
+		return termenv.ANSI256
+	case Colors16:
+		return termenv.ANSI
+	default:
+		return termenv.Ascii
+	}
+}
+
+// terminals that support true color without saying so in COLORTERM
+var trueColorPrograms = map[string]bool{
+	"iTerm.app": true,
+	"WezTerm":   true,
+	"vscode":    true,
+	"ghostty":   true,
+	"Hyper":     true,
+}
+
+// detectColorDepth reads the color support from the environment.
+// LYRECHO_COLORS (truecolor, 256, 16 or none) overrides the guess.
+func detectColorDepth() ColorDepth {
+	switch strings.ToLower(os.Getenv("LYRECHO_COLORS")) {
+	case "truecolor", "24bit", "true":
+		return ColorsTrue
+	case "256":
+		return Colors256
+	case "16", "8":
+		return Colors16
+	case "none", "0", "off":
+		return ColorsNone
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return ColorsNone
+	}
+
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorsTrue
+	}
+
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case term == "dumb":
+		return ColorsNone
+	case strings.HasSuffix(term, "-direct"), strings.Contains(term, "kitty"),
+		strings.Contains(term, "alacritty"), strings.Contains(term, "foot"):
+		return ColorsTrue
+	case trueColorPrograms[os.Getenv("TERM_PROGRAM")], os.Getenv("WT_SESSION") != "":
+		return ColorsTrue
+	case strings.Contains(term, "256color"):
+		return Colors256
+	default:
+		return Colors16
+	}
+}
+
+// detectLightBackground asks the terminal for its background color (osc 11)
+// and checks its luminance. LYRECHO_BACKGROUND (light or dark) overrides
+// the answer for terminals that don't reply.
+func detectLightBackground() bool {
+	switch strings.ToLower(os.Getenv("LYRECHO_BACKGROUND")) {
+	case "light":
+		return true
+	case "dark":
+		return false
+	}
+	return !lipgloss.HasDarkBackground()
+}
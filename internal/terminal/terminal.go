@@ -9,19 +9,49 @@ import (
 	"os"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/nfnt/resize"
 )
 
 type Capabilities struct {
 	SupportsKittyGraphics bool
 	SupportsRGB           bool
-	TermProgram           string
+	ColorDepth            ColorDepth
+	// LightBackground is set when the terminal has a light background, the
+	// palette is darkened to stay readable on it
+	LightBackground bool
+	TermProgram     string
+	// CellWidth and CellHeight are the size of a cell in pixels, zero when
+	// the terminal doesn't say
+	CellWidth  int
+	CellHeight int
 }
 
+// cells are assumed to be this many pixels when the terminal doesn't report
+// its size
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+// the artwork is always sent as the same kitty image with a single
+// placement, so drawing it again replaces it instead of adding a copy
+const (
+	kittyImageID     = 7031
+	kittyPlacementID = 1
+)
+
 func DetectCapabilities() *Capabilities {
 	caps := &Capabilities{
-		SupportsRGB: true,
+		ColorDepth: detectColorDepth(),
 	}
+	caps.SupportsRGB = caps.ColorDepth == ColorsTrue
+	caps.RefreshCellSize()
+
+	// lipgloss maps every palette and gradient color to the nearest one the
+	// terminal has
+	lipgloss.SetColorProfile(caps.ColorDepth.profile())
+	caps.LightBackground = detectLightBackground()
 
 	termProgram := os.Getenv("TERM_PROGRAM")
 	useKittyGraphics := os.Getenv("LYRECHO_USE_KITTY_GRAPHICS")
@@ -55,7 +85,40 @@ func Reset() {
 	os.Stdout.Sync()
 }
 
-func EncodeImageForKitty(img image.Image, cols int, rows int) string {
+// RefreshCellSize reads the cell size again, it changes with the font size
+// and when the window moves to a screen with a different scale
+func (c *Capabilities) RefreshCellSize() {
+	c.CellWidth, c.CellHeight = queryCellSize()
+}
+
+func (c *Capabilities) cellSize() (int, int) {
+	if c.CellWidth <= 0 || c.CellHeight <= 0 {
+		return defaultCellWidth, defaultCellHeight
+	}
+	return c.CellWidth, c.CellHeight
+}
+
+// ClearKittyImage deletes the artwork placed by the encoders, for frames
+// that don't draw it anymore
+func (c *Capabilities) ClearKittyImage() string {
+	if !c.SupportsKittyGraphics {
+		return ""
+	}
+	return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+}
+
+// EncodeImageForKitty fits the image into a box of cols by rows cells
+func (c *Capabilities) EncodeImageForKitty(img image.Image, cols int, rows int) string {
+	return c.encodeKittyImage(img, cols, rows, false)
+}
+
+// EncodeImageForKittyInPlace places the image without moving the cursor, so
+// text can continue on the same row next to it
+func (c *Capabilities) EncodeImageForKittyInPlace(img image.Image, cols int, rows int) string {
+	return c.encodeKittyImage(img, cols, rows, true)
+}
+
+func (c *Capabilities) encodeKittyImage(img image.Image, cols int, rows int, keepCursor bool) string {
 	if img == nil {
 		return ""
 	}
@@ -68,8 +131,9 @@ func EncodeImageForKitty(img image.Image, cols int, rows int) string {
 		return ""
 	}
 
-	newWidth := uint(cols * 10)
-	newHeight := uint(rows * 20)
+	cellWidth, cellHeight := c.cellSize()
+	newWidth := uint(cols * cellWidth)
+	newHeight := uint(rows * cellHeight)
 
 	aspectRatio := float64(width) / float64(height)
 	targetAspect := float64(newWidth) / float64(newHeight)
@@ -97,7 +161,23 @@ func EncodeImageForKitty(img image.Image, cols int, rows int) string {
 
 	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
 
+	cursor := ""
+	if keepCursor {
+		cursor = "C=1,"
+	}
+
+	// with a known cell size the image is already scaled to fit, drawing it
+	// at its own size keeps the aspect ratio. otherwise let the terminal
+	// stretch it over the box.
+	placement := ""
+	if c.CellWidth <= 0 || c.CellHeight <= 0 {
+		placement = fmt.Sprintf("c=%d,r=%d,", cols, rows)
+	}
+
+	// the old placement goes first, otherwise it stays wherever the layout
+	// had it before a resize or a header toggle
 	var result strings.Builder
+	result.WriteString(c.ClearKittyImage())
 
 	chunkSize := 4096
 	for i := 0; i < len(encoded); i += chunkSize {
@@ -113,7 +193,8 @@ func EncodeImageForKitty(img image.Image, cols int, rows int) string {
 		}
 
 		if i == 0 {
-			result.WriteString(fmt.Sprintf("\x1b_Ga=T,f=100,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, chunk))
+			result.WriteString(fmt.Sprintf("\x1b_Ga=T,f=100,i=%d,p=%d,q=2,%s%sm=%d;%s\x1b\\",
+				kittyImageID, kittyPlacementID, cursor, placement, more, chunk))
 		} else {
 			result.WriteString(fmt.Sprintf("\x1b_Gm=%d;%s\x1b\\", more, chunk))
 		}
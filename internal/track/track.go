@@ -7,6 +7,8 @@ type Info struct {
 	DurationSecs int64
 	ArtworkURL   string
 	TrackID      string
+	// BPM is the tempo from the file's tags, 0 when unknown
+	BPM int
 }
 
 func (t *Info) IsValid() bool {
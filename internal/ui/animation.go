@@ -1,9 +1,76 @@
 package ui
 
 import (
+	"fmt"
 	"math"
+	"sort"
+	"strings"
 )
 
+// AnimConfig tunes the lyric animations
+type AnimConfig struct {
+	// TransitionTicks is how many ticks a line change slides for
+	TransitionTicks int
+	// RevealStep is how much of a new line fades in per tick, 1 shows it at once
+	RevealStep float64
+	Shimmer    bool
+	// Glow is how bright a new line flashes, 0 turns the flash off
+	Glow float64
+}
+
+func DefaultAnimConfig() AnimConfig {
+	return AnimConfig{
+		TransitionTicks: 8,
+		RevealStep:      0.08,
+		Shimmer:         true,
+		Glow:            1,
+	}
+}
+
+// glow brighter than this washes the palette out to white
+const maxGlow = 2.0
+
+var animationPresets = map[string]AnimConfig{
+	"default": DefaultAnimConfig(),
+	"fast":    {TransitionTicks: 4, RevealStep: 0.2, Shimmer: true, Glow: 1},
+	"slow":    {TransitionTicks: 16, RevealStep: 0.04, Shimmer: true, Glow: 1},
+	"calm":    {TransitionTicks: 8, RevealStep: 0.08, Shimmer: false, Glow: 0},
+	"none":    {TransitionTicks: 1, RevealStep: 1, Shimmer: false, Glow: 0},
+}
+
+// Validate checks that the values are in range
+func (c AnimConfig) Validate() error {
+	if c.TransitionTicks < 1 {
+		return fmt.Errorf("transition ticks must be at least 1 (got %d)", c.TransitionTicks)
+	}
+	if c.RevealStep <= 0 || c.RevealStep > 1 {
+		return fmt.Errorf("reveal step must be above 0 and at most 1 (got %g)", c.RevealStep)
+	}
+	if c.Glow < 0 || c.Glow > maxGlow {
+		return fmt.Errorf("glow must be between 0 and %g (got %g)", maxGlow, c.Glow)
+	}
+	return nil
+}
+
+// AnimationNames returns the names of the built-in animation presets, sorted
+func AnimationNames() []string {
+	names := make([]string, 0, len(animationPresets))
+	for name := range animationPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AnimationPreset returns a built-in animation preset by name
+func AnimationPreset(name string) (AnimConfig, error) {
+	preset, ok := animationPresets[strings.ToLower(name)]
+	if !ok {
+		return AnimConfig{}, fmt.Errorf("unknown animation %q (available: %s)", name, strings.Join(AnimationNames(), ", "))
+	}
+	return preset, nil
+}
+
 type AnimState struct {
 	TransitionProgress float64
 	CharReveal         float64
@@ -12,6 +79,7 @@ type AnimState struct {
 	ScrollPosition     float64
 	TargetScrollY      float64
 	PrevScrollY        float64
+	Config             AnimConfig
 }
 
 func (a *AnimState) Reset() {
@@ -24,15 +92,20 @@ func (a *AnimState) Reset() {
 	a.PrevScrollY = 0
 }
 
-func (a *AnimState) Update(tickCount int, newLine bool, transitionTicks int) {
+func (a *AnimState) Update(tickCount int, newLine bool) {
+	transitionTicks := a.Config.TransitionTicks
 	if transitionTicks <= 0 {
 		transitionTicks = 18
 	}
+	revealStep := a.Config.RevealStep
+	if revealStep <= 0 {
+		revealStep = 0.08
+	}
 
 	if newLine {
 		a.TransitionProgress = 0
 		a.CharReveal = 0
-		a.GlowIntensity = 1.0
+		a.GlowIntensity = a.Config.Glow
 		a.PrevScrollY = a.ScrollPosition
 	}
 
@@ -45,7 +118,7 @@ func (a *AnimState) Update(tickCount int, newLine bool, transitionTicks int) {
 	}
 
 	if a.CharReveal < 1.0 {
-		a.CharReveal += 0.08
+		a.CharReveal += revealStep
 		if a.CharReveal > 1.0 {
 			a.CharReveal = 1.0
 		}
@@ -64,6 +137,29 @@ func (a *AnimState) Update(tickCount int, newLine bool, transitionTicks int) {
 	a.ShimmerPhase = float64(tickCount) * 0.05
 }
 
+// beatGlow is how bright the glow pulses on each beat, relative to the
+// glow of a new line
+const beatGlow = 0.3
+
+// Pulse drives the shimmer and glow from the song's beat instead of the
+// tick count. beats counts beats since the first one, the fraction is how
+// far into the current beat playback is.
+func (a *AnimState) Pulse(beats float64) {
+	// one shimmer sweep every two beats
+	a.ShimmerPhase = beats * math.Pi
+
+	if a.Config.Glow > 0 {
+		phase := beats - math.Floor(beats)
+		pulse := math.Pow(1-phase, 3) * a.Config.Glow * beatGlow
+		a.GlowIntensity = max(a.GlowIntensity, pulse)
+	}
+}
+
+// Settled reports whether every transition has finished
+func (a *AnimState) Settled() bool {
+	return a.TransitionProgress >= 1 && a.CharReveal >= 1 && a.GlowIntensity == 0
+}
+
 func (a *AnimState) SlideOffset() float64 {
 	return easeOutCubic(a.TransitionProgress)
 }
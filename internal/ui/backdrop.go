@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"image"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/colors"
+)
+
+// backdropBrightness dims the blurred artwork so the lyrics stay readable
+const backdropBrightness = 0.3
+
+const sgrReset = "\x1b[0m"
+
+// drawBackdrop puts a blurred, dimmed copy of the artwork behind the screen.
+// blank cells show it as half blocks, text keeps its colors on top of it.
+// on a light background the artwork is washed out towards white instead.
+func drawBackdrop(screen string, img image.Image, width int, height int, light bool) string {
+	// without colors there is nothing to draw it with
+	if lipgloss.ColorProfile().Color("#000000").Sequence(true) == "" {
+		return screen
+	}
+
+	brightness := backdropBrightness
+	if light {
+		brightness = 1
+	}
+	pixels := artwork.BlurredPixels(img, width, height*2, brightness)
+	if pixels == nil {
+		return screen
+	}
+	if light {
+		for _, row := range pixels {
+			for x, pixel := range row {
+				row[x] = colors.BlendColors(pixel, "#FFFFFF", 1-backdropBrightness)
+			}
+		}
+	}
+
+	lines := strings.Split(screen, "\n")
+	for y := range lines {
+		if y >= height {
+			break
+		}
+		lines[y] = compositeLine(lines[y], pixels[y*2], pixels[y*2+1], width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compositeLine lays a rendered line over a row of backdrop cells. top and
+// bottom are the pixel colors of each cell.
+func compositeLine(line string, top []string, bottom []string, width int) string {
+	profile := lipgloss.ColorProfile()
+	var out strings.Builder
+
+	// the text's own styling since the last reset, restored after each run
+	// of backdrop cells
+	active := ""
+	hasBackground := false
+	inBackdrop := false
+	col := 0
+
+	drawCell := func() {
+		if !inBackdrop {
+			out.WriteString(sgrReset)
+			inBackdrop = true
+		}
+		out.WriteString("\x1b[" + profile.Color(top[col]).Sequence(false) + ";" +
+			profile.Color(bottom[col]).Sequence(true) + "m▀")
+		col++
+	}
+	leaveBackdrop := func() {
+		if inBackdrop {
+			out.WriteString(sgrReset + active)
+			inBackdrop = false
+		}
+	}
+
+	for len(line) > 0 {
+		if line[0] == 0x1b {
+			seq := line[:escapeEnd(line)]
+			line = line[len(seq):]
+
+			leaveBackdrop()
+			out.WriteString(seq)
+			if strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m") {
+				active, hasBackground = applySGR(active, hasBackground, seq)
+			}
+			continue
+		}
+
+		cluster, rest, cellWidth, _ := uniseg.FirstGraphemeClusterInString(line, -1)
+		line = rest
+
+		switch {
+		case col+cellWidth > width:
+			leaveBackdrop()
+			out.WriteString(cluster)
+		case cluster == " " && !hasBackground:
+			drawCell()
+		case hasBackground:
+			leaveBackdrop()
+			out.WriteString(cluster)
+			col += cellWidth
+		default:
+			leaveBackdrop()
+			behind := colors.BlendColors(top[col], bottom[col], 0.5)
+			out.WriteString("\x1b[" + profile.Color(behind).Sequence(true) + "m" + cluster)
+			col += cellWidth
+		}
+	}
+
+	for col < width {
+		drawCell()
+	}
+	if inBackdrop {
+		out.WriteString(sgrReset)
+	}
+
+	return out.String()
+}
+
+// escapeEnd returns the length of the escape sequence at the start of s:
+// csi sequences end at their final byte, string sequences (osc, apc, dcs)
+// at the string terminator
+func escapeEnd(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']', '_', 'P':
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 2
+	}
+	return len(s)
+}
+
+// applySGR tracks the styling a select graphic rendition sequence leaves
+// active, and whether it includes a background color
+func applySGR(active string, hasBackground bool, seq string) (string, bool) {
+	params := strings.Split(seq[2:len(seq)-1], ";")
+	for i := 0; i < len(params); i++ {
+		switch params[i] {
+		case "", "0":
+			active = ""
+			hasBackground = false
+			continue
+		case "38":
+			i += colorParamCount(params, i)
+		case "48":
+			i += colorParamCount(params, i)
+			hasBackground = true
+		case "49":
+			hasBackground = false
+		default:
+			if isBackgroundParam(params[i]) {
+				hasBackground = true
+			}
+		}
+	}
+
+	if params[len(params)-1] != "" && params[len(params)-1] != "0" {
+		active += seq
+	}
+	return active, hasBackground
+}
+
+// colorParamCount is how many parameters follow an extended color, 5;n for
+// the 256 color palette or 2;r;g;b for true color
+func colorParamCount(params []string, i int) int {
+	if i+1 < len(params) && params[i+1] == "5" {
+		return 2
+	}
+	return 4
+}
+
+func isBackgroundParam(param string) bool {
+	switch len(param) {
+	case 2:
+		return param[0] == '4' && param[1] >= '0' && param[1] <= '7'
+	case 3:
+		return param[0] == '1' && param[1] == '0' && param[2] >= '0' && param[2] <= '7'
+	}
+	return false
+}
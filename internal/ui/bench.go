@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+	"strings"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/config"
+	"karolbroda.com/lyrecho/internal/terminal"
+)
+
+// benchArtworkSize is the side of the fake artwork, about what players
+// hand out
+const benchArtworkSize = 640
+
+// BenchScene is a kind of frame lyrecho bench renders
+type BenchScene struct {
+	Name        string
+	Description string
+	setup       func(m *Model)
+}
+
+// BenchScenes are the frames that cover the expensive parts of rendering
+var BenchScenes = []BenchScene{
+	{"pixel", "pixel font lyrics under the stacked header", func(m *Model) {}},
+	{"karaoke", "pixel font with the karaoke gradient sweep", func(m *Model) { m.karaoke = true }},
+	{"plain", "plain text lyrics", func(m *Model) { m.plainText = true }},
+	{"side", "half-block artwork panel beside the lyrics", func(m *Model) { m.layout = LayoutSide }},
+	{"backdrop", "blurred artwork behind the lyrics", func(m *Model) { m.backdrop = true }},
+}
+
+// BenchConfig says what to render and for how long
+type BenchConfig struct {
+	Palette   *artwork.Palette
+	Animation AnimConfig
+	Width     int
+	Height    int
+	Duration  time.Duration
+	// Scenes are the names to run, every scene when empty
+	Scenes []string
+}
+
+// BenchResult is how fast a scene rendered
+type BenchResult struct {
+	Scene   string
+	Frames  int
+	Elapsed time.Duration
+	// Allocs and Bytes are what the frames allocated altogether
+	Allocs uint64
+	Bytes  uint64
+	// FrameSize is the average length of a frame in bytes, escapes included
+	FrameSize int
+}
+
+// FPS is how many frames were rendered per second
+func (r BenchResult) FPS() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Frames) / r.Elapsed.Seconds()
+}
+
+// FrameTime is the average time a frame took
+func (r BenchResult) FrameTime() time.Duration {
+	if r.Frames == 0 {
+		return 0
+	}
+	return r.Elapsed / time.Duration(r.Frames)
+}
+
+// AllocsPerFrame and BytesPerFrame average the allocations over the frames
+func (r BenchResult) AllocsPerFrame() float64 {
+	return float64(r.Allocs) / float64(max(r.Frames, 1))
+}
+
+func (r BenchResult) BytesPerFrame() float64 {
+	return float64(r.Bytes) / float64(max(r.Frames, 1))
+}
+
+// Bench renders each scene off screen for the configured duration. the
+// playback clock moves one poll interval per frame, so line changes and
+// animations run like they do in the viewer.
+func Bench(cfg BenchConfig) ([]BenchResult, error) {
+	scenes, err := benchScenes(cfg.Scenes)
+	if err != nil {
+		return nil, err
+	}
+
+	palette := cfg.Palette
+	if palette == nil {
+		palette = artwork.DefaultPalette()
+	}
+	art := benchArtwork(palette)
+
+	results := make([]BenchResult, 0, len(scenes))
+	for _, scene := range scenes {
+		m := NewPreviewModel(PreviewConfig{
+			Palette:   palette,
+			Animation: cfg.Animation,
+			Label:     "lyrecho bench",
+			TermCaps:  &terminal.Capabilities{SupportsRGB: true, ColorDepth: terminal.ColorsTrue},
+		})
+		m.width = cfg.Width
+		m.height = cfg.Height
+		m.display.Image = art
+		scene.setup(&m)
+
+		results = append(results, benchScene(m, scene.Name, cfg.Duration))
+	}
+	return results, nil
+}
+
+// benchScene renders frames until the duration is up
+func benchScene(m Model, name string, duration time.Duration) BenchResult {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	result := BenchResult{Scene: name}
+	var size int
+	start := time.Now()
+	for result.Frames == 0 || time.Since(start) < duration {
+		m.previewStart = time.Now().Add(-time.Duration(result.Frames) * config.PollInterval)
+		updated, _ := m.handleTick()
+		m = updated.(Model)
+		size += len(m.View())
+		result.Frames++
+	}
+	result.Elapsed = time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	result.Allocs = after.Mallocs - before.Mallocs
+	result.Bytes = after.TotalAlloc - before.TotalAlloc
+	result.FrameSize = size / result.Frames
+	return result
+}
+
+func benchScenes(names []string) ([]BenchScene, error) {
+	if len(names) == 0 {
+		return BenchScenes, nil
+	}
+
+	var scenes []BenchScene
+	for _, name := range names {
+		found := false
+		for _, scene := range BenchScenes {
+			if strings.EqualFold(scene.Name, name) {
+				scenes = append(scenes, scene)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown scene %q (available: %s)", name, strings.Join(BenchSceneNames(), ", "))
+		}
+	}
+	return scenes, nil
+}
+
+// BenchSceneNames lists the scenes in the order they run
+func BenchSceneNames() []string {
+	names := make([]string, len(BenchScenes))
+	for i, scene := range BenchScenes {
+		names[i] = scene.Name
+	}
+	return names
+}
+
+// benchArtwork stands in for album art: rings of the palette colors over a
+// diagonal gradient, detailed enough that scaling it down does real work
+func benchArtwork(palette *artwork.Palette) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, benchArtworkSize, benchArtworkSize))
+	primary := hexToRGBA(palette.Primary)
+	accent := hexToRGBA(palette.Accent)
+	dim := hexToRGBA(palette.Dim)
+
+	center := float64(benchArtworkSize) / 2
+	for y := 0; y < benchArtworkSize; y++ {
+		for x := 0; x < benchArtworkSize; x++ {
+			t := float64(x+y) / float64(2*benchArtworkSize)
+			ring := (math.Sin(math.Hypot(float64(x)-center, float64(y)-center)/12) + 1) / 2
+			c := mixRGBA(mixRGBA(dim, primary, t), accent, ring*0.5)
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func mixRGBA(a color.RGBA, b color.RGBA, t float64) color.RGBA {
+	mix := func(x uint8, y uint8) uint8 { return uint8(float64(x) + t*(float64(y)-float64(x))) }
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 255}
+}
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/colors"
+)
+
+// crossfadeDuration is how long the old track takes to slide out and its
+// colors to blend into the new ones
+const crossfadeDuration = 500 * time.Millisecond
+
+// crossfade carries the previous track off screen and blends the palette
+// that was showing into the current one
+type crossfade struct {
+	start time.Time
+	from  *artwork.Palette
+
+	// outgoing is the previous track's display and animation, shown until
+	// the new lyrics arrive or the fade ends
+	outgoing     TrackDisplay
+	outgoingAnim AnimState
+	outgoingErr  error
+}
+
+// progress reports how far the fade has run, from 0 to 1, and whether it is
+// still running
+func (f crossfade) progress() (float64, bool) {
+	if f.start.IsZero() {
+		return 1, false
+	}
+	p := float64(time.Since(f.start)) / float64(crossfadeDuration)
+	if p >= 1 {
+		return 1, false
+	}
+	return p, true
+}
+
+// startCrossfade begins blending from the palette on screen now. a track
+// change also keeps the old lyrics around to slide them out.
+func (m *Model) startCrossfade(trackChanged bool) {
+	fade := crossfade{start: time.Now(), from: m.shownPalette()}
+	if trackChanged {
+		fade.outgoing = m.display
+		fade.outgoingAnim = m.animState
+		fade.outgoingErr = m.err
+	} else if _, running := m.crossfade.progress(); running {
+		// new artwork mid fade shouldn't cut the old lyrics off
+		fade.start = m.crossfade.start
+		fade.outgoing = m.crossfade.outgoing
+		fade.outgoingAnim = m.crossfade.outgoingAnim
+		fade.outgoingErr = m.crossfade.outgoingErr
+	}
+	m.crossfade = fade
+}
+
+// fading reports whether a crossfade is still running
+func (m Model) fading() bool {
+	_, running := m.crossfade.progress()
+	return running
+}
+
+// shownPalette is the palette to draw with, part way between the old and
+// new colors during a crossfade
+func (m Model) shownPalette() *artwork.Palette {
+	palette := m.display.Palette
+	if palette == nil {
+		palette = artwork.DefaultPalette()
+	}
+
+	p, running := m.crossfade.progress()
+	if !running || m.crossfade.from == nil {
+		return palette
+	}
+	return blendPalettes(m.crossfade.from, palette, easeOutCubic(p))
+}
+
+// showOutgoing reports whether the previous track should still be drawn,
+// which is while fading and before the new lyrics take its place
+func (m Model) showOutgoing() bool {
+	return m.fading() && m.crossfade.outgoing.Track != nil &&
+		len(m.display.Lines) == 0 && !m.oneLine
+}
+
+// renderOutgoing draws the previous track fading into the background while
+// it slides up and out of the screen
+func (m Model) renderOutgoing(width int, height int) string {
+	p, _ := m.crossfade.progress()
+	level := 1 - easeOutCubic(p)
+
+	from := m.crossfade.from
+	if from == nil {
+		from = artwork.DefaultPalette()
+	}
+	base := from.Base()
+	blank := artwork.Palette{
+		Primary:   base,
+		Secondary: base,
+		Accent:    base,
+		Dim:       base,
+		Gradient:  make([]string, len(from.Gradient)),
+		Light:     from.Light,
+	}
+	for i := range blank.Gradient {
+		blank.Gradient[i] = base
+	}
+	faded := blendPalettes(&blank, from, level)
+
+	old := m
+	old.display = m.crossfade.outgoing
+	old.animState = m.crossfade.outgoingAnim
+	old.err = m.crossfade.outgoingErr
+	old.browsing = false
+	old.comparing = false
+
+	lines := strings.Split(old.renderMainScreen(faded, width, height), "\n")
+	shift := min(int(easeOutCubic(p)*float64(height)/3), len(lines))
+	lines = lines[shift:]
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blendPalettes mixes two palettes, t of 0 is from and 1 is to
+func blendPalettes(from *artwork.Palette, to *artwork.Palette, t float64) *artwork.Palette {
+	blended := *to
+	blended.Primary = colors.BlendColors(from.Primary, to.Primary, t)
+	blended.Secondary = colors.BlendColors(from.Secondary, to.Secondary, t)
+	blended.Accent = colors.BlendColors(from.Accent, to.Accent, t)
+	blended.Dim = colors.BlendColors(from.Dim, to.Dim, t)
+
+	if len(from.Gradient) == len(to.Gradient) {
+		blended.Gradient = make([]string, len(to.Gradient))
+		for i := range to.Gradient {
+			blended.Gradient[i] = colors.BlendColors(from.Gradient[i], to.Gradient[i], t)
+		}
+	}
+	if from.Dominant != "" && to.Dominant != "" {
+		blended.Dominant = colors.BlendColors(from.Dominant, to.Dominant, t)
+	}
+	return &blended
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"karolbroda.com/lyrecho/internal/history"
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+// minListened is how long a track has to play to make it into the history,
+// skipping through a playlist shouldn't fill it up
+const minListened = 10 * time.Second
+
+// maxTickGap bounds the time counted between two ticks, a suspended laptop
+// shouldn't count as listening
+const maxTickGap = time.Second
+
+// listening follows the play of the current track for the history
+type listening struct {
+	play history.Play
+	// shown holds the lines that were current while playing
+	shown   map[int]bool
+	counted time.Time
+}
+
+func (l listening) active() bool {
+	return l.shown != nil
+}
+
+// startListening begins a play of the new track
+func (m *Model) startListening(trk *track.Info) {
+	m.listening = listening{}
+	if !m.history || !trk.IsValid() {
+		return
+	}
+
+	m.listening = listening{
+		play: history.Play{
+			Artist:    trk.Artist,
+			Title:     trk.Title,
+			Album:     trk.Album,
+			Duration:  trk.DurationSecs,
+			StartedAt: time.Now(),
+		},
+		shown: make(map[int]bool),
+	}
+}
+
+// countListening adds the time since the last tick while playing, and
+// marks the current line as shown once it's reached
+func (m *Model) countListening() {
+	if !m.listening.active() {
+		return
+	}
+
+	now := time.Now()
+	last := m.listening.counted
+	m.listening.counted = now
+	if last.IsZero() || !m.clock.playing {
+		return
+	}
+
+	elapsed := min(now.Sub(last), maxTickGap).Seconds()
+	m.listening.play.Listened += elapsed
+
+	lines := m.display.Lines
+	if len(lines) == 0 {
+		return
+	}
+	m.listening.play.LyricsTime += elapsed
+
+	index := m.display.CurrentIndex
+	if index >= 0 && index < len(lines) && m.position()+m.lyricOffset() >= lines[index].TimeSeconds {
+		m.listening.shown[index] = true
+	}
+}
+
+// lyricsLoaded notes where the lyrics of the play came from
+func (m *Model) lyricsLoaded(source string, lines int) {
+	if !m.listening.active() {
+		return
+	}
+	m.listening.play.Source = source
+	m.listening.play.Lines = lines
+}
+
+// finishedPlay ends the current play, reporting false when it was too short
+// to keep
+func (m *Model) finishedPlay() (history.Play, bool) {
+	l := m.listening
+	m.listening = listening{}
+	if !l.active() || l.play.Listened < minListened.Seconds() {
+		return history.Play{}, false
+	}
+
+	l.play.EndedAt = time.Now()
+	l.play.LinesShown = len(l.shown)
+	return l.play, true
+}
+
+// finishListening writes the current play to the history in the background
+func (m *Model) finishListening() tea.Cmd {
+	play, ok := m.finishedPlay()
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		_ = history.Append(play)
+		return nil
+	}
+}
+
+// SaveHistory writes the play of the track showing when the viewer quits
+func (m Model) SaveHistory() error {
+	play, ok := m.finishedPlay()
+	if !ok {
+		return nil
+	}
+	return history.Append(play)
+}
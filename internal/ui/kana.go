@@ -0,0 +1,134 @@
+package ui
+
+// wideFont holds the glyphs drawn in a 7x7 cell. voiced kana are composed
+// from their base glyph, see withKanaMarks.
+var wideFont = map[rune][7]uint8{
+	// hiragana
+	'あ': {0b0010000, 0b1111111, 0b0010000, 0b0111110, 0b1010011, 0b1010101, 0b0101010},
+	'い': {0b0000000, 0b1000000, 0b1000010, 0b1000001, 0b1000001, 0b0100000, 0b0000000},
+	'う': {0b0011100, 0b0000000, 0b0111100, 0b1000010, 0b0000010, 0b0000100, 0b0011000},
+	'え': {0b0011100, 0b0000000, 0b1111110, 0b0001000, 0b0011000, 0b0101000, 0b1000111},
+	'お': {0b0100000, 0b1111010, 0b0100001, 0b0111100, 0b1100010, 0b0100010, 0b0101100},
+	'か': {0b0100000, 0b0100010, 0b1111101, 0b0100100, 0b0100101, 0b1000100, 0b0001100},
+	'き': {0b0001000, 0b1111111, 0b0000100, 0b1111111, 0b0011100, 0b0100000, 0b0011110},
+	'く': {0b0000100, 0b0001000, 0b0010000, 0b0100000, 0b0010000, 0b0001000, 0b0000100},
+	'け': {0b1000100, 0b1000100, 0b1011111, 0b1000100, 0b1000100, 0b1001000, 0b1010000},
+	'こ': {0b0000000, 0b0111110, 0b0000001, 0b0000000, 0b0100000, 0b0100000, 0b0011111},
+	'さ': {0b0001000, 0b1111111, 0b0000100, 0b0011110, 0b0100000, 0b0100000, 0b0011110},
+	'し': {0b0100000, 0b0100000, 0b0100000, 0b0100000, 0b0100001, 0b0100010, 0b0011100},
+	'す': {0b0000100, 0b1111111, 0b0001100, 0b0010100, 0b0001100, 0b0000100, 0b0001000},
+	'せ': {0b0100100, 0b0100100, 0b1111111, 0b0100100, 0b0101100, 0b0100000, 0b0011110},
+	'そ': {0b0111100, 0b0001000, 0b0010000, 0b1111111, 0b0001000, 0b0001000, 0b0000110},
+	'た': {0b0100000, 0b1111100, 0b0100111, 0b0100000, 0b1001000, 0b1001000, 0b0000111},
+	'ち': {0b0010000, 0b1111111, 0b0100000, 0b0111100, 0b1100010, 0b0000010, 0b0011100},
+	'つ': {0b0000000, 0b0000000, 0b1111100, 0b0000010, 0b0000010, 0b0000100, 0b0111000},
+	'て': {0b0000000, 0b1111111, 0b0001000, 0b0010000, 0b0010000, 0b0010000, 0b0001110},
+	'と': {0b0100000, 0b0100000, 0b0100110, 0b0111000, 0b1000000, 0b1000000, 0b0111110},
+	'な': {0b0100000, 0b1111010, 0b0100001, 0b1000100, 0b0000100, 0b0011110, 0b0011001},
+	'に': {0b1000000, 0b1011110, 0b1000000, 0b1000000, 0b1010000, 0b1010000, 0b1001111},
+	'ぬ': {0b0100100, 0b0100100, 0b1111110, 0b1010010, 0b1010010, 0b1100111, 0b0100110},
+	'ね': {0b0100000, 0b0100000, 0b1111010, 0b0110101, 0b1010001, 0b1010011, 0b1010011},
+	'の': {0b0000000, 0b0011100, 0b0101010, 0b1001001, 0b1001001, 0b1010001, 0b0100010},
+	'は': {0b1000100, 0b1000100, 0b1011111, 0b1000100, 0b1000100, 0b1001110, 0b1001110},
+	'ひ': {0b0000000, 0b1110010, 0b0010011, 0b0100001, 0b0100001, 0b0100010, 0b0011100},
+	'ふ': {0b0001000, 0b0000100, 0b0001000, 0b0001000, 0b0100100, 0b1010010, 0b1001001},
+	'へ': {0b0000000, 0b0000000, 0b0010000, 0b0101000, 0b1000100, 0b0000010, 0b0000001},
+	'ほ': {0b1011111, 0b1000100, 0b1011111, 0b1000100, 0b1000100, 0b1001110, 0b1001110},
+	'ま': {0b0001000, 0b1111111, 0b0001000, 0b1111111, 0b0001000, 0b0111100, 0b0101110},
+	'み': {0b0111000, 0b0001000, 0b0010010, 0b0111110, 0b1100011, 0b1001010, 0b0110010},
+	'む': {0b0100000, 0b1111000, 0b0100000, 0b1100010, 0b1100001, 0b0100000, 0b0011110},
+	'め': {0b0100100, 0b0100100, 0b1111110, 0b1010010, 0b1010010, 0b1100010, 0b0101100},
+	'も': {0b0001000, 0b0111110, 0b0001000, 0b0111110, 0b0001001, 0b0001001, 0b0000110},
+	'や': {0b0100000, 0b0101110, 0b1111101, 0b0010010, 0b0010000, 0b0001000, 0b0001000},
+	'ゆ': {0b0010000, 0b1011110, 0b1010101, 0b1010101, 0b1100110, 0b0000100, 0b0001000},
+	'よ': {0b0001000, 0b0001111, 0b0001000, 0b0001000, 0b0111100, 0b1001010, 0b0110001},
+	'ら': {0b0011000, 0b0000100, 0b0100000, 0b0101110, 0b0110001, 0b0000001, 0b0011110},
+	'り': {0b0100100, 0b1000100, 0b1000100, 0b1000100, 0b0100100, 0b0000100, 0b0011000},
+	'る': {0b1111100, 0b0001000, 0b0010000, 0b0111100, 0b1000010, 0b0011010, 0b0111100},
+	'れ': {0b0100000, 0b0100000, 0b1111010, 0b0110101, 0b1010001, 0b1010001, 0b1010000},
+	'ろ': {0b1111100, 0b0001000, 0b0010000, 0b0111100, 0b1000010, 0b0000010, 0b0111100},
+	'わ': {0b0100000, 0b0100000, 0b1111010, 0b0110101, 0b1010001, 0b1010001, 0b1010110},
+	'を': {0b0010000, 0b1111110, 0b0100000, 0b0111011, 0b1001100, 0b0001000, 0b0001111},
+	'ん': {0b0001000, 0b0001000, 0b0010000, 0b0010000, 0b0101000, 0b0101001, 0b1000110},
+
+	// katakana
+	'ア': {0b1111111, 0b0000001, 0b0001010, 0b0001000, 0b0001000, 0b0010000, 0b0100000},
+	'イ': {0b0000001, 0b0000010, 0b0001100, 0b0110100, 0b0000100, 0b0000100, 0b0000100},
+	'ウ': {0b0001000, 0b1111111, 0b1000001, 0b0000001, 0b0000010, 0b0000100, 0b0011000},
+	'エ': {0b0000000, 0b1111111, 0b0001000, 0b0001000, 0b0001000, 0b0001000, 0b1111111},
+	'オ': {0b0000100, 0b1111111, 0b0001100, 0b0010100, 0b0100100, 0b1000100, 0b0001100},
+	'カ': {0b0010000, 0b1111111, 0b0010001, 0b0010001, 0b0100001, 0b0100010, 0b1001100},
+	'キ': {0b0010000, 0b1111111, 0b0010000, 0b0010000, 0b1111111, 0b0001000, 0b0001000},
+	'ク': {0b0010000, 0b0111111, 0b1000001, 0b0000010, 0b0000100, 0b0001000, 0b0110000},
+	'ケ': {0b0100000, 0b0111111, 0b1000100, 0b0000100, 0b0000100, 0b0001000, 0b0110000},
+	'コ': {0b0000000, 0b1111110, 0b0000010, 0b0000010, 0b0000010, 0b0000010, 0b1111110},
+	'サ': {0b0100010, 0b1111111, 0b0100010, 0b0000010, 0b0000100, 0b0001000, 0b0110000},
+	'シ': {0b1100000, 0b0010001, 0b1100001, 0b0010010, 0b0000010, 0b0000100, 0b1110000},
+	'ス': {0b0000000, 0b1111110, 0b0000010, 0b0000100, 0b0001100, 0b0010010, 0b1100001},
+	'セ': {0b0100000, 0b0100001, 0b1111111, 0b0100010, 0b0100100, 0b0100000, 0b0011110},
+	'ソ': {0b1000001, 0b0100001, 0b0100010, 0b0000010, 0b0000100, 0b0001000, 0b0110000},
+	'タ': {0b0010000, 0b0111111, 0b1000001, 0b0110010, 0b0001100, 0b0001000, 0b0110000},
+	'チ': {0b0000110, 0b0111000, 0b0001000, 0b1111111, 0b0001000, 0b0001000, 0b0010000},
+	'ツ': {0b1010001, 0b1010001, 0b0000001, 0b0000010, 0b0000010, 0b0000100, 0b0110000},
+	'テ': {0b0111110, 0b0000000, 0b1111111, 0b0001000, 0b0001000, 0b0010000, 0b0100000},
+	'ト': {0b0100000, 0b0100000, 0b0110000, 0b0101100, 0b0100010, 0b0100000, 0b0100000},
+	'ナ': {0b0001000, 0b0001000, 0b1111111, 0b0001000, 0b0001000, 0b0010000, 0b0100000},
+	'ニ': {0b0000000, 0b0111110, 0b0000000, 0b0000000, 0b0000000, 0b1111111, 0b0000000},
+	'ヌ': {0b0000000, 0b1111110, 0b0000010, 0b0100100, 0b0011000, 0b0010100, 0b1100010},
+	'ネ': {0b0001000, 0b1111111, 0b0000010, 0b0001100, 0b0110101, 0b1000100, 0b0000100},
+	'ノ': {0b0000001, 0b0000001, 0b0000010, 0b0000100, 0b0001000, 0b0010000, 0b1100000},
+	'ハ': {0b0000000, 0b0010100, 0b0010010, 0b0100010, 0b0100001, 0b1000001, 0b1000000},
+	'ヒ': {0b1000000, 0b1000110, 0b1110000, 0b1000000, 0b1000000, 0b1000000, 0b0111111},
+	'フ': {0b0000000, 0b1111111, 0b0000001, 0b0000010, 0b0000100, 0b0001000, 0b0110000},
+	'ヘ': {0b0000000, 0b0010000, 0b0101000, 0b1000100, 0b0000010, 0b0000001, 0b0000000},
+	'ホ': {0b0001000, 0b1111111, 0b0001000, 0b0101010, 0b1001001, 0b0001000, 0b0011000},
+	'マ': {0b0000000, 0b1111111, 0b0000001, 0b0100110, 0b0011000, 0b0001000, 0b0000100},
+	'ミ': {0b0111000, 0b0000110, 0b0000000, 0b0111000, 0b0000110, 0b0000000, 0b1111000},
+	'ム': {0b0001000, 0b0001000, 0b0010000, 0b0010000, 0b0100010, 0b0100001, 0b1111111},
+	'メ': {0b0000001, 0b0100010, 0b0010100, 0b0001000, 0b0010100, 0b0100010, 0b1000000},
+	'モ': {0b1111110, 0b0001000, 0b1111111, 0b0001000, 0b0001000, 0b0001000, 0b0000111},
+	'ヤ': {0b0100000, 0b0101111, 0b1110010, 0b0100100, 0b0010000, 0b0010000, 0b0010000},
+	'ユ': {0b0000000, 0b0111100, 0b0000100, 0b0000100, 0b0000100, 0b0000100, 0b1111111},
+	'ヨ': {0b1111110, 0b0000010, 0b0000010, 0b1111110, 0b0000010, 0b0000010, 0b1111110},
+	'ラ': {0b0111110, 0b0000000, 0b1111111, 0b0000001, 0b0000010, 0b0000100, 0b0110000},
+	'リ': {0b1000010, 0b1000010, 0b1000010, 0b1000010, 0b0000010, 0b0000100, 0b0011000},
+	'ル': {0b0100100, 0b0100100, 0b0100100, 0b0100100, 0b0100101, 0b1000101, 0b1000110},
+	'レ': {0b1000000, 0b1000000, 0b1000000, 0b1000001, 0b1000010, 0b1001100, 0b1110000},
+	'ロ': {0b0000000, 0b1111111, 0b1000001, 0b1000001, 0b1000001, 0b1000001, 0b1111111},
+	'ワ': {0b0000000, 0b1111111, 0b1000001, 0b1000001, 0b0000010, 0b0000100, 0b0110000},
+	'ヲ': {0b0000000, 0b1111111, 0b0000001, 0b1111111, 0b0000010, 0b0000100, 0b0110000},
+	'ン': {0b1100000, 0b0000000, 0b0000001, 0b0000010, 0b0000100, 0b0001000, 0b1110000},
+
+	// small kana
+	'ぁ': {0b0000000, 0b0000000, 0b0010000, 0b1111100, 0b0111100, 0b1010110, 0b0101100},
+	'ぃ': {0b0000000, 0b0000000, 0b0000000, 0b1000100, 0b1000010, 0b1000010, 0b0100000},
+	'ぅ': {0b0000000, 0b0000000, 0b0111000, 0b0000000, 0b0111000, 0b0000100, 0b0011000},
+	'ぇ': {0b0000000, 0b0000000, 0b0111000, 0b0000000, 0b1111100, 0b0011000, 0b0100110},
+	'ぉ': {0b0000000, 0b0000000, 0b0100000, 0b1111010, 0b0111000, 0b1100100, 0b0101000},
+	'っ': {0b0000000, 0b0000000, 0b0000000, 0b1111000, 0b0000100, 0b0001000, 0b0110000},
+	'ゃ': {0b0000000, 0b0000000, 0b0100000, 0b0101100, 0b1111010, 0b0010000, 0b0001000},
+	'ゅ': {0b0000000, 0b0000000, 0b0101000, 0b1011110, 0b1010101, 0b1101100, 0b0001000},
+	'ょ': {0b0000000, 0b0000000, 0b0001000, 0b0001110, 0b0001000, 0b0111100, 0b1001010},
+	'ゎ': {0b0000000, 0b0000000, 0b0100000, 0b1111000, 0b0110100, 0b1010010, 0b1010100},
+	'ァ': {0b0000000, 0b0000000, 0b1111100, 0b0000100, 0b0011000, 0b0010000, 0b0100000},
+	'ィ': {0b0000000, 0b0000000, 0b0000100, 0b0001000, 0b0110000, 0b0010000, 0b0010000},
+	'ゥ': {0b0000000, 0b0000000, 0b0010000, 0b1111100, 0b1000100, 0b0001000, 0b0110000},
+	'ェ': {0b0000000, 0b0000000, 0b0000000, 0b1111100, 0b0010000, 0b0010000, 0b1111100},
+	'ォ': {0b0000000, 0b0000000, 0b0001000, 0b1111100, 0b0011000, 0b0101000, 0b1011000},
+	'ッ': {0b0000000, 0b0000000, 0b0000000, 0b1010010, 0b1010010, 0b0000100, 0b0110000},
+	'ャ': {0b0000000, 0b0000000, 0b0100000, 0b1111110, 0b0100100, 0b0010000, 0b0010000},
+	'ュ': {0b0000000, 0b0000000, 0b0000000, 0b0111000, 0b0001000, 0b0001000, 0b1111110},
+	'ョ': {0b0000000, 0b0000000, 0b1111100, 0b0000100, 0b1111100, 0b0000100, 0b1111100},
+	'ヮ': {0b0000000, 0b0000000, 0b1111100, 0b1000100, 0b0000100, 0b0001000, 0b0110000},
+	'ヵ': {0b0000000, 0b0000000, 0b0100000, 0b1111100, 0b0100100, 0b1000100, 0b0011000},
+	'ヶ': {0b0000000, 0b0000000, 0b1000000, 0b1111100, 0b1010000, 0b0010000, 0b0100000},
+
+	// japanese punctuation
+	'ー': {0b0000000, 0b0000000, 0b0000000, 0b1111111, 0b0000000, 0b0000000, 0b0000000},
+	'〜': {0b0000000, 0b0000000, 0b0110000, 0b1001001, 0b0000110, 0b0000000, 0b0000000},
+	'、': {0b0000000, 0b0000000, 0b0000000, 0b0000000, 0b0000000, 0b0100000, 0b0010000},
+	'。': {0b0000000, 0b0000000, 0b0000000, 0b0000000, 0b0110000, 0b1001000, 0b0110000},
+	'「': {0b0111100, 0b0100000, 0b0100000, 0b0100000, 0b0100000, 0b0000000, 0b0000000},
+	'」': {0b0000000, 0b0000000, 0b0000010, 0b0000010, 0b0000010, 0b0000010, 0b0011110},
+	'・': {0b0000000, 0b0000000, 0b0000000, 0b0001000, 0b0000000, 0b0000000, 0b0000000},
+}
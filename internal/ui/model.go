@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"fmt"
 	"image"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -31,7 +33,86 @@ func (l LoadingState) IsLoadingArtwork() bool {
 	return l == LoadingArtwork || l == LoadingBoth
 }
 
-type TickMsg time.Time
+// EndBehavior controls what the viewer shows once the last lyric line is over
+type EndBehavior int
+
+const (
+	EndIdle EndBehavior = iota
+	EndHold
+	EndOutro
+	EndScroll
+)
+
+// Layout is how the artwork and the lyrics share the screen
+type Layout int
+
+const (
+	// LayoutStacked shows a small artwork thumbnail in a header above the lyrics
+	LayoutStacked Layout = iota
+	// LayoutSide docks a large artwork panel to the left of the lyrics
+	LayoutSide
+	// LayoutColumns puts the whole lyric sheet in a second column next to
+	// the synced lyrics, for very wide terminals
+	LayoutColumns
+)
+
+func ParseLayout(s string) (Layout, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "stacked":
+		return LayoutStacked, nil
+	case "side":
+		return LayoutSide, nil
+	case "columns", "wide":
+		return LayoutColumns, nil
+	default:
+		return LayoutStacked, fmt.Errorf("invalid layout %q (use stacked, side or columns)", s)
+	}
+}
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutSide:
+		return "side"
+	case LayoutColumns:
+		return "columns"
+	default:
+		return "stacked"
+	}
+}
+
+// artworkImage is the track's artwork, or the placeholder without it
+func (m Model) artworkImage() image.Image {
+	if m.display.Image != nil {
+		return m.display.Image
+	}
+	return m.display.placeholder
+}
+
+// lastLineHoldSeconds is how long the last line counts as "current" before the
+// lyrics are considered finished
+const lastLineHoldSeconds = 6.0
+
+func ParseEndBehavior(s string) (EndBehavior, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "idle":
+		return EndIdle, nil
+	case "hold", "last":
+		return EndHold, nil
+	case "outro":
+		return EndOutro, nil
+	case "scroll", "loop":
+		return EndScroll, nil
+	default:
+		return EndIdle, fmt.Errorf("invalid end behavior %q (use hold, outro, idle or scroll)", s)
+	}
+}
+
+// TickMsg drives polling and animation. gen identifies the tick chain, a
+// chain is dropped when a newer one replaces it.
+type TickMsg struct {
+	Time time.Time
+	gen  int
+}
 
 type TrackChangedMsg struct {
 	Track *track.Info
@@ -54,7 +135,37 @@ type ArtworkFetchedMsg struct {
 type LyricsFetchedMsg struct {
 	Lines      []lyrics.TimedLine
 	SyncOffset float64
-	Err        error
+	Source     string
+	// Plain is the plain text version the provider had next to the synced one
+	Plain       string
+	Translation lyrics.Layer
+	Err         error
+}
+
+// LyricsProgressMsg reports a search attempt of a running lyrics fetch,
+// updates is where the next one comes from
+type LyricsProgressMsg struct {
+	Progress lyrics.Progress
+	updates  <-chan lyrics.Progress
+}
+
+// VolumeChangedMsg reports the volume after a volume key
+type VolumeChangedMsg struct {
+	Volume float64
+	Err    error
+}
+
+// OptionChangedMsg reports the result of toggling shuffle or loop, Label
+// describes the new setting
+type OptionChangedMsg struct {
+	Label string
+	Err   error
+}
+
+// UpNextFetchedMsg carries the tracks queued after the current one
+type UpNextFetchedMsg struct {
+	Tracks []track.Info
+	Err    error
 }
 
 type PlayerEventMsg struct {
@@ -68,17 +179,38 @@ type TrackDisplay struct {
 	Lines        []lyrics.TimedLine
 	CurrentIndex int
 	PrevIndex    int
+	lineTracker  lyrics.LineTracker
+	sections     []lyrics.Section
+	// placeholder stands in for the artwork while it loads or when there
+	// is none
+	placeholder image.Image
 }
 
 type Model struct {
-	player     *player.Service
+	player     player.Service
 	lrclibURL  string
 	syncOffset float64
 	hideHeader bool
+	plainText  bool
+	oneLine    bool
+	karaoke    bool
+	timestamps bool
+	sections   bool
+	bgTint     bool
+	backdrop   bool
+	statusBar  bool
 	termCaps   *terminal.Capabilities
+	override   artwork.Override
+
+	// cachePolicy is what --no-cache leaves of the lyrics cache
+	cachePolicy lyrics.CachePolicy
+
+	// history records the tracks played, listening is the current one
+	history   bool
+	listening listening
 
 	display        TrackDisplay
-	positionSecs   int64
+	clock          playbackClock
 	loadingState   LoadingState
 	err            error
 	quitting       bool
@@ -86,48 +218,341 @@ type Model struct {
 	height         int
 	lastLineChange time.Time
 	tickCount      int
+	tickGen        int
 	animState      AnimState
+	endBehavior    EndBehavior
+	layout         Layout
+	contextLines   int
+	preview        bool
+	previewStart   time.Time
+	browsing       bool
+	browseIndex    int
+	sheet          bool
+	sheetLine      int
+	sheetFollow    bool
+	lastScroll     time.Time
+	relockAfter    time.Duration
+	idleAfter      time.Duration
+	pausedSince    time.Time
+	prompting      bool
+	promptInput    string
+	editingSearch  bool
+	searchInput    string
+	searchTrack    *track.Info
+	refetching     bool
+	volumeKeys     [2]string
+	shuffle        bool
+	loop           string
+	toast          string
+	toastUntil     time.Time
+	upNext         []track.Info
+	showUpNext     bool
+	// prefetched is the track whose lyrics were last fetched ahead of time
+	prefetched string
+	// lyricsSource is where the current lyrics came from, playerErr the
+	// last failure to reach the player
+	lyricsSource string
+	playerErr    error
+	// syncedLines are the lyrics as fetched, plainLyrics the plain text of
+	// the same entry. usingPlain shows the plain text timed along the synced
+	// lines instead.
+	syncedLines []lyrics.TimedLine
+	plainLyrics string
+	usingPlain  bool
+	comparing   bool
+	translation lyrics.Layer
+	// bpm is the tempo the animations pulse to, from the tags or tapped in,
+	// with a beat falling on beatAnchor seconds into the track
+	bpm        float64
+	beatAnchor float64
+	taps       []time.Time
+
+	levels         LevelSource
+	showVisualizer bool
+
+	crossfade crossfade
+
+	// fetchUpdates streams the progress of the current lyrics fetch, older
+	// fetches are ignored
+	fetchUpdates  <-chan lyrics.Progress
+	fetchProgress lyrics.Progress
+}
+
+// LevelSource provides audio levels from 0 to 1 for the visualizer
+type LevelSource interface {
+	Levels() []float64
 }
 
 type ModelConfig struct {
-	Player     *player.Service
-	LrclibURL  string
-	SyncOffset float64
-	HideHeader bool
-	TermCaps   *terminal.Capabilities
+	Player      player.Service
+	LrclibURL   string
+	CachePolicy lyrics.CachePolicy
+	SyncOffset  float64
+	HideHeader  bool
+	PlainText   bool
+	OneLine     bool
+	Karaoke     bool
+	Sections    bool
+	BgTint      bool
+	Backdrop    bool
+	StatusBar   bool
+	TermCaps    *terminal.Capabilities
+	EndBehavior EndBehavior
+	Layout      Layout
+	Animation   AnimConfig
+	// ContextLines is how many lines to show before and after the current
+	// one, negative picks from the terminal height
+	ContextLines int
+	// VolumeKeys are the keys that lower and raise the volume
+	VolumeKeys [2]string
+	// RelockAfter is how long after manual scrolling the view follows the
+	// playing line again, 0 waits for the follow key
+	RelockAfter time.Duration
+	// IdleClock is how long playback has to be paused before the lyrics give
+	// way to a clock, 0 never shows it
+	IdleClock time.Duration
+	// Override replaces or tints the colors taken from the artwork
+	Override artwork.Override
+	// Levels feeds the visualizer band under the lyrics, nil leaves it out
+	Levels LevelSource
+	// History records every track played to the listening history
+	History bool
+}
+
+// previewLineSeconds is how long each fake line stays current in preview mode
+const previewLineSeconds = 3
+
+// PreviewConfig configures a model that plays a looping fake lyric sequence
+// instead of following a player, for iterating on themes and animations
+type PreviewConfig struct {
+	Palette   *artwork.Palette
+	Animation AnimConfig
+	Text      string
+	Label     string
+	TermCaps  *terminal.Capabilities
 }
 
 func NewModel(cfg ModelConfig) Model {
 	m := Model{
 		player:         cfg.Player,
 		lrclibURL:      cfg.LrclibURL,
+		cachePolicy:    cfg.CachePolicy,
 		syncOffset:     cfg.SyncOffset,
 		hideHeader:     cfg.HideHeader,
+		plainText:      cfg.PlainText,
+		oneLine:        cfg.OneLine,
+		karaoke:        cfg.Karaoke,
+		sections:       cfg.Sections,
+		bgTint:         cfg.BgTint,
+		backdrop:       cfg.Backdrop,
+		statusBar:      cfg.StatusBar,
+		contextLines:   cfg.ContextLines,
 		termCaps:       cfg.TermCaps,
+		endBehavior:    cfg.EndBehavior,
+		layout:         cfg.Layout,
 		lastLineChange: time.Now(),
+		volumeKeys:     cfg.VolumeKeys,
+		relockAfter:    cfg.RelockAfter,
+		idleAfter:      cfg.IdleClock,
+		sheetFollow:    true,
+		override:       cfg.Override,
+		levels:         cfg.Levels,
+		showVisualizer: cfg.Levels != nil,
+		history:        cfg.History,
+	}
+	if m.volumeKeys == ([2]string{}) {
+		m.volumeKeys = [2]string{"[", "]"}
 	}
 
 	m.display.CurrentIndex = -1
-	m.display.Palette = artwork.DefaultPalette()
+	m.setPalette(artwork.DefaultPalette())
+
+	m.animState.Config = cfg.Animation
+	if cfg.Animation == (AnimConfig{}) {
+		m.animState.Config = DefaultAnimConfig()
+	}
+
+	return m
+}
+
+func NewPreviewModel(cfg PreviewConfig) Model {
+	m := NewModel(ModelConfig{
+		TermCaps:     cfg.TermCaps,
+		EndBehavior:  EndHold,
+		Animation:    cfg.Animation,
+		ContextLines: -1,
+	})
+
+	text := cfg.Text
+	if text == "" {
+		text = "every word you sing lights up the room"
+	}
+
+	texts := []string{
+		text,
+		"the quick brown fox",
+		"jumps over the lazy dog",
+		text,
+		"(oh, oh, oh)",
+	}
+
+	lines := make([]lyrics.TimedLine, len(texts))
+	for i, t := range texts {
+		lines[i] = lyrics.TimedLine{TimeSeconds: float64(i * previewLineSeconds), Text: t}
+	}
+
+	if cfg.Palette != nil {
+		m.display.Palette = m.forBackground(cfg.Palette)
+	}
+	m.display.Track = &track.Info{
+		Title:        "preview",
+		Artist:       cfg.Label,
+		DurationSecs: int64(len(lines) * previewLineSeconds),
+	}
+	m.display.Lines = lines
+	m.display.lineTracker = lyrics.NewLineTracker(lines)
+	m.display.sections = lyrics.DetectSections(lines)
+	m.display.CurrentIndex = 0
+	m.display.placeholder = placeholderArtwork(m.display.Palette, cfg.Label)
+	m.preview = true
+	m.previewStart = time.Now()
 
 	return m
 }
 
 func (m Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{
-		tickCmd(),
+		tickCmd(config.PollInterval, m.tickGen),
 		m.listenForPlayerEvents(),
 	}
 
 	return tea.Batch(cmds...)
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(config.PollInterval, func(t time.Time) tea.Msg {
-		return TickMsg(t)
+func tickCmd(interval time.Duration, gen int) tea.Cmd {
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
+		return TickMsg{Time: t, gen: gen}
 	})
 }
 
+// toastDuration is how long a transient status message stays on screen
+const toastDuration = 1500 * time.Millisecond
+
+// showToast puts a short message in the status line
+func (m *Model) showToast(text string) {
+	m.toast = text
+	m.toastUntil = time.Now().Add(toastDuration)
+}
+
+// activeToast returns the status message while it hasn't expired
+func (m Model) activeToast() string {
+	if m.toast == "" || time.Now().After(m.toastUntil) {
+		return ""
+	}
+	return m.toast
+}
+
+// below these sizes the viewer drops the header and the pixel font instead
+// of clipping rows
+const (
+	miniModeHeight = 12
+	miniModeWidth  = 40
+)
+
+// miniMode reports whether the terminal is too small for the full layout
+func (m Model) miniMode() bool {
+	if m.width == 0 || m.height == 0 {
+		return false
+	}
+	return m.height < miniModeHeight || m.width < miniModeWidth
+}
+
+// paused reports whether a player is known to be paused, which is when
+// ticking slows down and the position isn't read
+func (m Model) paused() bool {
+	return m.player != nil && !m.preview && !m.clock.at.IsZero() && !m.clock.playing
+}
+
+// tapTimeout is how long after the last tap a new tap starts over
+const tapTimeout = 2 * time.Second
+
+// maxTaps is how many taps the tempo is averaged over
+const maxTaps = 8
+
+// tapTempo takes the tempo from the taps so far, the latest tap is a beat
+func (m *Model) tapTempo() {
+	now := time.Now()
+	if len(m.taps) > 0 && now.Sub(m.taps[len(m.taps)-1]) > tapTimeout {
+		m.taps = nil
+	}
+	m.taps = append(m.taps, now)
+	if len(m.taps) > maxTaps {
+		m.taps = m.taps[len(m.taps)-maxTaps:]
+	}
+
+	if len(m.taps) < 2 {
+		m.showToast("tap the beat")
+		return
+	}
+
+	interval := m.taps[len(m.taps)-1].Sub(m.taps[0]) / time.Duration(len(m.taps)-1)
+	rate := 1.0
+	if m.clock.rate > 0 {
+		rate = m.clock.rate
+	}
+	// taps come in real time, beats are counted in track time
+	m.bpm = 60 / interval.Seconds() * rate
+	m.beatAnchor = m.position()
+	m.showToast(fmt.Sprintf("%.0f bpm", m.bpm))
+}
+
+// beats counts the beats since the anchor while playing at a known tempo
+func (m Model) beats() (float64, bool) {
+	if m.bpm <= 0 || m.paused() || m.display.Track == nil {
+		return 0, false
+	}
+	return (m.position() - m.beatAnchor) * m.bpm / 60, true
+}
+
+// idleFade is how long the idle clock takes to fade in
+const idleFade = 2 * time.Second
+
+// idleClockLevel reports whether the idle clock shows and how far it has
+// faded in, from 0 to 1
+func (m Model) idleClockLevel() (float64, bool) {
+	if m.idleAfter <= 0 || !m.paused() || m.pausedSince.IsZero() {
+		return 0, false
+	}
+
+	shown := time.Since(m.pausedSince) - m.idleAfter
+	if shown < 0 {
+		return 0, false
+	}
+	return min(float64(shown)/float64(idleFade), 1), true
+}
+
+// nextTick schedules the next tick of the current chain, slowing down once
+// the player is paused and running animations have finished
+func (m Model) nextTick() tea.Cmd {
+	interval := config.PollInterval
+	level, idle := m.idleClockLevel()
+	fading := idle && level < 1
+	if m.paused() && m.animState.Settled() && !fading && !m.fading() {
+		interval = config.PausedPollInterval
+	}
+	return tickCmd(interval, m.tickGen)
+}
+
+// wake replaces a slow paused tick chain, so a resume or a line change from
+// a key press shows up right away instead of after the paused interval
+func (m *Model) wake() tea.Cmd {
+	if !m.paused() {
+		return nil
+	}
+	m.tickGen++
+	return tickCmd(config.PollInterval, m.tickGen)
+}
+
 func (m Model) listenForPlayerEvents() tea.Cmd {
 	if m.player == nil {
 		return nil
@@ -174,24 +599,119 @@ func (m *Model) setLoadingArtwork(loading bool) {
 	}
 }
 
+// setPalette shows a new palette with the user's color override applied
+func (m *Model) setPalette(palette *artwork.Palette) {
+	m.display.Palette = m.forBackground(m.override.Apply(palette))
+}
+
+// forBackground darkens the palette when the terminal has a light background
+func (m *Model) forBackground(palette *artwork.Palette) *artwork.Palette {
+	if m.termCaps != nil && m.termCaps.LightBackground {
+		return palette.ForLightBackground()
+	}
+	return palette
+}
+
 func (m *Model) resetForNewTrack() {
 	m.display.Lines = nil
+	m.display.lineTracker = lyrics.LineTracker{}
+	m.display.sections = nil
 	m.display.CurrentIndex = -1
 	m.display.PrevIndex = -1
 	m.display.Image = nil
-	m.display.Palette = artwork.DefaultPalette()
+	m.display.placeholder = nil
+	m.lyricsSource = ""
+	m.syncedLines = nil
+	m.plainLyrics = ""
+	m.usingPlain = false
+	m.comparing = false
+	m.translation = lyrics.LayerBoth
+	m.searchTrack = nil
+	m.refetching = false
+	m.editingSearch = false
+	m.setPalette(artwork.DefaultPalette())
 	m.lastLineChange = time.Now()
 	m.err = nil
+	m.browsing = false
+	m.sheetLine = 0
+	m.sheetFollow = true
 	m.animState.Reset()
 }
 
-func (m *Model) updateLyricIndex(positionSecs int64) bool {
+// positionResyncInterval is how often the player is asked for its position,
+// in between the clock extrapolates
+const positionResyncInterval = time.Second
+
+// playbackClock extrapolates the playback position from the last read using
+// wall-clock time scaled by the playback rate while the player is playing
+type playbackClock struct {
+	baseMicros int64
+	at         time.Time
+	playing    bool
+	// rate is the playback speed, 0 counts as normal speed
+	rate float64
+}
+
+func (c *playbackClock) set(micros int64, playing bool) {
+	c.baseMicros = micros
+	c.at = time.Now()
+	c.playing = playing
+}
+
+// setPlaying freezes or resumes the clock at the current position
+func (c *playbackClock) setPlaying(playing bool) {
+	c.set(c.micros(), playing)
+}
+
+// setRate changes the speed from the current position on
+func (c *playbackClock) setRate(rate float64) {
+	c.set(c.micros(), c.playing)
+	c.rate = rate
+}
+
+func (c playbackClock) micros() int64 {
+	if !c.playing || c.at.IsZero() {
+		return c.baseMicros
+	}
+
+	elapsed := time.Since(c.at).Microseconds()
+	if c.rate > 0 {
+		elapsed = int64(float64(elapsed) * c.rate)
+	}
+	return c.baseMicros + elapsed
+}
+
+// stale reports whether the position should be read from the player again
+func (c playbackClock) stale() bool {
+	return c.at.IsZero() || time.Since(c.at) >= positionResyncInterval
+}
+
+// position returns the interpolated playback position in seconds, kept
+// within the track length
+func (m Model) position() float64 {
+	pos := float64(m.clock.micros()) / 1_000_000
+	if m.display.Track != nil && m.display.Track.DurationSecs > 0 {
+		pos = min(pos, float64(m.display.Track.DurationSecs))
+	}
+	return max(pos, 0)
+}
+
+// lyricOffset is the sync offset in track time. the offset makes up for a
+// delay in real time, which covers more of the track at a faster rate.
+func (m Model) lyricOffset() float64 {
+	if m.clock.rate > 0 {
+		return m.syncOffset * m.clock.rate
+	}
+	return m.syncOffset
+}
+
+func (m *Model) updateLyricIndex(position float64) bool {
 	if len(m.display.Lines) == 0 {
 		return false
 	}
 
-	adjustedPos := float64(positionSecs) + m.syncOffset
-	idx := lyrics.FindCurrentLineIndex(m.display.Lines, adjustedPos)
+	adjustedPos := position + m.lyricOffset()
+	idx := m.display.lineTracker.Find(adjustedPos)
 	if idx < 0 && len(m.display.Lines) > 0 {
 		idx = 0
 	}
@@ -199,6 +719,9 @@ func (m *Model) updateLyricIndex(positionSecs int64) bool {
 	if idx != m.display.CurrentIndex {
 		m.display.PrevIndex = m.display.CurrentIndex
 		m.display.CurrentIndex = idx
+		if m.sheetFollow {
+			m.sheetLine = idx
+		}
 		m.lastLineChange = time.Now()
 		m.animState.TargetScrollY = float64(idx)
 		return true
@@ -207,11 +730,22 @@ func (m *Model) updateLyricIndex(positionSecs int64) bool {
 	return false
 }
 
+// lyricsEnded reports whether playback is past the last line plus a short hold
+func (m Model) lyricsEnded() bool {
+	lines := m.display.Lines
+	if len(lines) == 0 || m.display.CurrentIndex != len(lines)-1 {
+		return false
+	}
+
+	adjustedPos := m.position() + m.lyricOffset()
+	return adjustedPos >= lines[len(lines)-1].TimeSeconds+lastLineHoldSeconds
+}
+
 func (m Model) Width() int  { return m.width }
 func (m Model) Height() int { return m.height }
 
 func (m Model) Track() *track.Info        { return m.display.Track }
-func (m Model) Position() int64           { return m.positionSecs }
+func (m Model) Position() float64         { return m.position() }
 func (m Model) Palette() *artwork.Palette { return m.display.Palette }
 func (m Model) Image() image.Image        { return m.display.Image }
 func (m Model) Lines() []lyrics.TimedLine { return m.display.Lines }
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+
+	"karolbroda.com/lyrecho/internal/artwork"
+	"karolbroda.com/lyrecho/internal/colors"
+)
+
+// placeholderSize is the side of the generated artwork in pixels, small so
+// the pixel font stays crisp when drawn as half blocks
+const placeholderSize = 24
+
+// artistInitials picks the first letter of the first two words
+func artistInitials(artist string) []rune {
+	var initials []rune
+	for _, word := range strings.Fields(artist) {
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				initials = append(initials, unicode.ToUpper(r))
+				break
+			}
+		}
+		if len(initials) == 2 {
+			break
+		}
+	}
+	return initials
+}
+
+// placeholderArtwork stands in for missing artwork: the artist's initials
+// in the pixel font on a diagonal gradient of the palette
+func placeholderArtwork(palette *artwork.Palette, artist string) image.Image {
+	if palette == nil {
+		palette = artwork.DefaultPalette()
+	}
+	img := image.NewRGBA(image.Rect(0, 0, placeholderSize, placeholderSize))
+
+	// one color per diagonal, blending every pixel would be slow
+	start := colors.AdjustBrightness(palette.Primary, 0.45)
+	end := colors.AdjustBrightness(palette.Accent, 0.45)
+	diagonals := make([]color.RGBA, placeholderSize*2-1)
+	for i := range diagonals {
+		diagonals[i] = hexToRGBA(colors.BlendColors(start, end, float64(i)/float64(len(diagonals)-1)))
+	}
+	for y := 0; y < placeholderSize; y++ {
+		for x := 0; x < placeholderSize; x++ {
+			img.SetRGBA(x, y, diagonals[x+y])
+		}
+	}
+
+	initials := artistInitials(artist)
+	if len(initials) == 0 {
+		return img
+	}
+
+	grid := pixelGrid(initials, len(initials), 0)
+	gridHeight := len(grid)
+	gridWidth := len(grid[0])
+	scale := max(min((placeholderSize-2)/gridWidth, placeholderSize/2/gridHeight), 1)
+
+	// even offsets keep the glyph pixels on whole half block cells
+	left := (placeholderSize - gridWidth*scale) / 2 &^ 1
+	top := (placeholderSize - gridHeight*scale) / 2 &^ 1
+	ink := hexToRGBA(colors.BlendColors(palette.Primary, "#FFFFFF", 0.6))
+
+	for row := range grid {
+		for col, pixel := range grid[row] {
+			if !pixel.filled {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetRGBA(left+col*scale+dx, top+row*scale+dy, ink)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+func hexToRGBA(hex string) color.RGBA {
+	r, g, b := colors.HexToRGB(hex)
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
+}
@@ -3,9 +3,13 @@ package ui
 import (
 	"fmt"
 	"math"
+	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
 
 	"karolbroda.com/lyrecho/internal/artwork"
 	"karolbroda.com/lyrecho/internal/colors"
@@ -149,19 +153,307 @@ var pixelFont = map[rune][5]uint8{
 	'û': {0b00100, 0b01010, 0b10001, 0b10001, 0b01110},
 	'ÿ': {0b01010, 0b10001, 0b01111, 0b00001, 0b01110},
 	'œ': {0b00000, 0b01111, 0b10101, 0b10100, 0b01111},
+
+	// spanish, portuguese and italian letters
+	'Á': {0b00010, 0b01110, 0b10001, 0b11111, 0b10001},
+	'Ã': {0b01101, 0b01110, 0b10001, 0b11111, 0b10001},
+	'Í': {0b00010, 0b11111, 0b00100, 0b00100, 0b11111},
+	'Ì': {0b01000, 0b11111, 0b00100, 0b00100, 0b11111},
+	'Ñ': {0b01101, 0b10001, 0b11001, 0b10101, 0b10011},
+	'Ò': {0b01000, 0b01110, 0b10001, 0b10001, 0b01110},
+	'Õ': {0b01101, 0b01110, 0b10001, 0b10001, 0b01110},
+	'Ú': {0b00010, 0b10001, 0b10001, 0b10001, 0b01110},
+
+	'á': {0b00010, 0b01110, 0b00001, 0b01111, 0b01111},
+	'ã': {0b01101, 0b01110, 0b00001, 0b01111, 0b01111},
+	'í': {0b00010, 0b00000, 0b00100, 0b00100, 0b00100},
+	'ì': {0b01000, 0b00000, 0b00100, 0b00100, 0b00100},
+	'ñ': {0b01101, 0b00000, 0b11110, 0b10001, 0b10001},
+	'ò': {0b01000, 0b00000, 0b01110, 0b10001, 0b01110},
+	'õ': {0b01101, 0b00000, 0b01110, 0b10001, 0b01110},
+	'ú': {0b00010, 0b00000, 0b10001, 0b10001, 0b01110},
+
+	'¿': {0b00100, 0b00000, 0b01100, 0b10001, 0b01110},
+	'¡': {0b00100, 0b00000, 0b00100, 0b00100, 0b00100},
+
+	// cyrillic letters (russian, ukrainian)
+	'А': {0b01110, 0b10001, 0b11111, 0b10001, 0b10001},
+	'Б': {0b11111, 0b10000, 0b11110, 0b10001, 0b11110},
+	'В': {0b11110, 0b10001, 0b11110, 0b10001, 0b11110},
+	'Г': {0b11111, 0b10000, 0b10000, 0b10000, 0b10000},
+	'Д': {0b01110, 0b01010, 0b01010, 0b11111, 0b10001},
+	'Е': {0b11111, 0b10000, 0b11110, 0b10000, 0b11111},
+	'Ё': {0b01010, 0b11111, 0b11110, 0b10000, 0b11111},
+	'Ж': {0b10101, 0b10101, 0b01110, 0b10101, 0b10101},
+	'З': {0b11110, 0b00001, 0b01110, 0b00001, 0b11110},
+	'И': {0b10001, 0b10011, 0b10101, 0b11001, 0b10001},
+	'Й': {0b01010, 0b10011, 0b10101, 0b11001, 0b10001},
+	'К': {0b10001, 0b10010, 0b11100, 0b10010, 0b10001},
+	'Л': {0b00111, 0b01001, 0b01001, 0b01001, 0b10001},
+	'М': {0b10001, 0b11011, 0b10101, 0b10001, 0b10001},
+	'Н': {0b10001, 0b10001, 0b11111, 0b10001, 0b10001},
+	'О': {0b01110, 0b10001, 0b10001, 0b10001, 0b01110},
+	'П': {0b11111, 0b10001, 0b10001, 0b10001, 0b10001},
+	'Р': {0b11110, 0b10001, 0b11110, 0b10000, 0b10000},
+	'С': {0b01111, 0b10000, 0b10000, 0b10000, 0b01111},
+	'Т': {0b11111, 0b00100, 0b00100, 0b00100, 0b00100},
+	'У': {0b10001, 0b10001, 0b01111, 0b00001, 0b11110},
+	'Ф': {0b01110, 0b10101, 0b10101, 0b01110, 0b00100},
+	'Х': {0b10001, 0b01010, 0b00100, 0b01010, 0b10001},
+	'Ц': {0b10010, 0b10010, 0b10010, 0b11111, 0b00001},
+	'Ч': {0b10001, 0b10001, 0b01111, 0b00001, 0b00001},
+	'Ш': {0b10101, 0b10101, 0b10101, 0b10101, 0b11111},
+	'Щ': {0b10101, 0b10101, 0b10101, 0b11111, 0b00001},
+	'Ъ': {0b11000, 0b01000, 0b01110, 0b01001, 0b01110},
+	'Ы': {0b10001, 0b10001, 0b11101, 0b10101, 0b11101},
+	'Ь': {0b10000, 0b10000, 0b11110, 0b10001, 0b11110},
+	'Э': {0b11110, 0b00001, 0b01111, 0b00001, 0b11110},
+	'Ю': {0b10010, 0b10101, 0b11101, 0b10101, 0b10010},
+	'Я': {0b01111, 0b10001, 0b01111, 0b01001, 0b10001},
+	'Є': {0b01111, 0b10000, 0b11110, 0b10000, 0b01111},
+	'І': {0b11111, 0b00100, 0b00100, 0b00100, 0b11111},
+	'Ї': {0b10101, 0b01110, 0b00100, 0b00100, 0b01110},
+	'Ґ': {0b00001, 0b11111, 0b10000, 0b10000, 0b10000},
+
+	'а': {0b00000, 0b01110, 0b00001, 0b01111, 0b01111},
+	'б': {0b00111, 0b01000, 0b11110, 0b10001, 0b01110},
+	'в': {0b00000, 0b11110, 0b11110, 0b10001, 0b11110},
+	'г': {0b00000, 0b11111, 0b10000, 0b10000, 0b10000},
+	'д': {0b00000, 0b01110, 0b01010, 0b11111, 0b10001},
+	'е': {0b01110, 0b10001, 0b11111, 0b10000, 0b01110},
+	'ё': {0b01010, 0b01110, 0b11111, 0b10000, 0b01110},
+	'ж': {0b00000, 0b10101, 0b01110, 0b01110, 0b10101},
+	'з': {0b00000, 0b11110, 0b00110, 0b00001, 0b11110},
+	'и': {0b00000, 0b10001, 0b10011, 0b10101, 0b11001},
+	'й': {0b01010, 0b00100, 0b10011, 0b10101, 0b11001},
+	'к': {0b00000, 0b10010, 0b11100, 0b10010, 0b10001},
+	'л': {0b00000, 0b00111, 0b01001, 0b01001, 0b10001},
+	'м': {0b00000, 0b10001, 0b11011, 0b10101, 0b10001},
+	'н': {0b00000, 0b10001, 0b11111, 0b10001, 0b10001},
+	'о': {0b00000, 0b01110, 0b10001, 0b10001, 0b01110},
+	'п': {0b00000, 0b11111, 0b10001, 0b10001, 0b10001},
+	'р': {0b00000, 0b11110, 0b10001, 0b11110, 0b10000},
+	'с': {0b00000, 0b01110, 0b10000, 0b10000, 0b01110},
+	'т': {0b00000, 0b11111, 0b00100, 0b00100, 0b00100},
+	'у': {0b00000, 0b10001, 0b01111, 0b00001, 0b01110},
+	'ф': {0b00100, 0b01110, 0b10101, 0b01110, 0b00100},
+	'х': {0b00000, 0b10001, 0b01010, 0b01010, 0b10001},
+	'ц': {0b00000, 0b10010, 0b10010, 0b11111, 0b00001},
+	'ч': {0b00000, 0b10001, 0b10001, 0b01111, 0b00001},
+	'ш': {0b00000, 0b10101, 0b10101, 0b10101, 0b11111},
+	'щ': {0b00000, 0b10101, 0b10101, 0b11111, 0b00001},
+	'ъ': {0b00000, 0b11000, 0b01110, 0b01001, 0b01110},
+	'ы': {0b00000, 0b10001, 0b11101, 0b10101, 0b11101},
+	'ь': {0b00000, 0b10000, 0b11110, 0b10001, 0b11110},
+	'э': {0b00000, 0b11110, 0b00111, 0b00001, 0b11110},
+	'ю': {0b00000, 0b10010, 0b10101, 0b11101, 0b10010},
+	'я': {0b00000, 0b01111, 0b10001, 0b01111, 0b10001},
+	'є': {0b00000, 0b01111, 0b11100, 0b10000, 0b01111},
+	'і': {0b00100, 0b00000, 0b00100, 0b00100, 0b00100},
+	'ї': {0b01010, 0b00000, 0b00100, 0b00100, 0b00100},
+	'ґ': {0b00001, 0b11111, 0b10000, 0b10000, 0b10000},
+
+	// greek letters
+	'Α': {0b01110, 0b10001, 0b11111, 0b10001, 0b10001},
+	'Β': {0b11110, 0b10001, 0b11110, 0b10001, 0b11110},
+	'Γ': {0b11111, 0b10000, 0b10000, 0b10000, 0b10000},
+	'Δ': {0b00100, 0b01010, 0b01010, 0b10001, 0b11111},
+	'Ε': {0b11111, 0b10000, 0b11110, 0b10000, 0b11111},
+	'Ζ': {0b11111, 0b00010, 0b00100, 0b01000, 0b11111},
+	'Η': {0b10001, 0b10001, 0b11111, 0b10001, 0b10001},
+	'Θ': {0b01110, 0b10001, 0b11111, 0b10001, 0b01110},
+	'Ι': {0b11111, 0b00100, 0b00100, 0b00100, 0b11111},
+	'Κ': {0b10001, 0b10010, 0b11100, 0b10010, 0b10001},
+	'Λ': {0b00100, 0b01010, 0b01010, 0b10001, 0b10001},
+	'Μ': {0b10001, 0b11011, 0b10101, 0b10001, 0b10001},
+	'Ν': {0b10001, 0b11001, 0b10101, 0b10011, 0b10001},
+	'Ξ': {0b11111, 0b00000, 0b01110, 0b00000, 0b11111},
+	'Ο': {0b01110, 0b10001, 0b10001, 0b10001, 0b01110},
+	'Π': {0b11111, 0b10001, 0b10001, 0b10001, 0b10001},
+	'Ρ': {0b11110, 0b10001, 0b11110, 0b10000, 0b10000},
+	'Σ': {0b11111, 0b01000, 0b00100, 0b01000, 0b11111},
+	'Τ': {0b11111, 0b00100, 0b00100, 0b00100, 0b00100},
+	'Υ': {0b10001, 0b01010, 0b00100, 0b00100, 0b00100},
+	'Φ': {0b01110, 0b10101, 0b10101, 0b01110, 0b00100},
+	'Χ': {0b10001, 0b01010, 0b00100, 0b01010, 0b10001},
+	'Ψ': {0b10101, 0b10101, 0b01110, 0b00100, 0b00100},
+	'Ω': {0b01110, 0b10001, 0b10001, 0b01010, 0b11011},
+	'Ά': {0b10110, 0b01001, 0b01111, 0b01001, 0b01001},
+	'Έ': {0b10111, 0b01000, 0b01110, 0b01000, 0b01111},
+	'Ή': {0b10101, 0b00101, 0b00111, 0b00101, 0b00101},
+	'Ί': {0b10111, 0b00010, 0b00010, 0b00010, 0b00111},
+	'Ό': {0b10110, 0b01001, 0b01001, 0b01001, 0b00110},
+	'Ύ': {0b10101, 0b00101, 0b00010, 0b00010, 0b00010},
+	'Ώ': {0b10110, 0b01001, 0b01001, 0b00110, 0b01101},
+	'Ϊ': {0b10101, 0b01110, 0b00100, 0b00100, 0b01110},
+	'Ϋ': {0b01010, 0b10001, 0b01010, 0b00100, 0b00100},
+
+	'α': {0b00000, 0b01101, 0b10010, 0b10010, 0b01101},
+	'β': {0b01100, 0b10010, 0b11110, 0b10001, 0b11110},
+	'γ': {0b00000, 0b10001, 0b01010, 0b00100, 0b00100},
+	'δ': {0b01110, 0b01000, 0b01100, 0b10010, 0b01100},
+	'ε': {0b00000, 0b01111, 0b11100, 0b10000, 0b01111},
+	'ζ': {0b11111, 0b00100, 0b01000, 0b10000, 0b01110},
+	'η': {0b00000, 0b11110, 0b10001, 0b10001, 0b00001},
+	'θ': {0b01100, 0b10010, 0b11110, 0b10010, 0b01100},
+	'ι': {0b00000, 0b00100, 0b00100, 0b00100, 0b00010},
+	'κ': {0b00000, 0b10010, 0b11100, 0b10010, 0b10001},
+	'λ': {0b10000, 0b01000, 0b00100, 0b01010, 0b10001},
+	'μ': {0b00000, 0b10010, 0b10010, 0b11101, 0b10000},
+	'ν': {0b00000, 0b10001, 0b10001, 0b01010, 0b00100},
+	'ξ': {0b11110, 0b01000, 0b00110, 0b01000, 0b00111},
+	'ο': {0b00000, 0b01110, 0b10001, 0b10001, 0b01110},
+	'π': {0b00000, 0b11111, 0b01010, 0b01010, 0b01010},
+	'ρ': {0b00000, 0b01110, 0b10001, 0b11110, 0b10000},
+	'σ': {0b00000, 0b01111, 0b10010, 0b10010, 0b01100},
+	'ς': {0b00000, 0b01110, 0b10000, 0b01100, 0b00011},
+	'τ': {0b00000, 0b11111, 0b00100, 0b00100, 0b00010},
+	'υ': {0b00000, 0b10001, 0b10001, 0b10001, 0b01110},
+	'φ': {0b00100, 0b01110, 0b10101, 0b01110, 0b00100},
+	'χ': {0b00000, 0b10001, 0b01010, 0b01010, 0b10001},
+	'ψ': {0b00000, 0b10101, 0b10101, 0b01110, 0b00100},
+	'ω': {0b00000, 0b01010, 0b10001, 0b10101, 0b01010},
+	'ά': {0b00010, 0b01101, 0b10010, 0b10010, 0b01101},
+	'έ': {0b00010, 0b01111, 0b11100, 0b10000, 0b01111},
+	'ή': {0b00100, 0b11110, 0b10001, 0b10001, 0b00001},
+	'ί': {0b00010, 0b00100, 0b00100, 0b00100, 0b00010},
+	'ό': {0b00010, 0b01110, 0b10001, 0b10001, 0b01110},
+	'ύ': {0b00010, 0b10001, 0b10001, 0b10001, 0b01110},
+	'ώ': {0b00010, 0b01010, 0b10001, 0b10101, 0b01010},
+	'ϊ': {0b01010, 0b00000, 0b00100, 0b00100, 0b00010},
+	'ϋ': {0b01010, 0b00000, 0b10001, 0b10001, 0b01110},
+	'ΐ': {0b10101, 0b00000, 0b00100, 0b00100, 0b00010},
+	'ΰ': {0b10101, 0b00000, 0b10001, 0b10001, 0b01110},
 }
 
 const (
 	charWidth  = 5
 	charHeight = 5
 	charGap    = 1
+
+	// wideCharSize is the cell of glyphs that need more room than 5x5, like
+	// kana
+	wideCharSize = 7
 )
 
+// glyphFallbackRatio is the share of characters without a glyph above which
+// a lyric is drawn as plain text instead of the pixel font
+const glyphFallbackRatio = 0.2
+
+// glyph is one pixel font character, each row holds width bits with the
+// leftmost pixel in the highest bit
+type glyph struct {
+	rows  []uint8
+	width int
+}
+
+func (g glyph) filled(row int, col int) bool {
+	return (g.rows[row]>>(g.width-1-col))&1 == 1
+}
+
+// pixelGlyph returns the glyph for a character, falling back to the glyph of
+// its letter without accents. characters without either show as blanks.
+func pixelGlyph(char rune) (glyph, bool) {
+	if rows, ok := pixelFont[char]; ok {
+		return glyph{rows: rows[:], width: charWidth}, true
+	}
+	if rows, ok := wideFont[char]; ok {
+		return glyph{rows: rows[:], width: wideCharSize}, true
+	}
+
+	decomposed := []rune(norm.NFD.String(string(char)))
+	if decomposed[0] != char {
+		if base, ok := pixelGlyph(decomposed[0]); ok {
+			return withKanaMarks(base, decomposed[1:]), true
+		}
+	}
+
+	blank := pixelFont[' ']
+	return glyph{rows: blank[:], width: charWidth}, false
+}
+
+// withKanaMarks draws dakuten and handakuten into the top right corner of a
+// kana glyph, so voiced kana don't each need their own glyph
+func withKanaMarks(g glyph, marks []rune) glyph {
+	if g.width != wideCharSize {
+		return g
+	}
+
+	rows := slices.Clone(g.rows)
+	for _, mark := range marks {
+		switch mark {
+		case '\u3099':
+			rows[0] = rows[0]&^0b111 | 0b101
+			rows[1] = rows[1]&^0b111 | 0b101
+		case '\u309A':
+			rows[0] = rows[0]&^0b111 | 0b010
+			rows[1] = rows[1]&^0b111 | 0b101
+			rows[2] = rows[2]&^0b111 | 0b010
+		}
+	}
+
+	return glyph{rows: rows, width: g.width}
+}
+
+// lineGlyphs looks up the glyphs of a line. short glyphs are padded at the
+// top so all share the height of the tallest and sit on one baseline.
+func lineGlyphs(runes []rune) []glyph {
+	glyphs := make([]glyph, len(runes))
+	height := charHeight
+	for i, char := range runes {
+		glyphs[i], _ = pixelGlyph(char)
+		height = max(height, len(glyphs[i].rows))
+	}
+
+	for i, g := range glyphs {
+		if pad := height - len(g.rows); pad > 0 {
+			glyphs[i].rows = append(make([]uint8, pad), g.rows...)
+		}
+	}
+
+	return glyphs
+}
+
+// pixelTextWidth is how many columns text takes in the pixel font
+func pixelTextWidth(text string) int {
+	width := 0
+	for i, char := range []rune(strings.ToUpper(text)) {
+		if i > 0 {
+			width += charGap
+		}
+		g, _ := pixelGlyph(char)
+		width += g.width
+	}
+	return width
+}
+
+// pixelFontCovers reports whether the pixel font can draw enough of text for
+// it to stay readable
+func pixelFontCovers(text string) bool {
+	total := 0
+	missing := 0
+	for _, char := range strings.ToUpper(text) {
+		if unicode.IsSpace(char) {
+			continue
+		}
+		total++
+		if _, ok := pixelGlyph(char); !ok {
+			missing++
+		}
+	}
+	return float64(missing) <= float64(total)*glyphFallbackRatio
+}
+
 type TextRenderer struct {
 	palette     *artwork.Palette
 	animState   *AnimState
 	tickCount   int
 	screenWidth int
+	// plain draws lyrics as styled text instead of the pixel font
+	plain bool
+	// halfRow draws pixel font context lyrics half a row lower, so a slide
+	// can move them in half rows
+	halfRow bool
 }
 
 func NewTextRenderer(palette *artwork.Palette, animState *AnimState, tickCount int, screenWidth int) *TextRenderer {
@@ -174,32 +466,67 @@ func NewTextRenderer(palette *artwork.Palette, animState *AnimState, tickCount i
 }
 
 func (r *TextRenderer) RenderFocusLyric(text string) []string {
+	return r.RenderFocusLyricTimed(text, -1)
+}
+
+// RenderFocusLyricTimed renders the focus lyric with word-level progress: the
+// first sungRunes non-space runes are drawn in full color and the rest dimmed.
+// a fractional part fills the next rune partway from the left, and a negative
+// sungRunes renders the whole line as sung.
+func (r *TextRenderer) RenderFocusLyricTimed(text string, sungRunes float64) []string {
 	if text == "" {
 		return nil
 	}
+	if r.plain || !pixelFontCovers(text) {
+		return r.renderPlainFocus(text, sungRunes)
+	}
 
-	lines := r.wrapText(text)
+	lines := wrapText(text, r.pixelLineWidth(), pixelTextWidth)
 	var result []string
+	remaining := sungRunes
 
 	for _, line := range lines {
 		runes := []rune(strings.ToUpper(line))
-		totalPixelWidth := len(runes)*charWidth + (len(runes)-1)*charGap
-		if totalPixelWidth < 0 {
-			totalPixelWidth = 0
+		totalPixelWidth := pixelTextWidth(line)
+
+		sungChars := len(runes)
+		partial := 0.0
+		if sungRunes >= 0 {
+			sungChars, partial, remaining = splitSungRunes(runes, remaining)
 		}
-		rendered := r.renderFocusText(runes, totalPixelWidth)
+
+		rendered := r.renderFocusText(runes, totalPixelWidth, sungChars, partial)
 		result = append(result, rendered...)
 	}
 
 	return result
 }
 
+// splitSungRunes returns the index of the first unsung rune in runes given the
+// number of sung non-space runes left, how much of that rune is already sung,
+// and how many are left for the next line
+func splitSungRunes(runes []rune, remaining float64) (int, float64, float64) {
+	for i, char := range runes {
+		if char == ' ' {
+			continue
+		}
+		if remaining < 1 {
+			return i, max(remaining, 0), 0
+		}
+		remaining--
+	}
+	return len(runes), 0, remaining
+}
+
 func (r *TextRenderer) RenderContextLyric(text string, brightness float64, isPast bool) []string {
 	if text == "" {
 		return nil
 	}
+	if r.plain || !pixelFontCovers(text) {
+		return r.renderPlainContext(text, brightness)
+	}
 
-	lines := r.wrapText(text)
+	lines := wrapText(text, r.pixelLineWidth(), pixelTextWidth)
 	var result []string
 
 	for _, line := range lines {
@@ -211,154 +538,195 @@ func (r *TextRenderer) RenderContextLyric(text string, brightness float64, isPas
 	return result
 }
 
-func (r *TextRenderer) wrapText(text string) []string {
-	maxPixelWidth := r.screenWidth - 8
-	maxCharsPerLine := maxPixelWidth / (charWidth + charGap)
-	if maxCharsPerLine < 5 {
-		maxCharsPerLine = 5
+// renderPlainFocus draws the focus lyric as one bold text row per wrapped
+// line, sung words in the palette colors and the rest dimmed
+func (r *TextRenderer) renderPlainFocus(text string, sungRunes float64) []string {
+	baseColor := r.palette.Primary
+	if r.animState.GlowIntensity > 0.05 {
+		baseColor = r.glowColor(baseColor, r.animState.GlowIntensity*0.5)
 	}
+	unsungColor := r.dimColor(colors.BlendColors(baseColor, r.palette.Dim, 0.6), 0.55)
 
-	words := strings.Fields(text)
-	var lines []string
-	var currentLine string
+	sungStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(baseColor))
+	unsungStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(unsungColor))
 
-	for _, word := range words {
-		testLine := currentLine
-		if testLine != "" {
-			testLine += " "
+	var result []string
+	remaining := sungRunes
+
+	for _, line := range wrapText(text, r.plainLineChars(), uniseg.StringWidth) {
+		runes := []rune(line)
+
+		sungChars := len(runes)
+		if sungRunes >= 0 {
+			var partial float64
+			sungChars, partial, remaining = splitSungRunes(runes, remaining)
+			// a cell can't be half lit, round to the nearest rune
+			if partial >= 0.5 {
+				sungChars++
+			}
 		}
-		testLine += word
 
-		if len(testLine) <= maxCharsPerLine {
-			currentLine = testLine
-		} else {
-			if currentLine != "" {
-				lines = append(lines, currentLine)
-			}
-			if len(word) > maxCharsPerLine {
-				currentLine = word[:maxCharsPerLine]
-			} else {
-				currentLine = word
-			}
+		var rendered string
+		if sungChars > 0 {
+			rendered += sungStyle.Render(string(runes[:sungChars]))
 		}
-	}
-	if currentLine != "" {
-		lines = append(lines, currentLine)
+		if sungChars < len(runes) {
+			rendered += unsungStyle.Render(string(runes[sungChars:]))
+		}
+		result = append(result, centerText(rendered, r.screenWidth))
 	}
 
-	return lines
+	return result
 }
 
-type pixelInfo struct {
-	filled    bool
-	charIndex int
-	pixelX    int
-}
+// renderPlainContext draws a surrounding lyric as grey text
+func (r *TextRenderer) renderPlainContext(text string, brightness float64) []string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(r.calculateContextColor(true, brightness)))
 
-func (r *TextRenderer) renderFocusText(runes []rune, totalPixelWidth int) []string {
-	grid := make([][]pixelInfo, charHeight)
-	for row := range grid {
-		grid[row] = make([]pixelInfo, 0, totalPixelWidth)
+	var result []string
+	for _, line := range wrapText(text, r.plainLineChars(), uniseg.StringWidth) {
+		result = append(result, centerText(style.Render(line), r.screenWidth))
 	}
+	return result
+}
 
-	charIndex := 0
-	pixelX := 0
+// pixelLineWidth is how many columns of pixel font fit on a line
+func (r *TextRenderer) pixelLineWidth() int {
+	return max(r.screenWidth-8, 5*(wideCharSize+charGap))
+}
 
-	for _, char := range runes {
-		charData, ok := pixelFont[char]
-		if !ok {
-			charData = pixelFont[' ']
-		}
+// plainLineChars is how many text cells fit on a line in plain mode
+func (r *TextRenderer) plainLineChars() int {
+	return max(r.screenWidth-8, 5)
+}
 
-		for row := 0; row < charHeight; row++ {
-			for col := 0; col < charWidth; col++ {
-				bit := (charData[row] >> (charWidth - 1 - col)) & 1
-				grid[row] = append(grid[row], pixelInfo{
-					filled:    bit == 1,
-					charIndex: charIndex,
-					pixelX:    pixelX + col,
-				})
+// wrapText breaks text into lines of at most maxWidth as measured by measure,
+// splitting words that are too long on their own (lines in scripts without
+// spaces are one long word)
+func wrapText(text string, maxWidth int, measure func(string) int) []string {
+	var lines []string
+
+	// multi-line blocks keep their own line breaks, each part is wrapped separately
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		var currentLine string
+
+		for _, word := range words {
+			testLine := currentLine
+			if testLine != "" {
+				testLine += " "
 			}
-		}
+			testLine += word
 
-		pixelX += charWidth
+			if measure(testLine) <= maxWidth {
+				currentLine = testLine
+				continue
+			}
 
-		if charIndex < len(runes)-1 {
-			for row := 0; row < charHeight; row++ {
-				for g := 0; g < charGap; g++ {
-					grid[row] = append(grid[row], pixelInfo{
-						filled:    false,
-						charIndex: charIndex,
-						pixelX:    pixelX + g,
-					})
-				}
+			if currentLine != "" {
+				lines = append(lines, currentLine)
 			}
-			pixelX += charGap
+			currentLine = word
+			if measure(word) > maxWidth {
+				pieces := breakWord(word, maxWidth, measure)
+				lines = append(lines, pieces[:len(pieces)-1]...)
+				currentLine = pieces[len(pieces)-1]
+			}
+		}
+		if currentLine != "" {
+			lines = append(lines, currentLine)
 		}
+	}
+
+	return lines
+}
 
-		charIndex++
+// breakWord cuts a word into pieces of at most maxWidth between grapheme
+// clusters, a single cluster wider than that gets a piece of its own
+func breakWord(word string, maxWidth int, measure func(string) int) []string {
+	var pieces []string
+	var current string
+	graphemes := uniseg.NewGraphemes(word)
+	for graphemes.Next() {
+		cluster := graphemes.Str()
+		if current != "" && measure(current+cluster) > maxWidth {
+			pieces = append(pieces, current)
+			current = ""
+		}
+		current += cluster
 	}
+	return append(pieces, current)
+}
+
+type pixelInfo struct {
+	filled    bool
+	unsung    bool
+	charIndex int
+	pixelX    int
+}
 
+func (r *TextRenderer) renderFocusText(runes []rune, totalPixelWidth int, sungChars int, partial float64) []string {
+	grid := pixelGrid(runes, sungChars, partial)
 	return r.renderGridFocus(grid, len(runes), totalPixelWidth)
 }
 
 func (r *TextRenderer) renderContextText(runes []rune, brightness float64, isPast bool) []string {
-	gridWidth := 0
-	for i := range runes {
-		gridWidth += charWidth
-		if i < len(runes)-1 {
-			gridWidth += charGap
-		}
-	}
+	grid := pixelGrid(runes, len(runes), 0)
+	return r.renderGridContext(grid, len(grid[0]), brightness, isPast)
+}
+
+// pixelGrid lays out the glyphs of a line, characters from sungChars on are
+// marked unsung except the left partial share of the one at sungChars
+func pixelGrid(runes []rune, sungChars int, partial float64) [][]pixelInfo {
+	glyphs := lineGlyphs(runes)
 
-	grid := make([][]pixelInfo, charHeight)
-	for row := range grid {
-		grid[row] = make([]pixelInfo, 0, gridWidth)
+	height := charHeight
+	if len(glyphs) > 0 {
+		height = len(glyphs[0].rows)
 	}
 
-	charIndex := 0
+	grid := make([][]pixelInfo, height)
 	pixelX := 0
 
-	for _, char := range runes {
-		charData, ok := pixelFont[char]
-		if !ok {
-			charData = pixelFont[' ']
+	for charIndex, g := range glyphs {
+		// columns of the partly sung character left of this are lit
+		sungCols := 0
+		if charIndex == sungChars {
+			sungCols = int(partial * float64(g.width))
 		}
 
-		for row := 0; row < charHeight; row++ {
-			for col := 0; col < charWidth; col++ {
-				bit := (charData[row] >> (charWidth - 1 - col)) & 1
+		for row := range grid {
+			for col := 0; col < g.width; col++ {
 				grid[row] = append(grid[row], pixelInfo{
-					filled:    bit == 1,
+					filled:    g.filled(row, col),
+					unsung:    charIndex > sungChars || (charIndex == sungChars && col >= sungCols),
 					charIndex: charIndex,
 					pixelX:    pixelX + col,
 				})
 			}
 		}
 
-		pixelX += charWidth
+		pixelX += g.width
 
-		if charIndex < len(runes)-1 {
-			for row := 0; row < charHeight; row++ {
-				for g := 0; g < charGap; g++ {
+		if charIndex < len(glyphs)-1 {
+			for row := range grid {
+				for gap := 0; gap < charGap; gap++ {
 					grid[row] = append(grid[row], pixelInfo{
 						filled:    false,
 						charIndex: charIndex,
-						pixelX:    pixelX + g,
+						pixelX:    pixelX + gap,
 					})
 				}
 			}
 			pixelX += charGap
 		}
-
-		charIndex++
 	}
 
-	return r.renderGridContext(grid, gridWidth, brightness, isPast)
+	return grid
 }
 
 func (r *TextRenderer) renderGridFocus(grid [][]pixelInfo, totalChars int, totalPixelWidth int) []string {
-	numTermRows := (charHeight + 1) / 2
+	numTermRows := (len(grid) + 1) / 2
 	result := make([]string, numTermRows)
 
 	centerPad := (r.screenWidth - totalPixelWidth) / 2
@@ -377,12 +745,12 @@ func (r *TextRenderer) renderGridFocus(grid [][]pixelInfo, totalChars int, total
 		for col := 0; col < totalPixelWidth && col < len(grid[0]); col++ {
 			topPixel := grid[topRowIdx][col]
 			var bottomPixel pixelInfo
-			if bottomRowIdx < charHeight {
+			if bottomRowIdx < len(grid) {
 				bottomPixel = grid[bottomRowIdx][col]
 			}
 
 			topFilled := topPixel.filled
-			bottomFilled := bottomRowIdx < charHeight && bottomPixel.filled
+			bottomFilled := bottomRowIdx < len(grid) && bottomPixel.filled
 
 			color := r.calculateFocusColor(topPixel, topFilled || bottomFilled, totalChars, totalPixelWidth)
 			style := lipgloss.NewStyle().Foreground(lipgloss.Color(color))
@@ -405,7 +773,13 @@ func (r *TextRenderer) renderGridFocus(grid [][]pixelInfo, totalChars int, total
 }
 
 func (r *TextRenderer) renderGridContext(grid [][]pixelInfo, totalPixelWidth int, brightness float64, isPast bool) []string {
-	numTermRows := (charHeight + 1) / 2
+	if r.halfRow && len(grid) > 0 {
+		// an empty pixel row on top turns each cell's halves into ▀ and ▄
+		// of the row below
+		grid = append([][]pixelInfo{make([]pixelInfo, len(grid[0]))}, grid...)
+	}
+
+	numTermRows := (len(grid) + 1) / 2
 	result := make([]string, numTermRows)
 
 	centerPad := (r.screenWidth - totalPixelWidth) / 2
@@ -424,12 +798,12 @@ func (r *TextRenderer) renderGridContext(grid [][]pixelInfo, totalPixelWidth int
 		for col := 0; col < totalPixelWidth && col < len(grid[0]); col++ {
 			topPixel := grid[topRowIdx][col]
 			var bottomPixel pixelInfo
-			if bottomRowIdx < charHeight {
+			if bottomRowIdx < len(grid) {
 				bottomPixel = grid[bottomRowIdx][col]
 			}
 
 			topFilled := topPixel.filled
-			bottomFilled := bottomRowIdx < charHeight && bottomPixel.filled
+			bottomFilled := bottomRowIdx < len(grid) && bottomPixel.filled
 
 			color := r.calculateContextColor(topFilled || bottomFilled, brightness)
 			style := lipgloss.NewStyle().Foreground(lipgloss.Color(color))
@@ -481,16 +855,25 @@ func (r *TextRenderer) calculateFocusColor(pixel pixelInfo, anyFilled bool, tota
 	baseColor := colors.BlendColors(r.palette.Primary, r.palette.Accent, gradientPos)
 
 	if r.animState.GlowIntensity > 0.05 {
-		baseColor = colors.AddGlow(baseColor, r.animState.GlowIntensity*0.5)
+		baseColor = r.glowColor(baseColor, r.animState.GlowIntensity*0.5)
+	}
+
+	if pixel.unsung {
+		// words that have not been reached yet sit dimmed until their timestamp
+		baseColor = r.dimColor(colors.BlendColors(baseColor, r.palette.Dim, 0.6), 0.55)
+	} else if r.animState.Config.Shimmer {
+		shimmer := math.Sin(r.animState.ShimmerPhase+float64(pixel.pixelX)*0.05)*0.5 + 0.5
+		if shimmer > 0.5 {
+			baseColor = r.glowColor(baseColor, (shimmer-0.5)*0.25)
+		}
 	}
 
-	shimmer := math.Sin(r.animState.ShimmerPhase+float64(pixel.pixelX)*0.05)*0.5 + 0.5
-	if shimmer > 0.5 {
-		baseColor = colors.AddGlow(baseColor, (shimmer-0.5)*0.25)
+	fadeT := easeOutCubic(charRevealT)
+	if r.palette.Light {
+		return colors.BlendColors(r.palette.Base(), baseColor, fadeT)
 	}
 
 	rVal, gVal, bVal := colors.HexToRGB(baseColor)
-	fadeT := easeOutCubic(charRevealT)
 	rVal = int(float64(rVal) * fadeT)
 	gVal = int(float64(gVal) * fadeT)
 	bVal = int(float64(bVal) * fadeT)
@@ -509,6 +892,23 @@ func (r *TextRenderer) calculateFocusColor(pixel pixelInfo, anyFilled bool, tota
 	return fmt.Sprintf("#%02X%02X%02X", rVal, gVal, bVal)
 }
 
+// dimColor pushes a color towards the background, darker on dark terminals
+// and lighter on light ones
+func (r *TextRenderer) dimColor(color string, factor float64) string {
+	if r.palette.Light {
+		return colors.BlendColors(color, r.palette.Base(), 1-factor)
+	}
+	return colors.AdjustBrightness(color, factor)
+}
+
+// glowColor makes a color stand out from the background
+func (r *TextRenderer) glowColor(color string, intensity float64) string {
+	if r.palette.Light {
+		return colors.AdjustBrightness(color, 1-intensity*0.4)
+	}
+	return colors.AddGlow(color, intensity)
+}
+
 func (r *TextRenderer) calculateContextColor(anyFilled bool, brightness float64) string {
 	if !anyFilled {
 		return "#000000"
@@ -521,6 +921,10 @@ func (r *TextRenderer) calculateContextColor(anyFilled bool, brightness float64)
 	if baseGrey > 80 {
 		baseGrey = 80
 	}
+	// on a light background the grey fades towards white instead
+	if r.palette.Light {
+		baseGrey = 255 - baseGrey
+	}
 
 	return fmt.Sprintf("#%02X%02X%02X", baseGrey, baseGrey, baseGrey)
 }
@@ -0,0 +1,550 @@
+package ui
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rivo/uniseg"
+
+	"karolbroda.com/lyrecho/internal/track"
+)
+
+// a rasterized snapshot draws every terminal cell this many pixels wide and
+// high, the pixel font glyphs inside are scaled up by snapshotGlyphScale
+const (
+	snapshotCellWidth  = 12
+	snapshotCellHeight = 24
+	snapshotGlyphScale = 2
+)
+
+// captureSettle is how long Capture keeps rendering after everything loaded,
+// so the line that just came in has finished animating
+const captureSettle = time.Second
+
+// SnapshotSavedMsg reports the files the snapshot key wrote
+type SnapshotSavedMsg struct {
+	Paths []string
+	Err   error
+}
+
+// Snapshot renders the frame on screen for saving to a file. the artwork is
+// drawn with half blocks instead of terminal graphics, and toasts and
+// prompts are left out.
+func (m Model) Snapshot() string {
+	if m.termCaps != nil {
+		caps := *m.termCaps
+		caps.SupportsKittyGraphics = false
+		m.termCaps = &caps
+	}
+	m.toast = ""
+	m.prompting = false
+	m.editingSearch = false
+	return m.View()
+}
+
+// saveSnapshotCmd writes the frame as .ans and .png to the snapshot directory
+func (m Model) saveSnapshotCmd() tea.Cmd {
+	frame := m.Snapshot()
+	light := m.shownPalette().Light
+	name := SnapshotName(m.display.Track, time.Now())
+
+	return func() tea.Msg {
+		dir, err := SnapshotDir()
+		if err != nil {
+			return SnapshotSavedMsg{Err: err}
+		}
+
+		var paths []string
+		for _, ext := range []string{".ans", ".png"} {
+			path := filepath.Join(dir, name+ext)
+			err := SaveSnapshot(path, frame, light)
+			if err != nil {
+				return SnapshotSavedMsg{Paths: paths, Err: err}
+			}
+			paths = append(paths, path)
+		}
+		return SnapshotSavedMsg{Paths: paths}
+	}
+}
+
+func (m Model) handleSnapshotSaved(msg SnapshotSavedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.showToast("snapshot failed: " + msg.Err.Error())
+		return m, m.wake()
+	}
+
+	dir := filepath.Dir(msg.Paths[0])
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(dir, home) {
+		dir = "~" + strings.TrimPrefix(dir, home)
+	}
+	m.showToast("snapshot saved to " + dir)
+	return m, m.wake()
+}
+
+// SnapshotDir is where the snapshot key saves to
+func SnapshotDir() (string, error) {
+	picturesDir := os.Getenv("XDG_PICTURES_DIR")
+	if picturesDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		picturesDir = filepath.Join(homeDir, "Pictures")
+	}
+	return filepath.Join(picturesDir, "lyrecho"), nil
+}
+
+// SnapshotName names a snapshot after the track and when it was taken,
+// without an extension
+func SnapshotName(trk *track.Info, at time.Time) string {
+	parts := []string{"lyrecho"}
+	if trk != nil {
+		parts = append(parts, fileNamePart(trk.Artist), fileNamePart(trk.Title))
+	}
+	parts = append(parts, at.Format("20060102-150405"))
+
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "-")
+}
+
+// fileNamePart lowercases text and joins its words with dashes, dropping
+// everything that isn't a letter or a digit
+func fileNamePart(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// SaveSnapshot writes a frame to path, rasterized when it ends in .png and
+// as the escape sequences otherwise. light picks the colors of a light
+// terminal for cells that have none of their own.
+func SaveSnapshot(path string, frame string, light bool) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		err = png.Encode(file, Rasterize(frame, light))
+	} else {
+		err = WriteANSI(file, frame)
+	}
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// WriteANSI writes a frame as it would be printed to a terminal, ending with
+// a style reset so the shell prompt after a cat keeps its colors
+func WriteANSI(w io.Writer, frame string) error {
+	_, err := io.WriteString(w, frame+"\x1b[0m\n")
+	return err
+}
+
+// Capture runs a viewer off screen until the current track's lyrics and
+// artwork are in, or the timeout is up, and returns the frame it shows
+func Capture(cfg ModelConfig, width int, height int, timeout time.Duration) (string, error) {
+	m := NewModel(cfg)
+	m.width = width
+	m.height = height
+
+	p := tea.NewProgram(
+		captureModel{Model: m, deadline: time.Now().Add(timeout)},
+		tea.WithInput(nil),
+		tea.WithOutput(io.Discard),
+		tea.WithoutRenderer(),
+		tea.WithoutSignalHandler(),
+	)
+	final, err := p.Run()
+	if err != nil {
+		return "", err
+	}
+
+	captured := final.(captureModel)
+	if captured.display.Track == nil {
+		return "", errors.New("no track is playing")
+	}
+	return captured.frame, nil
+}
+
+type captureTickMsg struct{}
+
+// captureModel wraps the viewer and quits with its frame once it has
+// settled
+type captureModel struct {
+	Model
+	deadline   time.Time
+	readySince time.Time
+	frame      string
+}
+
+func (c captureModel) Init() tea.Cmd {
+	return tea.Batch(c.Model.Init(), captureTick())
+}
+
+func (c captureModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(captureTickMsg); !ok {
+		updated, cmd := c.Model.Update(msg)
+		c.Model = updated.(Model)
+		return c, cmd
+	}
+
+	ready := c.display.Track != nil && c.loadingState == LoadingNone
+	if !ready {
+		c.readySince = time.Time{}
+	} else if c.readySince.IsZero() {
+		c.readySince = time.Now()
+	}
+
+	if ready && time.Since(c.readySince) >= captureSettle || time.Now().After(c.deadline) {
+		c.frame = c.Snapshot()
+		return c, tea.Quit
+	}
+	return c, captureTick()
+}
+
+func captureTick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return captureTickMsg{}
+	})
+}
+
+// snapshotCell is one terminal cell of a parsed frame. wide characters take
+// a second cell with no char of its own.
+type snapshotCell struct {
+	char rune
+	fg   color.RGBA
+	bg   color.RGBA
+}
+
+// Rasterize draws a frame into an image. block characters are drawn exactly,
+// so half-block artwork and the pixel font come out sharp, and other text is
+// drawn with the pixel font.
+func Rasterize(frame string, light bool) *image.RGBA {
+	defaultFg := color.RGBA{R: 0xe0, G: 0xe0, B: 0xe0, A: 255}
+	defaultBg := color.RGBA{R: 0x12, G: 0x12, B: 0x12, A: 255}
+	if light {
+		defaultFg, defaultBg = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 255}, color.RGBA{R: 0xfa, G: 0xfa, B: 0xfa, A: 255}
+	}
+
+	rows := parseFrame(frame, defaultFg, defaultBg)
+	columns := 1
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, columns*snapshotCellWidth, max(len(rows), 1)*snapshotCellHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(defaultBg), image.Point{}, draw.Src)
+
+	for y, row := range rows {
+		for x, cell := range row {
+			if cell.char == 0 {
+				// the right half of a wide character, drawn with it
+				continue
+			}
+			wide := x+1 < len(row) && row[x+1].char == 0
+			drawCell(img, x*snapshotCellWidth, y*snapshotCellHeight, cell, wide)
+		}
+	}
+	return img
+}
+
+// parseFrame splits a frame into cells, following the colors set by sgr
+// sequences and skipping every other escape
+func parseFrame(frame string, defaultFg color.RGBA, defaultBg color.RGBA) [][]snapshotCell {
+	lines := strings.Split(strings.TrimRight(frame, "\n"), "\n")
+	rows := make([][]snapshotCell, len(lines))
+
+	style := sgrState{fg: defaultFg, bg: defaultBg, defaultFg: defaultFg, defaultBg: defaultBg}
+	for y, line := range lines {
+		runes := []rune(line)
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			switch {
+			case r == '\x1b':
+				i = skipEscape(runes, i, &style)
+			case r == '\t':
+				rows[y] = append(rows[y], style.cell(' '))
+				for len(rows[y])%8 != 0 {
+					rows[y] = append(rows[y], style.cell(' '))
+				}
+			case r < ' ' || r == 0x7f:
+				// other control characters don't take a cell
+			default:
+				width := uniseg.StringWidth(string(r))
+				if width == 0 {
+					continue
+				}
+				rows[y] = append(rows[y], style.cell(r))
+				if width == 2 {
+					rows[y] = append(rows[y], style.cell(0))
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// skipEscape reads the escape sequence starting at runes[i], applying it to
+// style when it sets colors, and returns the index of its last rune
+func skipEscape(runes []rune, i int, style *sgrState) int {
+	if i+1 >= len(runes) {
+		return i
+	}
+
+	switch runes[i+1] {
+	case '[':
+		end := i + 2
+		for end < len(runes) && (runes[end] < 0x40 || runes[end] > 0x7e) {
+			end++
+		}
+		if end < len(runes) && runes[end] == 'm' {
+			style.apply(string(runes[i+2 : end]))
+		}
+		return end
+
+	case ']', '_', 'P', '^':
+		// strings like kitty graphics end with ST or, for osc, BEL
+		for end := i + 2; end < len(runes); end++ {
+			if runes[end] == '\a' {
+				return end
+			}
+			if runes[end] == '\x1b' && end+1 < len(runes) && runes[end+1] == '\\' {
+				return end + 1
+			}
+		}
+		return len(runes) - 1
+	}
+
+	return i + 1
+}
+
+// sgrState is the style that printed characters get
+type sgrState struct {
+	fg, bg               color.RGBA
+	defaultFg, defaultBg color.RGBA
+	faint, reverse       bool
+}
+
+func (s sgrState) cell(char rune) snapshotCell {
+	fg, bg := s.fg, s.bg
+	if s.reverse {
+		fg, bg = bg, fg
+	}
+	if s.faint {
+		fg = mixRGBA(fg, bg, 0.5)
+	}
+	return snapshotCell{char: char, fg: fg, bg: bg}
+}
+
+// apply reads the parameters of an sgr sequence
+func (s *sgrState) apply(params string) {
+	fields := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	if len(fields) == 0 {
+		fields = []string{"0"}
+	}
+
+	codes := make([]int, len(fields))
+	for i, field := range fields {
+		codes[i], _ = strconv.Atoi(field)
+	}
+
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		switch {
+		case code == 0:
+			*s = sgrState{fg: s.defaultFg, bg: s.defaultBg, defaultFg: s.defaultFg, defaultBg: s.defaultBg}
+		case code == 2:
+			s.faint = true
+		case code == 22:
+			s.faint = false
+		case code == 7:
+			s.reverse = true
+		case code == 27:
+			s.reverse = false
+		case code == 39:
+			s.fg = s.defaultFg
+		case code == 49:
+			s.bg = s.defaultBg
+		case code >= 30 && code <= 37:
+			s.fg = ansiColor(code - 30)
+		case code >= 90 && code <= 97:
+			s.fg = ansiColor(code - 90 + 8)
+		case code >= 40 && code <= 47:
+			s.bg = ansiColor(code - 40)
+		case code >= 100 && code <= 107:
+			s.bg = ansiColor(code - 100 + 8)
+		case code == 38 || code == 48:
+			c, used := extendedColor(codes[i+1:])
+			i += used
+			if used == 0 {
+				continue
+			}
+			if code == 38 {
+				s.fg = c
+			} else {
+				s.bg = c
+			}
+		}
+	}
+}
+
+// extendedColor reads the color after a 38 or 48, either 5;n or 2;r;g;b, and
+// how many parameters it took
+func extendedColor(codes []int) (color.RGBA, int) {
+	switch {
+	case len(codes) >= 2 && codes[0] == 5:
+		return ansiColor(codes[1]), 2
+	case len(codes) >= 4 && codes[0] == 2:
+		return color.RGBA{R: uint8(codes[1]), G: uint8(codes[2]), B: uint8(codes[3]), A: 255}, 4
+	}
+	return color.RGBA{}, 0
+}
+
+// ansiColors are the first 16 colors of the 256 color palette, as xterm
+// shows them
+var ansiColors = [16]color.RGBA{
+	{0x00, 0x00, 0x00, 255}, {0xcd, 0x00, 0x00, 255}, {0x00, 0xcd, 0x00, 255}, {0xcd, 0xcd, 0x00, 255},
+	{0x00, 0x00, 0xee, 255}, {0xcd, 0x00, 0xcd, 255}, {0x00, 0xcd, 0xcd, 255}, {0xe5, 0xe5, 0xe5, 255},
+	{0x7f, 0x7f, 0x7f, 255}, {0xff, 0x00, 0x00, 255}, {0x00, 0xff, 0x00, 255}, {0xff, 0xff, 0x00, 255},
+	{0x5c, 0x5c, 0xff, 255}, {0xff, 0x00, 0xff, 255}, {0x00, 0xff, 0xff, 255}, {0xff, 0xff, 0xff, 255},
+}
+
+// ansiColor is color n of the 256 color palette
+func ansiColor(n int) color.RGBA {
+	switch {
+	case n < 16:
+		return ansiColors[max(n, 0)]
+	case n < 232:
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return color.RGBA{R: levels[n/36], G: levels[n/6%6], B: levels[n%6], A: 255}
+	default:
+		gray := uint8(8 + 10*(min(n, 255)-232))
+		return color.RGBA{R: gray, G: gray, B: gray, A: 255}
+	}
+}
+
+// drawCell paints a cell's background and draws its character over it
+func drawCell(img *image.RGBA, x int, y int, cell snapshotCell, wide bool) {
+	width := snapshotCellWidth
+	if wide {
+		width *= 2
+	}
+	fillRect(img, x, y, width, snapshotCellHeight, cell.bg)
+
+	// fill draws the part of the cell between the given fractions of its
+	// width and height
+	fill := func(x0, y0, x1, y1 float64, c color.RGBA) {
+		px0 := x + int(x0*float64(snapshotCellWidth))
+		py0 := y + int(y0*float64(snapshotCellHeight))
+		px1 := x + int(x1*float64(snapshotCellWidth))
+		py1 := y + int(y1*float64(snapshotCellHeight))
+		fillRect(img, px0, py0, px1-px0, py1-py0, c)
+	}
+
+	fg := cell.fg
+	switch r := cell.char; {
+	case r == 0 || r == ' ':
+	case r == '█':
+		fill(0, 0, 1, 1, fg)
+	case r == '▀':
+		fill(0, 0, 1, 0.5, fg)
+	case r == '▔':
+		fill(0, 0, 1, 0.125, fg)
+	case r >= '▁' && r <= '▇':
+		fill(0, 1-float64(r-'▁'+1)/8, 1, 1, fg)
+	case r >= '▉' && r <= '▏':
+		fill(0, 0, float64('▏'-r+1)/8, 1, fg)
+	case r == '▐':
+		fill(0.5, 0, 1, 1, fg)
+	case r == '▕':
+		fill(0.875, 0, 1, 1, fg)
+	case r >= '░' && r <= '▓':
+		fill(0, 0, 1, 1, mixRGBA(cell.bg, fg, float64(r-'░'+1)/4))
+	case r >= '▖' && r <= '▟':
+		// quadrants, as upper left, upper right, lower left, lower right
+		quadrants := [10][4]bool{
+			{false, false, true, false}, {false, false, false, true}, {true, false, false, false},
+			{true, false, true, true}, {true, false, false, true}, {true, true, true, false},
+			{true, true, false, true}, {false, true, false, false}, {false, true, true, false},
+			{false, true, true, true},
+		}[r-'▖']
+		for i, filled := range quadrants {
+			if filled {
+				qx, qy := float64(i%2)/2, float64(i/2)/2
+				fill(qx, qy, qx+0.5, qy+0.5, fg)
+			}
+		}
+	case r == '─' || r == '━':
+		thickness := 1.0 / 12
+		if r == '━' {
+			thickness = 1.0 / 6
+		}
+		fill(0, 0.5-thickness/2, 1, 0.5+thickness/2, fg)
+	case r == '│' || r == '┃':
+		thickness := 1.0 / 6
+		if r == '┃' {
+			thickness = 1.0 / 3
+		}
+		fill(0.5-thickness/2, 0, 0.5+thickness/2, 1, fg)
+	case r == '·' || r == '•' || r == '●':
+		size := map[rune]float64{'·': 1.0 / 6, '•': 1.0 / 3, '●': 0.6}[r]
+		aspect := float64(snapshotCellWidth) / float64(snapshotCellHeight)
+		fill(0.5-size/2, 0.5-size*aspect/2, 0.5+size/2, 0.5+size*aspect/2, fg)
+	default:
+		drawGlyph(img, x, y, width, r, fg)
+	}
+}
+
+// drawGlyph draws a character with the pixel font, centered in the cell and
+// sitting on the same baseline as its neighbours
+func drawGlyph(img *image.RGBA, x int, y int, width int, char rune, fg color.RGBA) {
+	g, ok := pixelGlyph(unicode.ToUpper(char))
+	if !ok {
+		return
+	}
+
+	glyphWidth := g.width * snapshotGlyphScale
+	glyphHeight := len(g.rows) * snapshotGlyphScale
+	left := x + (width-glyphWidth)/2
+	top := y + snapshotCellHeight*3/4 - glyphHeight
+	for row := range g.rows {
+		for col := 0; col < g.width; col++ {
+			if g.filled(row, col) {
+				fillRect(img, left+col*snapshotGlyphScale, top+row*snapshotGlyphScale, snapshotGlyphScale, snapshotGlyphScale, fg)
+			}
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, x int, y int, width int, height int, c color.RGBA) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	draw.Draw(img, image.Rect(x, y, x+width, y+height), image.NewUniform(c), image.Point{}, draw.Src)
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"log/slog"
+	"time"
+)
+
+// renderLogInterval is how often the render timing is summed up in the log
+const renderLogInterval = 10 * time.Second
+
+// renderTiming adds up how long frames take, for the debug log. bubble tea
+// draws from a single goroutine, so it needs no lock.
+type renderTiming struct {
+	since   time.Time
+	frames  int
+	total   time.Duration
+	slowest time.Duration
+}
+
+var frameTiming renderTiming
+
+// record counts a frame that started at start, logging the frames since the
+// last summary once the interval is up
+func (t *renderTiming) record(start time.Time) {
+	took := time.Since(start)
+	if t.since.IsZero() {
+		t.since = start
+	}
+	t.frames++
+	t.total += took
+	t.slowest = max(t.slowest, took)
+
+	elapsed := time.Since(t.since)
+	if elapsed < renderLogInterval {
+		return
+	}
+	slog.Debug("render",
+		"frames", t.frames,
+		"fps", float64(t.frames)/elapsed.Seconds(),
+		"avg", (t.total / time.Duration(t.frames)).Round(time.Microsecond),
+		"max", t.slowest.Round(time.Microsecond),
+	)
+	*t = renderTiming{since: time.Now()}
+}
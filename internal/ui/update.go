@@ -3,12 +3,18 @@ package ui
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"karolbroda.com/lyrecho/internal/artwork"
 	"karolbroda.com/lyrecho/internal/cache"
+	"karolbroda.com/lyrecho/internal/config"
 	"karolbroda.com/lyrecho/internal/lyrics"
 	"karolbroda.com/lyrecho/internal/player"
 	"karolbroda.com/lyrecho/internal/track"
@@ -19,11 +25,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		// a font size change resizes the window too
+		if m.termCaps != nil {
+			m.termCaps.RefreshCellSize()
+		}
 		return m, nil
 
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
 
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
 	case PlayerEventMsg:
 		return m.handlePlayerEvent(msg.Event)
 
@@ -33,7 +46,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case LyricsFetchedMsg:
 		return m.handleLyricsFetched(msg)
 
+	case LyricsProgressMsg:
+		if msg.updates != m.fetchUpdates {
+			return m, nil
+		}
+		m.fetchProgress = msg.Progress
+		return m, tea.Batch(m.wake(), listenFetchProgress(msg.updates))
+
+	case OptionChangedMsg:
+		if msg.Err != nil {
+			m.showToast("not supported by this player")
+		} else {
+			m.showToast(msg.Label)
+			m.refreshOptions()
+		}
+		return m, m.wake()
+
+	case UpNextFetchedMsg:
+		return m.handleUpNextFetched(msg)
+
+	case SnapshotSavedMsg:
+		return m.handleSnapshotSaved(msg)
+
+	case VolumeChangedMsg:
+		if errors.Is(msg.Err, player.ErrUnsupported) {
+			m.showToast("volume not supported by this player")
+		} else if msg.Err != nil {
+			m.showToast("volume unavailable")
+		} else {
+			m.showToast(fmt.Sprintf("volume %d%%", int(math.Round(msg.Volume*100))))
+		}
+		return m, m.wake()
+
 	case TickMsg:
+		if msg.gen != m.tickGen {
+			return m, nil
+		}
 		return m.handleTick()
 	}
 
@@ -41,81 +89,824 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.prompting {
+		return m.handlePromptKey(msg)
+	}
+	if m.editingSearch {
+		return m.handleSearchKey(msg)
+	}
+	if m.err != nil && m.display.Track != nil && !m.loadingState.IsLoadingLyrics() {
+		if model, cmd, handled := m.handleErrorKey(msg); handled {
+			return model, cmd
+		}
+	}
+	if m.browsing {
+		return m.handleBrowseKey(msg)
+	}
+	if m.sheet {
+		return m.handleSheetKey(msg)
+	}
+	if m.comparing {
+		return m.handleCompareKey(msg)
+	}
+
+	switch msg.String() {
+	case m.volumeKeys[0]:
+		return m, m.adjustVolumeCmd(-volumeStep)
+	case m.volumeKeys[1]:
+		return m, m.adjustVolumeCmd(volumeStep)
+	}
+
 	switch msg.String() {
-	case "q", "ctrl+c", "esc":
+	case "esc":
+		// leave manual scrolling before quitting
+		if !m.sheetFollow {
+			m.follow()
+			return m, nil
+		}
 		m.quitting = true
 		m.Stop()
 		return m, tea.Quit
 
-	case "up", "k", "+", "=":
-		m.syncOffset += 0.1
-		m.updateLyricIndexFromPosition()
-		m.saveSyncOffset()
+	case "q", "ctrl+c":
+		m.quitting = true
+		m.Stop()
+		return m, tea.Quit
+
+	case "pgup":
+		m.scrollSheet(-sheetPageLines)
+		return m, m.wake()
+
+	case "pgdown":
+		m.scrollSheet(sheetPageLines)
+		return m, m.wake()
+
+	case "f":
+		m.follow()
 		return m, nil
 
-	case "down", "j", "-":
-		m.syncOffset -= 0.1
-		m.updateLyricIndexFromPosition()
-		m.saveSyncOffset()
+	case ":":
+		if m.display.Track != nil {
+			m.prompting = true
+			m.promptInput = ""
+		}
 		return m, nil
 
+	case "up", "k", "+", "=":
+		m.setSyncOffset(m.syncOffset + 0.1)
+		return m, m.wake()
+
+	case "down", "j", "-":
+		m.setSyncOffset(m.syncOffset - 0.1)
+		return m, m.wake()
+
 	case "left", "h":
-		m.syncOffset -= 0.5
-		m.updateLyricIndexFromPosition()
-		m.saveSyncOffset()
-		return m, nil
+		m.setSyncOffset(m.syncOffset - 0.5)
+		return m, m.wake()
 
 	case "right", "l":
-		m.syncOffset += 0.5
-		m.updateLyricIndexFromPosition()
-		m.saveSyncOffset()
-		return m, nil
+		m.setSyncOffset(m.syncOffset + 0.5)
+		return m, m.wake()
 
 	case "0":
-		m.syncOffset = 0
-		m.updateLyricIndexFromPosition()
-		m.saveSyncOffset()
-		return m, nil
+		m.setSyncOffset(0)
+		return m, m.wake()
 
 	case "tab", "i":
 		m.hideHeader = !m.hideHeader
+		m.saveViewState()
+		return m, nil
+
+	case "I":
+		m.statusBar = !m.statusBar
+		return m, nil
+
+	case "a":
+		switch m.layout {
+		case LayoutStacked:
+			m.layout = LayoutSide
+		case LayoutSide:
+			m.layout = LayoutColumns
+		default:
+			m.layout = LayoutStacked
+		}
+		m.saveViewState()
+		return m, nil
+
+	case "<", ">":
+		count := m.contextLines
+		if count < 0 {
+			// start from what fit the terminal
+			count = autoContextLines(m.height)
+		}
+		if msg.String() == "<" {
+			count = max(count-1, 0)
+		} else {
+			count = min(count+1, maxContextLines)
+		}
+		m.contextLines = count
+		m.saveViewState()
+		m.showToast(fmt.Sprintf("%d context lines", count))
+		return m, m.wake()
+
+	case "P":
+		m.plainText = !m.plainText
+		if m.plainText {
+			m.showToast("plain text")
+		} else {
+			m.showToast("pixel font")
+		}
+		return m, m.wake()
+
+	case "K":
+		m.karaoke = !m.karaoke
+		if m.karaoke {
+			m.showToast("karaoke fill on")
+		} else {
+			m.showToast("karaoke fill off")
+		}
+		return m, m.wake()
+
+	case "u":
+		m.showUpNext = !m.showUpNext
+		if m.showUpNext {
+			return m, m.fetchUpNextCmd()
+		}
+		return m, nil
+
+	case "T":
+		m.timestamps = !m.timestamps
+		if m.timestamps {
+			m.showToast("timestamps on")
+		} else {
+			m.showToast("timestamps off")
+		}
+		return m, m.wake()
+
+	case "B":
+		if m.display.Track != nil {
+			m.tapTempo()
+		}
+		return m, m.wake()
+
+	case "V":
+		if m.levels == nil {
+			m.showToast("start with --visualizer to show one")
+			return m, m.wake()
+		}
+		m.showVisualizer = !m.showVisualizer
+		return m, nil
+
+	case "C":
+		m.sections = !m.sections
+		if m.sections {
+			m.showToast("chorus highlighting on")
+		} else {
+			m.showToast("chorus highlighting off")
+		}
+		return m, m.wake()
+
+	case "v":
+		if len(m.display.Lines) > 0 {
+			m.sheet = true
+			m.follow()
+		}
+		return m, nil
+
+	case "t":
+		m.cycleTranslation()
+		return m, m.wake()
+
+	case "c":
+		if m.plainLyrics == "" {
+			m.showToast("no plain lyrics for this song")
+			return m, m.wake()
+		}
+		m.comparing = true
 		return m, nil
+
+	case "b":
+		if len(m.display.Lines) > 0 {
+			m.browsing = true
+			m.browseIndex = max(m.display.CurrentIndex, 0)
+		}
+		return m, nil
+
+	case " ":
+		return m, m.playerControlCmd(player.Service.PlayPause)
+
+	case "n":
+		return m, m.playerControlCmd(player.Service.Next)
+
+	case "s":
+		return m, m.toggleShuffleCmd()
+
+	case "o":
+		return m, m.cycleLoopCmd()
+
+	case "r":
+		if m.display.Track == nil || m.preview || m.loadingState.IsLoadingLyrics() {
+			return m, nil
+		}
+		// the current lyrics stay up until the new ones are in
+		m.refetching = true
+		m.setLoadingLyrics(true)
+		m.showToast("refetching lyrics")
+		return m, tea.Batch(m.wake(), m.fetchLyricsCmd(true))
+
+	case "R":
+		if m.display.Track == nil || m.preview || m.loadingState.IsLoadingLyrics() {
+			return m, nil
+		}
+		// unlike r the cached lyrics are taken for wrong, so they don't
+		// stay up while searching
+		m.display.Lines = nil
+		m.display.lineTracker = lyrics.LineTracker{}
+		m.display.sections = nil
+		m.display.CurrentIndex = -1
+		return m, m.refetchLyrics(true)
+
+	case "p":
+		return m, m.playerControlCmd(player.Service.Previous)
+
+	case "S":
+		return m, m.saveSnapshotCmd()
 	}
 
 	return m, nil
 }
 
+// setSyncOffset moves the lyrics against the playback position, shows the
+// new offset and saves it for the track
+func (m *Model) setSyncOffset(offset float64) {
+	// steps of 0.1 add up to values like 0.30000000000000004
+	m.syncOffset = math.Round(offset*100) / 100
+	m.updateLyricIndexFromPosition()
+	m.saveSyncOffset()
+	m.showToast(formatSyncOffset(m.syncOffset))
+}
+
+// formatSyncOffset shows an offset the way the old viewer did
+func formatSyncOffset(offset float64) string {
+	return fmt.Sprintf("sync: %+.1fs", offset)
+}
+
+// saveViewState remembers the header, layout and context lines for the
+// next run
+func (m *Model) saveViewState() {
+	if m.preview {
+		return
+	}
+	hideHeader := m.hideHeader
+	contextLines := m.contextLines
+	_ = config.SaveState(&config.State{
+		HideHeader:   &hideHeader,
+		Layout:       m.layout.String(),
+		ContextLines: &contextLines,
+	})
+}
+
 func (m *Model) saveSyncOffset() {
-	if m.display.Track == nil {
+	if m.display.Track == nil || m.preview || !m.cachePolicy.Writes() {
 		return
 	}
 
-	// update the cache entry with the new sync offset
-	diskCache := cache.GetGlobalCache()
+	// update the cache entry with the new sync offset, along with an
+	// imported record of it
+	trk := m.display.Track
+	_, _ = cache.GetGlobalCache().SetOffset(trk.Artist, trk.Title, float64(trk.DurationSecs), m.syncOffset)
+}
 
-	// get existing cache entry
-	cached, err := diskCache.Get(m.display.Track.Artist, m.display.Track.Title)
-	if err != nil {
-		// no cached entry yet, nothing to update
+// handleBrowseKey moves the selection through the lyric sheet, enter seeks
+// the player to the selected line
+func (m Model) handleBrowseKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		m.quitting = true
+		m.Stop()
+		return m, tea.Quit
+
+	case "esc", "b":
+		m.browsing = false
+		return m, nil
+
+	case "up", "k":
+		if m.browseIndex > 0 {
+			m.browseIndex--
+		}
+		return m, nil
+
+	case "down", "j":
+		if m.browseIndex < len(m.display.Lines)-1 {
+			m.browseIndex++
+		}
+		return m, nil
+
+	case "g", "home":
+		m.browseIndex = 0
+		return m, nil
+
+	case "G", "end":
+		m.browseIndex = len(m.display.Lines) - 1
+		return m, nil
+
+	case "enter":
+		m.browsing = false
+		return m, m.seekToLineCmd(m.browseIndex)
+
+	case " ":
+		return m, m.playerControlCmd(player.Service.PlayPause)
+	}
+
+	return m, nil
+}
+
+// handleCompareKey handles the side by side view of the synced and plain
+// lyrics, where the plain text can replace the synced text
+func (m Model) handleCompareKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		m.quitting = true
+		m.Stop()
+		return m, tea.Quit
+
+	case "esc", "c":
+		m.comparing = false
+		return m, nil
+
+	case "enter", "p":
+		m.usePlainLyrics(!m.usingPlain)
+		return m, m.wake()
+
+	case " ":
+		return m, m.playerControlCmd(player.Service.PlayPause)
+	}
+
+	return m, nil
+}
+
+// usePlainLyrics switches between the synced lyrics and the plain text,
+// which takes its timing from the synced lines it lines up with
+func (m *Model) usePlainLyrics(plain bool) {
+	lines := lyrics.SelectLayer(m.syncedLines, m.translation)
+	if plain {
+		merged, stats := lyrics.Merge(m.syncedLines, lyrics.PlainLines(m.plainLyrics))
+		lines = merged
+		m.showToast(fmt.Sprintf("plain lyrics, %d lines retimed", stats.Replaced+stats.Inserted))
+	} else {
+		m.showToast("synced lyrics")
+	}
+
+	m.usingPlain = plain
+	m.showLines(lines)
+}
+
+// translationLabels describe each layer for the status line
+var translationLabels = map[lyrics.Layer]string{
+	lyrics.LayerOriginal:   "original lyrics",
+	lyrics.LayerTranslated: "translation",
+	lyrics.LayerBoth:       "original and translation",
+}
+
+// cycleTranslation steps through the original, the translation and both,
+// remembering the choice for the song
+func (m *Model) cycleTranslation() {
+	if !lyrics.HasTranslation(m.syncedLines) {
+		m.showToast("no translation in these lyrics")
 		return
 	}
 
-	// update sync offset
-	cached.SyncOffset = m.syncOffset
+	m.translation = lyrics.NextLayer(m.translation)
+	m.usingPlain = false
+	m.showLines(lyrics.SelectLayer(m.syncedLines, m.translation))
+	m.showToast(translationLabels[m.translation])
 
-	// save back to cache
-	_ = diskCache.Set(m.display.Track.Artist, m.display.Track.Title, cached)
+	if m.display.Track == nil || m.preview || !m.cachePolicy.Writes() {
+		return
+	}
+	_ = cache.GetGlobalCache().SetTranslation(m.display.Track.Artist, m.display.Track.Title, string(m.translation))
 }
 
-func (m *Model) updateLyricIndexFromPosition() {
-	if m.player == nil {
+// showLines replaces the displayed lyrics and finds the playing line in them
+func (m *Model) showLines(lines []lyrics.TimedLine) {
+	m.display.Lines = lines
+	m.display.lineTracker = lyrics.NewLineTracker(lines)
+	m.display.sections = lyrics.DetectSections(lines)
+	m.display.CurrentIndex = -1
+	m.updateLyricIndex(m.position())
+	m.follow()
+	m.animState.Reset()
+}
+
+// sheetPageLines is how far page up and page down move the lyric sheet
+const sheetPageLines = 8
+
+// handleSheetKey scrolls the full lyric sheet. scrolling stops it from
+// following the playing line until the sheet is opened again.
+func (m Model) handleSheetKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := len(m.display.Lines) - 1
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		m.quitting = true
+		m.Stop()
+		return m, tea.Quit
+
+	case "esc", "v":
+		m.sheet = false
+		m.follow()
+		return m, nil
+
+	case "f":
+		m.follow()
+		return m, nil
+
+	case "up", "k":
+		m.scrollSheet(-1)
+		return m, nil
+
+	case "down", "j":
+		m.scrollSheet(1)
+		return m, nil
+
+	case "pgup":
+		m.scrollSheet(-sheetPageLines)
+		return m, nil
+
+	case "pgdown":
+		m.scrollSheet(sheetPageLines)
+		return m, nil
+
+	case "g", "home":
+		m.scrollSheet(-last)
+		return m, nil
+
+	case "G", "end":
+		m.scrollSheet(last)
+		return m, nil
+
+	case "T":
+		m.timestamps = !m.timestamps
+		return m, nil
+
+	case " ":
+		return m, m.playerControlCmd(player.Service.PlayPause)
+	}
+
+	return m, nil
+}
+
+// scrollSheet moves the view away from the playing line. outside the sheet
+// view this shows the sheet until the view locks back on.
+func (m *Model) scrollSheet(delta int) {
+	if len(m.display.Lines) == 0 {
 		return
 	}
-	pos, err := m.player.GetCurrentPosition()
-	if err != nil {
+	m.sheetFollow = false
+	m.lastScroll = time.Now()
+	m.sheetLine = max(0, min(m.sheetLine+delta, len(m.display.Lines)-1))
+}
+
+// follow locks the view back on the playing line
+func (m *Model) follow() {
+	m.sheetFollow = true
+	m.sheetLine = max(m.display.CurrentIndex, 0)
+}
+
+// mouseScrollLines is how far one wheel step scrolls
+const mouseScrollLines = 2
+
+// handleMouse scrolls the lyrics with the wheel, or changes the volume when
+// the wheel is over the track info
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+	if msg.Button != tea.MouseButtonWheelUp && msg.Button != tea.MouseButtonWheelDown {
+		return m, nil
+	}
+	up := msg.Button == tea.MouseButtonWheelUp
+
+	if m.overHeader(msg.X, msg.Y) {
+		if up {
+			return m, m.adjustVolumeCmd(volumeStep)
+		}
+		return m, m.adjustVolumeCmd(-volumeStep)
+	}
+
+	delta := mouseScrollLines
+	if up {
+		delta = -delta
+	}
+
+	if m.browsing {
+		m.browseIndex = max(0, min(m.browseIndex+delta, len(m.display.Lines)-1))
+		return m, nil
+	}
+
+	m.scrollSheet(delta)
+	return m, m.wake()
+}
+
+// overHeader reports whether a cell is on the track info, the header above
+// the lyrics or the panel beside them
+func (m Model) overHeader(x int, y int) bool {
+	if m.width == 0 || m.height == 0 || m.display.Track == nil || m.oneLine {
+		return false
+	}
+
+	if m.layout == LayoutSide && m.width >= sideLayoutMinWidth && m.height >= sideLayoutMinHeight {
+		return x < sideArtHeight(m.width, m.height)*2+4
+	}
+	if m.hideHeader || m.miniMode() {
+		return false
+	}
+
+	palette := m.display.Palette
+	if palette == nil {
+		palette = artwork.DefaultPalette()
+	}
+	return y < len(m.renderCompactHeader(palette, m.width))
+}
+
+// refetchLyrics looks the lyrics of the current track up again
+func (m *Model) refetchLyrics(skipCache bool) tea.Cmd {
+	m.err = nil
+	m.setLoadingLyrics(true)
+	return tea.Batch(m.wake(), m.fetchLyricsCmd(skipCache))
+}
+
+// lyricsTrack is what the lyrics are searched for, the current track unless
+// the search terms were corrected on the error screen
+func (m Model) lyricsTrack() *track.Info {
+	if m.searchTrack != nil {
+		return m.searchTrack
+	}
+	return m.display.Track
+}
+
+// handleErrorKey offers ways out of a failed lookup, other keys work as
+// usual
+func (m Model) handleErrorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	switch msg.String() {
+	case "r":
+		return m, m.refetchLyrics(false), true
+	case "R":
+		return m, m.refetchLyrics(true), true
+	case "e":
+		search := m.lyricsTrack()
+		m.editingSearch = true
+		m.searchInput = search.Artist + searchSeparator + search.Title
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// searchSeparator splits the artist from the title in the search editor
+const searchSeparator = " - "
+
+// handleSearchKey edits the search terms, enter looks the lyrics up with
+// them
+func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		m.Stop()
+		return m, tea.Quit
+
+	case tea.KeyEsc:
+		m.editingSearch = false
+		return m, nil
+
+	case tea.KeyBackspace:
+		runes := []rune(m.searchInput)
+		if len(runes) > 0 {
+			m.searchInput = string(runes[:len(runes)-1])
+		}
+		return m, nil
+
+	case tea.KeyEnter:
+		artist, title, ok := strings.Cut(m.searchInput, searchSeparator)
+		artist = strings.TrimSpace(artist)
+		title = strings.TrimSpace(title)
+		if !ok || artist == "" || title == "" {
+			m.showToast("type it as artist" + searchSeparator + "title")
+			return m, m.wake()
+		}
+
+		m.editingSearch = false
+		search := *m.display.Track
+		search.Artist = artist
+		search.Title = title
+		m.searchTrack = &search
+		return m, m.refetchLyrics(true)
+
+	case tea.KeySpace:
+		m.searchInput += " "
+
+	case tea.KeyRunes:
+		m.searchInput += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+// handlePromptKey edits the jump-to-time prompt, enter seeks to the typed
+// time
+func (m Model) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		m.Stop()
+		return m, tea.Quit
+
+	case tea.KeyEsc:
+		m.prompting = false
+		return m, nil
+
+	case tea.KeyBackspace:
+		if m.promptInput == "" {
+			m.prompting = false
+			return m, nil
+		}
+		m.promptInput = m.promptInput[:len(m.promptInput)-1]
+		return m, nil
+
+	case tea.KeyEnter:
+		m.prompting = false
+		seconds, err := parseTimestamp(m.promptInput)
+		if err != nil {
+			m.showToast(err.Error())
+			return m, m.wake()
+		}
+		return m.seekTo(seconds)
+
+	case tea.KeyRunes:
+		for _, r := range msg.Runes {
+			if (r >= '0' && r <= '9') || r == ':' || r == '.' {
+				m.promptInput += string(r)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// parseTimestamp reads a time typed as seconds, m:ss or h:mm:ss, with
+// optional fractional seconds
+func parseTimestamp(input string) (float64, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, errors.New("no time entered")
+	}
+
+	parts := strings.Split(input, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", input)
+	}
+
+	seconds := 0.0
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid time %q", input)
+		}
+		// minutes and seconds after the first field stay below 60
+		if i > 0 && value >= 60 {
+			return 0, fmt.Errorf("invalid time %q", input)
+		}
+		seconds = seconds*60 + value
+	}
+
+	return seconds, nil
+}
+
+// seekTo moves playback to a time in the track, updating the lyrics right
+// away instead of waiting for the player to report the seek
+func (m Model) seekTo(seconds float64) (tea.Model, tea.Cmd) {
+	trk := m.display.Track
+	if trk == nil {
+		return m, nil
+	}
+	if trk.DurationSecs > 0 {
+		seconds = min(seconds, float64(trk.DurationSecs))
+	}
+
+	micros := int64(seconds * 1_000_000)
+	trackID := trk.TrackID
+
+	m.clock.set(micros, m.clock.playing)
+	m.updateLyricIndex(m.position())
+	m.follow()
+	m.lastLineChange = time.Now()
+	m.animState.Reset()
+
+	return m, tea.Batch(m.wake(), m.playerControlCmd(func(s player.Service) error {
+		return s.SetPosition(trackID, micros)
+	}))
+}
+
+// seekToLineCmd moves playback to where a line shows up with the current sync offset
+func (m Model) seekToLineCmd(index int) tea.Cmd {
+	if index < 0 || index >= len(m.display.Lines) || m.display.Track == nil {
+		return nil
+	}
+
+	target := m.display.Lines[index].TimeSeconds - m.lyricOffset()
+	trackID := m.display.Track.TrackID
+
+	return m.playerControlCmd(func(s player.Service) error {
+		return s.SetPosition(trackID, int64(target*1_000_000))
+	})
+}
+
+// nextLoop is the order the loop key steps through
+var nextLoop = map[string]string{
+	player.LoopNone:     player.LoopPlaylist,
+	player.LoopPlaylist: player.LoopTrack,
+	player.LoopTrack:    player.LoopNone,
+}
+
+func (m Model) toggleShuffleCmd() tea.Cmd {
+	if m.player == nil || m.preview {
+		return nil
+	}
+
+	playerService := m.player
+	enabled := !m.shuffle
+	return func() tea.Msg {
+		label := "shuffle off"
+		if enabled {
+			label = "shuffle on"
+		}
+		return OptionChangedMsg{Label: label, Err: playerService.SetShuffle(enabled)}
+	}
+}
+
+func (m Model) cycleLoopCmd() tea.Cmd {
+	if m.player == nil || m.preview {
+		return nil
+	}
+
+	playerService := m.player
+	loop, ok := nextLoop[m.loop]
+	if !ok {
+		loop = player.LoopPlaylist
+	}
+	return func() tea.Msg {
+		return OptionChangedMsg{Label: "loop " + strings.ToLower(loop), Err: playerService.SetLoop(loop)}
+	}
+}
+
+// refreshOptions copies shuffle and loop from the player for the header
+func (m *Model) refreshOptions() {
+	state := m.player.GetState()
+	m.shuffle = state.Shuffle
+	m.loop = state.Loop
+}
+
+// volumeStep is how much one volume key press changes the volume
+const volumeStep = 0.05
+
+// adjustVolumeCmd changes the volume relative to the player's current one,
+// reporting the result for the status line
+func (m Model) adjustVolumeCmd(delta float64) tea.Cmd {
+	if m.player == nil || m.preview {
+		return nil
+	}
+
+	playerService := m.player
+	return func() tea.Msg {
+		volume, err := playerService.Volume()
+		if err != nil {
+			return VolumeChangedMsg{Err: err}
+		}
+
+		volume = max(0, min(volume+delta, 1))
+		err = playerService.SetVolume(volume)
+		return VolumeChangedMsg{Volume: volume, Err: err}
+	}
+}
+
+// playerControlCmd runs a player action off the update loop. failures are
+// ignored, the next poll picks up whatever state the player ended up in.
+func (m Model) playerControlCmd(action func(player.Service) error) tea.Cmd {
+	if m.player == nil || m.preview {
+		return nil
+	}
+
+	playerService := m.player
+	return func() tea.Msg {
+		_ = action(playerService)
+		return nil
+	}
+}
+
+func (m *Model) updateLyricIndexFromPosition() {
+	if m.player == nil {
 		return
 	}
-	m.updateLyricIndex(pos)
+	m.updateLyricIndex(m.position())
 }
 
 func (m Model) handlePlayerEvent(event player.EventData) (tea.Model, tea.Cmd) {
@@ -124,55 +915,154 @@ func (m Model) handlePlayerEvent(event player.EventData) (tea.Model, tea.Cmd) {
 
 	switch event.Type {
 	case player.EventTrackChanged:
+		m.clock.set(event.PositionMicros, m.clock.playing)
 		return m.handleTrackChange(event.Track, cmds)
 
 	case player.EventSeeked:
-		m.positionSecs = event.Position
-		m.updateLyricIndex(event.Position)
+		m.clock.set(event.PositionMicros, m.clock.playing)
+		m.updateLyricIndex(m.position())
 		m.lastLineChange = time.Now()
 		m.animState.Reset()
 		return m, tea.Batch(cmds...)
 
 	case player.EventPlaybackStateChanged:
+		if event.Playing {
+			cmds = append(cmds, m.wake())
+		}
+		m.clock.setPlaying(event.Playing)
+		return m, tea.Batch(cmds...)
+
+	case player.EventRateChanged:
+		m.clock.setRate(event.Rate)
 		return m, tea.Batch(cmds...)
+
+	case player.EventPlayerChanged:
+		// drop the old player's track until the new one reports its own
+		m.clock = playbackClock{}
+		return m.handleTrackChange(nil, cmds)
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) handleTrackChange(newTrack *track.Info, existingCmds []tea.Cmd) (tea.Model, tea.Cmd) {
+	existingCmds = append(existingCmds, m.finishListening())
+	m.startListening(newTrack)
+	m.startCrossfade(true)
 	m.display.Track = newTrack
 	m.resetForNewTrack()
+	m.bpm = 0
+	m.beatAnchor = 0
+	m.taps = nil
+	if newTrack != nil {
+		m.bpm = float64(newTrack.BPM)
+	}
 
 	if newTrack == nil || !newTrack.IsValid() {
+		// the player went away, wait for it (or another one) to come back
+		m.display.Track = nil
+		m.clock = playbackClock{}
 		m.err = errors.New("no track playing")
 		return m, tea.Batch(existingCmds...)
 	}
 
+	m.display.placeholder = placeholderArtwork(m.display.Palette, newTrack.Artist)
 	if newTrack.ArtworkURL != "" {
 		m.setLoadingArtwork(true)
-		existingCmds = append(existingCmds, fetchArtworkCmd(newTrack.ArtworkURL))
+		existingCmds = append(existingCmds, fetchArtworkCmd(newTrack.ArtworkURL, m.cachePolicy))
 	}
 
 	m.setLoadingLyrics(true)
-	existingCmds = append(existingCmds, fetchLyricsCmd(m.lrclibURL, newTrack))
+	existingCmds = append(existingCmds, m.fetchLyricsCmd(false))
+	existingCmds = append(existingCmds, m.fetchUpNextCmd())
 
 	return m, tea.Batch(existingCmds...)
 }
 
+// upNextLimit is how many queued tracks are asked for and listed
+const upNextLimit = 5
+
+// fetchUpNextCmd asks the player for the tracks after the current one
+func (m Model) fetchUpNextCmd() tea.Cmd {
+	if m.player == nil || m.preview {
+		return nil
+	}
+
+	playerService := m.player
+	return func() tea.Msg {
+		tracks, err := playerService.UpNext(upNextLimit)
+		return UpNextFetchedMsg{Tracks: tracks, Err: err}
+	}
+}
+
+// handleUpNextFetched stores the queue and fetches the next track's lyrics
+// in the background, so they are cached by the time it starts
+func (m Model) handleUpNextFetched(msg UpNextFetchedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.upNext = nil
+		if m.showUpNext {
+			m.showUpNext = false
+			if errors.Is(msg.Err, player.ErrUnsupported) {
+				m.showToast("up next not supported by this player")
+			} else {
+				m.showToast("up next unavailable")
+			}
+		}
+		return m, m.wake()
+	}
+
+	m.upNext = msg.Tracks
+	if len(msg.Tracks) == 0 {
+		return m, m.wake()
+	}
+
+	next := msg.Tracks[0]
+	key := next.Artist + "\x00" + next.Title
+	if key == m.prefetched {
+		return m, m.wake()
+	}
+	m.prefetched = key
+
+	// nothing would be kept from a prefetch without cache writes
+	if !m.cachePolicy.Writes() {
+		return m, m.wake()
+	}
+
+	return m, tea.Batch(m.wake(), prefetchLyricsCmd(m.lrclibURL, next))
+}
+
+// prefetchLyricsCmd fetches lyrics only to fill the cache, errors are left
+// for when the track actually plays
+func prefetchLyricsCmd(lrclibURL string, trk track.Info) tea.Cmd {
+	return func() tea.Msg {
+		_, _ = lyrics.Fetch(context.Background(), lrclibURL, lyricsParams(&trk))
+		return nil
+	}
+}
+
+func lyricsParams(trk *track.Info) *lyrics.TrackParams {
+	return &lyrics.TrackParams{
+		Title:        trk.Title,
+		Artist:       trk.Artist,
+		Album:        trk.Album,
+		DurationSecs: trk.DurationSecs,
+	}
+}
+
 func (m Model) handleArtworkFetched(msg ArtworkFetchedMsg) (tea.Model, tea.Cmd) {
 	m.setLoadingArtwork(false)
 
 	if msg.Err == nil && msg.Image != nil {
 		m.display.Image = msg.Image
 		if msg.Palette != nil {
-			m.display.Palette = msg.Palette
+			m.startCrossfade(false)
+			m.setPalette(msg.Palette)
 		}
 	} else if msg.Err != nil {
 		// artwork failed to load, but we should still update palette
 		// use default palette explicitly to ensure colors are set
 		if m.display.Palette == nil {
-			m.display.Palette = artwork.DefaultPalette()
+			m.setPalette(artwork.DefaultPalette())
 		}
 	}
 
@@ -185,6 +1075,16 @@ func (m Model) handleArtworkFetched(msg ArtworkFetchedMsg) (tea.Model, tea.Cmd)
 
 func (m Model) handleLyricsFetched(msg LyricsFetchedMsg) (tea.Model, tea.Cmd) {
 	m.setLoadingLyrics(false)
+	m.fetchProgress = lyrics.Progress{}
+
+	if m.refetching {
+		m.refetching = false
+		if msg.Err != nil || len(msg.Lines) == 0 {
+			m.showToast("refetch failed, keeping these lyrics")
+			return m, m.wake()
+		}
+		m.showToast("lyrics refetched")
+	}
 
 	if msg.Err != nil {
 		m.err = msg.Err
@@ -200,7 +1100,19 @@ func (m Model) handleLyricsFetched(msg LyricsFetchedMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	m.display.Lines = msg.Lines
+	lines := msg.Lines
+	if lyrics.HasTranslation(lines) {
+		m.translation = msg.Translation
+		lines = lyrics.SelectLayer(lines, m.translation)
+	}
+
+	m.display.Lines = lines
+	m.display.lineTracker = lyrics.NewLineTracker(lines)
+	m.display.sections = lyrics.DetectSections(lines)
+	m.lyricsSource = msg.Source
+	m.lyricsLoaded(msg.Source, len(lines))
+	m.syncedLines = msg.Lines
+	m.plainLyrics = msg.Plain
 	m.err = nil
 	m.display.CurrentIndex = 0
 
@@ -215,38 +1127,96 @@ func (m Model) handleLyricsFetched(msg LyricsFetchedMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleTick() (tea.Model, tea.Cmd) {
 	m.tickCount++
 
+	if !m.paused() {
+		m.pausedSince = time.Time{}
+	} else if m.pausedSince.IsZero() {
+		m.pausedSince = time.Now()
+	}
+
+	if !m.sheetFollow && m.relockAfter > 0 && time.Since(m.lastScroll) >= m.relockAfter {
+		m.follow()
+	}
+
+	if m.preview {
+		return m.handlePreviewTick()
+	}
+
 	if m.player == nil {
-		m.animState.Update(m.tickCount, false, 8)
-		return m, tickCmd()
+		m.animState.Update(m.tickCount, false)
+		return m, m.nextTick()
 	}
 
 	err := m.player.Poll()
+	m.playerErr = err
 	if err != nil {
-		m.animState.Update(m.tickCount, false, 8)
-		return m, tickCmd()
+		m.animState.Update(m.tickCount, false)
+		return m, m.nextTick()
 	}
+	m.refreshOptions()
 
-	pos, err := m.player.GetCurrentPosition()
-	if err != nil {
-		m.animState.Update(m.tickCount, false, 8)
-		return m, tickCmd()
+	// a paused position doesn't move, it is read again once playback resumes
+	resumed := m.paused() && m.player.GetState().Playing
+	if resumed || (m.clock.stale() && !m.paused()) {
+		micros, err := m.player.GetPositionMicros()
+		if err != nil {
+			m.animState.Update(m.tickCount, false)
+			return m, m.nextTick()
+		}
+		state := m.player.GetState()
+		m.clock.set(micros, state.Playing)
+		m.clock.rate = state.Rate
+	}
+
+	lineChanged := m.updateLyricIndex(m.position())
+	m.countListening()
+	m.animState.Update(m.tickCount, lineChanged)
+	if beats, ok := m.beats(); ok {
+		m.animState.Pulse(beats)
+	}
+
+	return m, m.nextTick()
+}
+
+// handlePreviewTick advances the fake playback clock, looping over the sample lines
+func (m Model) handlePreviewTick() (tea.Model, tea.Cmd) {
+	total := m.display.Track.DurationSecs
+	elapsed := time.Since(m.previewStart)
+	if total > 0 {
+		elapsed %= time.Duration(total) * time.Second
 	}
 
-	m.positionSecs = pos
+	m.clock.set(elapsed.Microseconds(), true)
 
-	lineChanged := m.updateLyricIndex(pos)
-	m.animState.Update(m.tickCount, lineChanged, 8)
+	lineChanged := m.updateLyricIndex(m.position())
+	m.countListening()
+	m.animState.Update(m.tickCount, lineChanged)
 
-	return m, tickCmd()
+	return m, m.nextTick()
 }
 
-func fetchArtworkCmd(artworkURL string) tea.Cmd {
+func fetchArtworkCmd(artworkURL string, cachePolicy lyrics.CachePolicy) tea.Cmd {
 	return func() tea.Msg {
 		img, err := artwork.Fetch(artworkURL)
 		if err != nil {
+			slog.Info("artwork fetch failed", "url", artworkURL, "err", err)
 			return ArtworkFetchedMsg{Err: err}
 		}
-		palette := artwork.ExtractPalette(img)
+		// extraction is slow and the same for every play of the artwork
+		diskCache := cache.GetGlobalCache()
+		var palette *artwork.Palette
+		if cachePolicy.Reads() {
+			palette, _ = diskCache.GetPalette(artworkURL)
+		}
+		if palette == nil {
+			start := time.Now()
+			palette = artwork.ExtractPalette(img)
+			if cachePolicy.Writes() {
+				_ = diskCache.SetPalette(artworkURL, palette)
+			}
+			slog.Debug("palette extracted", "url", artworkURL, "took", time.Since(start).Round(time.Millisecond))
+		} else {
+			slog.Debug("palette cache hit", "url", artworkURL)
+		}
 		return ArtworkFetchedMsg{
 			Image:   img,
 			Palette: palette,
@@ -254,17 +1224,34 @@ func fetchArtworkCmd(artworkURL string) tea.Cmd {
 	}
 }
 
-func fetchLyricsCmd(lrclibURL string, trk *track.Info) tea.Cmd {
-	return func() tea.Msg {
+// fetchLyricsCmd fetches the lyrics of the current track, reporting each
+// search attempt while it runs. skipCache searches again and replaces the
+// cached entry.
+func (m *Model) fetchLyricsCmd(skipCache bool) tea.Cmd {
+	updates := make(chan lyrics.Progress, 1)
+	m.fetchUpdates = updates
+	m.fetchProgress = lyrics.Progress{}
+
+	trk := m.display.Track
+	search := m.lyricsTrack()
+	lrclibURL := m.lrclibURL
+	cachePolicy := m.cachePolicy
+	fetch := func() tea.Msg {
+		defer close(updates)
+
 		if trk == nil {
 			return LyricsFetchedMsg{Err: errors.New("nil track")}
 		}
 
-		params := &lyrics.TrackParams{
-			Title:        trk.Title,
-			Artist:       trk.Artist,
-			Album:        trk.Album,
-			DurationSecs: trk.DurationSecs,
+		params := lyricsParams(search)
+		params.SkipCache = skipCache
+		params.Cache = cachePolicy
+		params.OnProgress = func(progress lyrics.Progress) {
+			// nobody listens once the track changed, progress can be dropped
+			select {
+			case updates <- progress:
+			default:
+			}
 		}
 
 		lyricsData, err := lyrics.Fetch(context.Background(), lrclibURL, params)
@@ -272,11 +1259,41 @@ func fetchLyricsCmd(lrclibURL string, trk *track.Info) tea.Cmd {
 			return LyricsFetchedMsg{Err: err}
 		}
 
+		// lyrics found under corrected search terms are stored for the
+		// track itself too, so they are there the next time it plays
+		if search != trk && cachePolicy.Writes() {
+			diskCache := cache.GetGlobalCache()
+			if entry, err := diskCache.Get(search.Artist, search.Title); err == nil {
+				copied := *entry
+				_ = diskCache.Set(trk.Artist, trk.Title, &copied)
+			}
+		}
+
 		if lyricsData.SyncedLyrics == "" {
 			return LyricsFetchedMsg{Err: errors.New("no synced lyrics available")}
 		}
 
 		lines := lyrics.ParseSynced(lyricsData.SyncedLyrics)
-		return LyricsFetchedMsg{Lines: lines, SyncOffset: lyricsData.SyncOffset}
+		return LyricsFetchedMsg{
+			Lines:       lines,
+			SyncOffset:  lyricsData.SyncOffset,
+			Source:      lyricsData.Source,
+			Plain:       lyricsData.PlainLyrics,
+			Translation: lyricsData.Translation,
+		}
+	}
+
+	return tea.Batch(fetch, listenFetchProgress(updates))
+}
+
+// listenFetchProgress waits for the next search attempt, it stops once the
+// fetch is done
+func listenFetchProgress(updates <-chan lyrics.Progress) tea.Cmd {
+	return func() tea.Msg {
+		progress, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return LyricsProgressMsg{Progress: progress, updates: updates}
 	}
 }
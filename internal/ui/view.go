@@ -2,16 +2,25 @@ package ui
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
 
 	"karolbroda.com/lyrecho/internal/artwork"
 	"karolbroda.com/lyrecho/internal/colors"
-	"karolbroda.com/lyrecho/internal/terminal"
+	"karolbroda.com/lyrecho/internal/logging"
+	"karolbroda.com/lyrecho/internal/lyrics"
+	"karolbroda.com/lyrecho/internal/player"
 )
 
 func (m Model) View() string {
+	if logging.Debugging() && !m.preview {
+		defer frameTiming.record(time.Now())
+	}
+
 	width := m.width
 	height := m.height
 	if width == 0 {
@@ -25,16 +34,309 @@ func (m Model) View() string {
 		return ""
 	}
 
-	palette := m.display.Palette
-	if palette == nil {
-		palette = artwork.DefaultPalette()
+	palette := m.shownPalette()
+
+	// the status bar takes the bottom row and the visualizer the rows above
+	// it, everything else fits above them
+	statusBar := m.statusBar && !m.oneLine && !m.preview && height > 2
+	if statusBar {
+		height--
+	}
+	visualizer := m.showVisualizer && m.levels != nil && !m.oneLine && height > visualizerRows*3
+	if visualizer {
+		height -= visualizerRows
+	}
+
+	var screen string
+	if m.oneLine {
+		screen = m.renderOneLine(palette, width, height)
+	} else if m.showOutgoing() {
+		screen = m.renderOutgoing(width, height)
+	} else if m.display.Track == nil {
+		screen = m.renderWaitingScreen(palette, width, height)
+	} else if level, idle := m.idleClockLevel(); idle {
+		screen = m.renderIdleClock(palette, width, height, level)
+	} else {
+		screen = m.renderMainScreen(palette, width, height)
+	}
+
+	if visualizer {
+		screen += "\n" + strings.Join(renderVisualizer(palette, m.levels.Levels(), width), "\n")
+		height += visualizerRows
+	}
+	if statusBar {
+		screen += "\n" + m.renderStatusBar(palette, width)
+		height++
+	}
+
+	if m.showUpNext && len(m.upNext) > 0 && m.display.Track != nil && !m.oneLine {
+		screen = overlayAboveLastLine(screen, m.renderUpNext(palette, width, height))
+	}
+
+	if m.prompting {
+		screen = overlayLastLine(screen, m.renderPrompt(palette))
+	} else if m.editingSearch {
+		screen = overlayLastLine(screen, m.renderSearchPrompt(palette, width))
+	} else if toast := m.activeToast(); toast != "" {
+		screen = overlayLastLine(screen, m.renderToast(palette, toast, width))
 	}
 
-	if m.display.Track == nil {
-		return m.renderWaitingScreen(palette, width, height)
+	// artwork placed by an earlier frame stays on screen until deleted
+	if m.termCaps != nil && !strings.Contains(screen, "\x1b_Ga=T") {
+		screen = m.termCaps.ClearKittyImage() + screen
 	}
 
-	return m.renderMainScreen(palette, width, height)
+	switch {
+	case m.backdrop && m.display.Image != nil:
+		screen = drawBackdrop(screen, m.display.Image, width, height, palette.Light)
+	case m.bgTint:
+		screen = fillBackground(screen, palette.BackgroundTint(), width)
+	}
+
+	return screen
+}
+
+// fillBackground paints every cell of the screen that has no background of
+// its own, by restarting the color after each style reset and padding lines
+// to the full width
+func fillBackground(screen string, color string, width int) string {
+	if color == "" {
+		return screen
+	}
+	seq := lipgloss.ColorProfile().Color(color).Sequence(true)
+	if seq == "" {
+		return screen
+	}
+	bg := "\x1b[" + seq + "m"
+
+	lines := strings.Split(screen, "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\x1b[0m", "\x1b[0m"+bg)
+		padding := max(width-lipgloss.Width(line), 0)
+		lines[i] = bg + line + strings.Repeat(" ", padding) + "\x1b[0m"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderIdleClock replaces the lyrics with a large clock and the track name
+// once playback has been paused for a while, fading in by level
+func (m Model) renderIdleClock(palette *artwork.Palette, width int, height int, level float64) string {
+	fade := func(color string) string {
+		return colors.BlendColors(palette.Base(), color, level)
+	}
+	faded := *palette
+	faded.Primary = fade(palette.Primary)
+	faded.Secondary = fade(palette.Secondary)
+	faded.Accent = fade(palette.Accent)
+	faded.Dim = fade(palette.Dim)
+
+	settled := AnimState{TransitionProgress: 1, CharReveal: 1}
+	renderer := NewTextRenderer(&faded, &settled, m.tickCount, width)
+	renderer.plain = m.plainText || m.miniMode()
+
+	block := renderer.RenderFocusLyric(time.Now().Format("15:04"))
+	if trk := m.display.Track; trk != nil {
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(faded.Secondary))
+		info := truncateText(trk.Artist+" - "+trk.Title, width-4)
+		block = append(block, "", centerText(style.Render(info), width))
+	}
+
+	lines := make([]string, height)
+	top := max((height-len(block))/2, 0)
+	for i, line := range block {
+		if top+i < height {
+			lines[top+i] = line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// visualizerRows is the height of the visualizer band
+const visualizerRows = 3
+
+// visualizerBlocks are the partial cells of a bar, by eighths
+var visualizerBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// renderVisualizer draws the audio levels as bars across the width, shaded
+// from the primary to the accent color
+func renderVisualizer(palette *artwork.Palette, levels []float64, width int) []string {
+	rows := make([]string, visualizerRows)
+	if len(levels) == 0 {
+		return rows
+	}
+
+	columns := max(width-4, 1)
+	var builders [visualizerRows]strings.Builder
+	for i := range builders {
+		builders[i].WriteString("  ")
+	}
+
+	for col := 0; col < columns; col++ {
+		level := levels[col*len(levels)/columns]
+		eighths := int(level * visualizerRows * 8)
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(
+			colors.BlendColors(palette.Primary, palette.Accent, float64(col)/float64(columns))))
+
+		for row := 0; row < visualizerRows; row++ {
+			// rows count from the bottom
+			fill := max(0, min(eighths-(visualizerRows-1-row)*8, 8))
+			builders[row].WriteString(style.Render(string(visualizerBlocks[fill])))
+		}
+	}
+
+	for i := range rows {
+		rows[i] = builders[i].String()
+	}
+	return rows
+}
+
+// renderStatusBar shows which player is followed and how, the sync offset
+// and where the lyrics came from
+func (m Model) renderStatusBar(palette *artwork.Palette, width int) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Accent))
+
+	var parts []string
+
+	switch {
+	case m.player == nil:
+		parts = append(parts, style.Render("no player"))
+	case m.playerErr != nil:
+		parts = append(parts, errStyle.Render("○ "+playerLabel(m.player.ServiceName())+" unreachable"))
+	default:
+		state := "playing"
+		if m.display.Track == nil {
+			state = "stopped"
+		} else if m.paused() {
+			state = "paused"
+		}
+		parts = append(parts, okStyle.Render("● "+playerLabel(m.player.ServiceName()))+style.Render(" "+state))
+	}
+
+	if m.syncOffset != 0 {
+		parts = append(parts, okStyle.Render(formatSyncOffset(m.syncOffset)))
+	}
+	if m.bpm > 0 {
+		parts = append(parts, style.Render(fmt.Sprintf("%.0f bpm", m.bpm)))
+	}
+
+	switch {
+	case m.loadingState.IsLoadingLyrics():
+		parts = append(parts, style.Render("fetching lyrics"))
+	case m.lyricsSource == lyrics.SourceCache:
+		parts = append(parts, style.Render("cache hit"))
+	case m.lyricsSource != "":
+		parts = append(parts, style.Render("cache miss · "+m.lyricsSource))
+	}
+
+	return lipgloss.NewStyle().MaxWidth(width).Render(" " + strings.Join(parts, style.Render(" │ ")))
+}
+
+// playerLabel shortens an mpris bus name to the player part
+func playerLabel(serviceName string) string {
+	return strings.TrimPrefix(serviceName, "org.mpris.MediaPlayer2.")
+}
+
+// renderPrompt shows the jump-to-time input with a block cursor
+func (m Model) renderPrompt(palette *artwork.Palette) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary))
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+
+	line := "  " + style.Render(":"+m.promptInput+"█")
+	if m.promptInput == "" {
+		line += hintStyle.Render(" jump to time, e.g. 1:23")
+	}
+	return line
+}
+
+// renderSearchPrompt shows the search terms being edited with a block cursor
+func (m Model) renderSearchPrompt(palette *artwork.Palette, width int) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary))
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+
+	label := hintStyle.Render("search: ")
+	input := truncateText(m.searchInput, max(width-12, 1))
+	return "  " + label + style.Render(input+"█")
+}
+
+// renderToast right-aligns a transient status message
+func (m Model) renderToast(palette *artwork.Palette, text string, width int) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	padding := max(width-lipgloss.Width(text)-2, 0)
+	return strings.Repeat(" ", padding) + style.Render(text)
+}
+
+// overlayLastLine replaces the bottom line of a rendered screen
+func overlayLastLine(screen string, line string) string {
+	idx := strings.LastIndex(screen, "\n")
+	if idx < 0 {
+		return line
+	}
+	return screen[:idx+1] + line
+}
+
+// renderUpNext lists the queued tracks, capped to leave most of the screen
+// to the lyrics
+func (m Model) renderUpNext(palette *artwork.Palette, width int, height int) []string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Accent)).Bold(true)
+	trackStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	artistStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
+
+	count := min(len(m.upNext), max(height/3-1, 1))
+	lines := []string{"  " + titleStyle.Render("up next")}
+	for i, trk := range m.upNext[:count] {
+		title := truncateText(trk.Title, max(width/2, 8))
+		artist := truncateText(trk.Artist, max(width-lipgloss.Width(title)-10, 4))
+		lines = append(lines, fmt.Sprintf("  %s %s %s",
+			artistStyle.Render(fmt.Sprintf("%d.", i+1)), trackStyle.Render(title), artistStyle.Render(artist)))
+	}
+	return lines
+}
+
+// overlayAboveLastLine replaces the lines just above the bottom one, keeping
+// the bottom line free for the prompt and status messages
+func overlayAboveLastLine(screen string, overlay []string) string {
+	lines := strings.Split(screen, "\n")
+	start := len(lines) - 1 - len(overlay)
+	for i, line := range overlay {
+		if start+i >= 0 {
+			lines[start+i] = line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderOneLine shows only the current lyric on the middle row, without the
+// header and animations, for tiny panes and dropdown terminals
+func (m Model) renderOneLine(palette *artwork.Palette, width int, height int) string {
+	lyricStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary)).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
+
+	trk := m.display.Track
+	text := ""
+	style := dimStyle
+	switch {
+	case trk == nil:
+		text = "awaiting music"
+	case m.err != nil:
+		text = m.err.Error()
+	case m.loadingState.IsLoadingLyrics():
+		text = "loading"
+	case m.display.CurrentIndex >= 0 && m.display.CurrentIndex < len(m.display.Lines) && !m.lyricsEnded():
+		text = strings.Join(strings.Fields(m.display.Lines[m.display.CurrentIndex].Text), " ")
+		if text == "" {
+			text = "···"
+		} else {
+			style = lyricStyle
+		}
+	default:
+		text = "♪ " + trk.Artist + " - " + trk.Title
+	}
+
+	lines := make([]string, height)
+	lines[height/2] = centerText(style.Render(truncateText(text, width-2)), width)
+	return strings.Join(lines, "\n")
 }
 
 func (m Model) renderWaitingScreen(palette *artwork.Palette, width int, height int) string {
@@ -48,13 +350,13 @@ func (m Model) renderWaitingScreen(palette *artwork.Palette, width int, height i
 			style := lipgloss.NewStyle().
 				Foreground(lipgloss.Color(palette.Dim)).
 				Italic(true)
-			centered := centerText(style.Render(waitText), len(waitText), width)
+			centered := centerText(style.Render(waitText), width)
 			lines = append(lines, centered)
 		} else if y == centerY {
 			pulseChars := []string{"·", "•", "●", "•"}
 			pulseIdx := (m.tickCount / 4) % len(pulseChars)
 			style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
-			lines = append(lines, centerText(style.Render(pulseChars[pulseIdx]), 1, width))
+			lines = append(lines, centerText(style.Render(pulseChars[pulseIdx]), width))
 		} else {
 			lines = append(lines, "")
 		}
@@ -64,25 +366,21 @@ func (m Model) renderWaitingScreen(palette *artwork.Palette, width int, height i
 }
 
 func (m Model) renderMainScreen(palette *artwork.Palette, width int, height int) string {
+	if m.layout == LayoutSide && width >= sideLayoutMinWidth && height >= sideLayoutMinHeight {
+		return m.renderSideLayout(palette, width, height)
+	}
+
 	var lines []string
 
 	headerHeight := 0
 
-	if !m.hideHeader {
+	if !m.hideHeader && !m.miniMode() {
 		headerLines := m.renderCompactHeader(palette, width)
 		lines = append(lines, headerLines...)
 		headerHeight = len(headerLines)
 	}
 
-	lyricsHeight := height - headerHeight
-
-	if m.err != nil {
-		lines = append(lines, m.renderErrorSection(palette, lyricsHeight, width)...)
-	} else if m.display.CurrentIndex >= 0 && m.display.CurrentIndex < len(m.display.Lines) {
-		lines = append(lines, m.renderSlidingLyrics(palette, lyricsHeight, width)...)
-	} else {
-		lines = append(lines, m.renderWaitingForLyrics(palette, lyricsHeight, width)...)
-	}
+	lines = append(lines, m.renderLyricsArea(palette, height-headerHeight, width)...)
 
 	for len(lines) < height {
 		lines = append(lines, "")
@@ -95,6 +393,142 @@ func (m Model) renderMainScreen(palette *artwork.Palette, width int, height int)
 	return strings.Join(lines, "\n")
 }
 
+// renderLyricsArea picks what fills the space below or beside the track info
+func (m Model) renderLyricsArea(palette *artwork.Palette, height int, width int) []string {
+	switch {
+	case m.err != nil:
+		return m.renderErrorSection(palette, height, width)
+	case m.browsing:
+		return m.renderBrowseList(palette, height, width)
+	case m.comparing:
+		return m.renderCompare(palette, height, width)
+	case m.layout == LayoutColumns && width >= columnsLayoutMinWidth && !m.sheet &&
+		m.display.CurrentIndex >= 0 && m.display.CurrentIndex < len(m.display.Lines):
+		return m.renderColumns(palette, height, width)
+	case (m.sheet || !m.sheetFollow) && len(m.display.Lines) > 0:
+		return m.renderLyricSheet(palette, height, width)
+	case m.lyricsEnded() && m.endBehavior != EndHold:
+		return m.renderLyricsEnd(palette, height, width)
+	case m.display.CurrentIndex >= 0 && m.display.CurrentIndex < len(m.display.Lines):
+		return m.renderSlidingLyrics(palette, height, width)
+	default:
+		return m.renderWaitingForLyrics(palette, height, width)
+	}
+}
+
+// columnsLayoutMinWidth is where each column still fits a readable line of
+// the pixel font, narrower terminals use the stacked layout
+const columnsLayoutMinWidth = 140
+
+// renderColumns shows the synced lyrics on the left and the whole sheet,
+// scrolling along with the playing line, on the right
+func (m Model) renderColumns(palette *artwork.Palette, height int, width int) []string {
+	leftWidth := width / 2
+	rightWidth := width - leftWidth
+
+	var left []string
+	if m.lyricsEnded() && m.endBehavior != EndHold {
+		left = m.renderLyricsEnd(palette, height, leftWidth)
+	} else {
+		left = m.renderSlidingLyrics(palette, height, leftWidth)
+	}
+	right := m.renderLyricSheet(palette, height, rightWidth)
+
+	lines := make([]string, height)
+	for i := range lines {
+		l := ""
+		if i < len(left) {
+			l = left[i]
+		}
+		r := ""
+		if i < len(right) {
+			r = right[i]
+		}
+		lines[i] = l + strings.Repeat(" ", max(leftWidth-lipgloss.Width(l), 0)) + r
+	}
+	return lines
+}
+
+// the side layout needs room for a useful panel next to the lyrics, smaller
+// terminals fall back to the stacked header
+const (
+	sideLayoutMinWidth  = 80
+	sideLayoutMinHeight = 16
+)
+
+// sideArtHeight sizes the side panel artwork. square art is twice as wide as
+// tall in cells, leaving rows for the info.
+func sideArtHeight(width int, height int) int {
+	return min((width*2/5-4)/2, height-9)
+}
+
+// renderSideLayout docks the artwork and track info to the left and lets the
+// lyrics use the remaining columns
+func (m Model) renderSideLayout(palette *artwork.Palette, width int, height int) string {
+	artHeight := sideArtHeight(width, height)
+	artWidth := artHeight * 2
+	panelWidth := artWidth + 4
+
+	panel := m.renderArtPanel(palette, artWidth, artHeight, panelWidth)
+	lyricsLines := m.renderLyricsArea(palette, height, width-panelWidth)
+
+	lines := make([]string, height)
+	for i := range lines {
+		left := ""
+		if i < len(panel) {
+			left = panel[i]
+		}
+		right := ""
+		if i < len(lyricsLines) {
+			right = lyricsLines[i]
+		}
+		lines[i] = left + strings.Repeat(" ", max(panelWidth-lipgloss.Width(left), 0)) + right
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderArtPanel stacks the artwork, track info and progress in a column
+func (m Model) renderArtPanel(palette *artwork.Palette, artWidth int, artHeight int, panelWidth int) []string {
+	lines := []string{""}
+
+	img := m.artworkImage()
+	useKittyGraphics := m.termCaps != nil && m.termCaps.SupportsKittyGraphics && img != nil
+	kittyImageOutput := ""
+	if useKittyGraphics {
+		kittyImageOutput = m.termCaps.EncodeImageForKittyInPlace(img, artWidth, artHeight)
+	}
+
+	if kittyImageOutput != "" {
+		// the image keeps the cursor in place, spaces step over it
+		lines = append(lines, "  "+kittyImageOutput+strings.Repeat(" ", artWidth))
+		for i := 0; i < artHeight-1; i++ {
+			lines = append(lines, "")
+		}
+	} else {
+		artworkLines := artwork.RenderHalfBlockArt(img, artWidth, artHeight)
+		for i := 0; i < artHeight; i++ {
+			if i < len(artworkLines) {
+				lines = append(lines, "  "+artworkLines[i])
+			} else {
+				lines = append(lines, "")
+			}
+		}
+	}
+
+	lines = append(lines, "")
+	for _, info := range m.renderTrackInfo(palette, artWidth+20) {
+		lines = append(lines, "  "+info)
+	}
+
+	// the progress bar needs 30 columns including the times
+	if trk := m.display.Track; trk != nil && trk.DurationSecs > 0 && panelWidth >= 30 {
+		lines = append(lines, "", m.renderMinimalProgress(palette, panelWidth))
+	}
+
+	return lines
+}
+
 func (m Model) renderCompactHeader(palette *artwork.Palette, width int) []string {
 	var lines []string
 
@@ -112,15 +546,16 @@ func (m Model) renderCompactHeader(palette *artwork.Palette, width int) []string
 	}
 
 	var artworkLines []string
-	useKittyGraphics := m.termCaps != nil && m.termCaps.SupportsKittyGraphics && artWidth > 0 && m.display.Image != nil
+	img := m.artworkImage()
+	useKittyGraphics := m.termCaps != nil && m.termCaps.SupportsKittyGraphics && artWidth > 0 && img != nil
 
 	if useKittyGraphics {
 		// use kitty graphics protocol
-		kittyImageOutput := terminal.EncodeImageForKitty(m.display.Image, artWidth, artHeight)
+		kittyImageOutput := m.termCaps.EncodeImageForKitty(img, artWidth, artHeight)
 		if kittyImageOutput == "" {
 			// fallback to half-block rendering if encoding fails
 			useKittyGraphics = false
-			artworkLines = artwork.RenderHalfBlockArt(m.display.Image, artWidth, artHeight)
+			artworkLines = artwork.RenderHalfBlockArt(img, artWidth, artHeight)
 		} else {
 			// output kitty image with proper indentation
 			lines = append(lines, "  "+kittyImageOutput)
@@ -130,7 +565,7 @@ func (m Model) renderCompactHeader(palette *artwork.Palette, width int) []string
 			}
 		}
 	} else {
-		artworkLines = artwork.RenderHalfBlockArt(m.display.Image, artWidth, artHeight)
+		artworkLines = artwork.RenderHalfBlockArt(img, artWidth, artHeight)
 	}
 
 	infoLines := m.renderTrackInfo(palette, width)
@@ -203,25 +638,16 @@ func (m Model) renderTrackInfo(palette *artwork.Palette, width int) []string {
 		maxWidth = 20
 	}
 
-	title := trk.Title
-	if len(title) > maxWidth {
-		title = title[:maxWidth-1] + "…"
-	}
+	title := truncateText(trk.Title, maxWidth)
 	lines = append(lines, titleStyle.Render(title))
 
-	artist := trk.Artist
-	if len(artist) > maxWidth {
-		artist = artist[:maxWidth-1] + "…"
-	}
+	artist := truncateText(trk.Artist, maxWidth)
 	lines = append(lines, artistStyle.Render(artist))
 
 	if trk.Album != "" {
 		albumStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color(palette.Dim))
-		album := trk.Album
-		if len(album) > maxWidth {
-			album = album[:maxWidth-1] + "…"
-		}
+		album := truncateText(trk.Album, maxWidth)
 		lines = append(lines, albumStyle.Render(album))
 	}
 
@@ -234,12 +660,14 @@ func (m Model) renderMinimalProgress(palette *artwork.Palette, width int) string
 		return ""
 	}
 
-	barWidth := width - 20
-	if barWidth < 20 {
-		barWidth = 20
+	indicators := m.renderOptionIndicators(palette)
+
+	barWidth := width - 20 - lipgloss.Width(indicators)
+	if barWidth < 10 {
+		barWidth = 10
 	}
 
-	progress := float64(m.positionSecs) / float64(trk.DurationSecs)
+	progress := m.position() / float64(trk.DurationSecs)
 	if progress > 1.0 {
 		progress = 1.0
 	}
@@ -264,19 +692,55 @@ func (m Model) renderMinimalProgress(palette *artwork.Palette, width int) string
 		}
 	}
 
-	currentTime := colors.FormatTime(m.positionSecs)
+	currentTime := colors.FormatTime(int64(m.position()))
 	totalTime := colors.FormatTime(trk.DurationSecs)
 
 	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
 
-	return fmt.Sprintf("  %s  %s  %s",
+	return fmt.Sprintf("  %s  %s  %s%s",
 		timeStyle.Render(currentTime),
 		bar.String(),
-		timeStyle.Render(totalTime))
+		timeStyle.Render(totalTime),
+		indicators)
+}
+
+// renderOptionIndicators shows pause, shuffle and loop when they are on
+func (m Model) renderOptionIndicators(palette *artwork.Palette) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+
+	var indicators []string
+	if m.paused() {
+		indicators = append(indicators, "⏸")
+	}
+	if m.shuffle {
+		indicators = append(indicators, "⤮")
+	}
+	switch m.loop {
+	case player.LoopPlaylist:
+		indicators = append(indicators, "↻")
+	case player.LoopTrack:
+		indicators = append(indicators, "↻1")
+	}
+
+	if len(indicators) == 0 {
+		return ""
+	}
+	return "  " + style.Render(strings.Join(indicators, " "))
 }
 
 func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width int) []string {
+	if m.paused() {
+		palette = pausedPalette(palette)
+	}
 	renderer := NewTextRenderer(palette, &m.animState, m.tickCount, width)
+	renderer.plain = m.plainText || m.miniMode()
+
+	// chorus lines are drawn in the accent color
+	chorusRenderer := renderer
+	if m.sections {
+		chorusRenderer = NewTextRenderer(chorusPalette(palette), &m.animState, m.tickCount, width)
+		chorusRenderer.plain = renderer.plain
+	}
 
 	slideT := m.animState.SlideOffset()
 
@@ -285,16 +749,24 @@ func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width i
 		output[i] = ""
 	}
 
-	contextCount := 2
-	if height < 20 {
+	contextCount := autoContextLines(height)
+	if m.miniMode() {
 		contextCount = 1
 	}
+	if m.contextLines >= 0 {
+		contextCount = m.contextLines
+		if m.miniMode() {
+			contextCount = min(contextCount, 1)
+		}
+	}
 
 	type renderedLyric struct {
 		lines      []string
 		offset     int
 		isFocus    bool
 		brightness float64
+		// render draws a context line again, for the half row shift
+		render func() []string
 	}
 
 	var allLyrics []renderedLyric
@@ -304,8 +776,14 @@ func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width i
 		if idx < 0 || idx >= len(m.display.Lines) {
 			continue
 		}
+		// with a set count the lines past it only show while sliding away
+		outside := offset < -contextCount || offset > contextCount
+		if m.contextLines >= 0 && outside && slideT >= 1 {
+			continue
+		}
 
-		text := m.display.Lines[idx].Text
+		line := m.display.Lines[idx]
+		text := line.Text
 		if text == "" {
 			text = "···"
 		}
@@ -334,12 +812,29 @@ func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width i
 			isFocus = false
 		}
 
+		lineRenderer := renderer
+		if section, ok := lyrics.SectionAt(m.display.sections, idx); ok && section.Kind == lyrics.SectionChorus {
+			lineRenderer = chorusRenderer
+		}
+
+		stamp := func(rendered []string) []string {
+			if m.timestamps && len(rendered) > 0 {
+				rendered[0] = stampLine(rendered[0], lineStamp(line.TimeSeconds), palette)
+			}
+			return rendered
+		}
+
 		var rendered []string
+		var render func() []string
 		if isFocus {
-			rendered = renderer.RenderFocusLyric(text)
+			position := m.position() + m.lyricOffset()
+			rendered = stamp(lineRenderer.RenderFocusLyricTimed(text, m.focusSungRunes(idx, position)))
 		} else {
 			isPast := offset < 0
-			rendered = renderer.RenderContextLyric(text, brightness, isPast)
+			render = func() []string {
+				return stamp(lineRenderer.RenderContextLyric(text, brightness, isPast))
+			}
+			rendered = render()
 		}
 
 		allLyrics = append(allLyrics, renderedLyric{
@@ -347,6 +842,7 @@ func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width i
 			offset:     offset,
 			isFocus:    isFocus,
 			brightness: brightness,
+			render:     render,
 		})
 	}
 
@@ -366,6 +862,9 @@ func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width i
 	}
 
 	spacing := 2
+	if renderer.plain {
+		spacing = 1
+	}
 	slideAmount := float64(currentLyricHeight + spacing)
 
 	positions := make([]int, len(allLyrics))
@@ -383,7 +882,36 @@ func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width i
 		y += len(allLyrics[i].lines) + spacing
 	}
 
-	slideOffset := int(slideT * slideAmount)
+	// the slide moves in half rows: on odd steps everything sits a row
+	// higher and the context lines are drawn half a row lower inside it
+	halfRows := int(slideT * slideAmount * 2)
+	slideOffset := (halfRows + 1) / 2
+	if halfRows%2 == 1 {
+		renderer.halfRow = true
+		chorusRenderer.halfRow = true
+		for i := range allLyrics {
+			if allLyrics[i].render != nil {
+				allLyrics[i].lines = allLyrics[i].render()
+			}
+		}
+	}
+	focusY := positions[currentLyricIdx] - slideOffset
+
+	// the section name sits in the spacing right above the current line
+	if m.sections && slideT >= 1 && focusY > 0 {
+		if section, ok := lyrics.SectionAt(m.display.sections, m.display.CurrentIndex); ok {
+			style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+			output[focusY-1] = centerText(style.Render(section.Label()), width)
+		}
+	}
+
+	// the countdown sits in the spacing right under the current line
+	if remaining, ok := m.gapCountdown(); ok && slideT >= 1 {
+		row := focusY + currentLyricHeight
+		if row >= 0 && row < height {
+			output[row] = centerText(m.renderGapCountdown(palette, remaining), width)
+		}
+	}
 
 	for pass := 0; pass < 2; pass++ {
 		for i, rl := range allLyrics {
@@ -410,6 +938,141 @@ func (m Model) renderSlidingLyrics(palette *artwork.Palette, height int, width i
 	return output
 }
 
+// maxContextLines is as many lines around the current one as the keys add
+const maxContextLines = 6
+
+// autoContextLines is how many lines show around the current one when the
+// count is left to the terminal height
+func autoContextLines(height int) int {
+	if height < 20 {
+		return 1
+	}
+	return 2
+}
+
+// chorusPalette tints the lyric colors towards the accent color
+func chorusPalette(palette *artwork.Palette) *artwork.Palette {
+	tinted := *palette
+	tinted.Primary = colors.BlendColors(palette.Primary, palette.Accent, 0.6)
+	tinted.Secondary = colors.BlendColors(palette.Secondary, palette.Accent, 0.6)
+	return &tinted
+}
+
+// lineStamp formats a line's start time for the timestamp margin
+func lineStamp(seconds float64) string {
+	total := max(int(seconds), 0)
+	return fmt.Sprintf("[%02d:%02d]", total/60, total%60)
+}
+
+// stampLine puts a timestamp in the left margin of a centered lyric row,
+// leaving rows too wide to have room for it as they are
+func stampLine(line string, stamp string, palette *artwork.Palette) string {
+	margin := len(line) - len(strings.TrimLeft(line, " "))
+	if margin < len(stamp)+2 {
+		return line
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+	return " " + style.Render(stamp) + line[len(stamp)+1:]
+}
+
+// pausedPalette mutes the lyric colors, so a paused track doesn't look like
+// it is still playing
+func pausedPalette(palette *artwork.Palette) *artwork.Palette {
+	muted := *palette
+	muted.Primary = colors.BlendColors(palette.Primary, palette.Dim, 0.6)
+	muted.Accent = colors.BlendColors(palette.Accent, palette.Dim, 0.6)
+	return &muted
+}
+
+// focusSungRunes is how much of the focus line to light: word timing when the
+// line has it, and in karaoke mode a sweep that reaches the end of the line as
+// the next one starts
+func (m Model) focusSungRunes(index int, position float64) float64 {
+	line := m.display.Lines[index]
+	if !m.karaoke || line.Text == "" {
+		return float64(line.SungRunes(position))
+	}
+
+	end := line.TimeSeconds + lastLineHoldSeconds
+	if index+1 < len(m.display.Lines) {
+		end = m.display.Lines[index+1].TimeSeconds
+	}
+	return line.SweptRunes(position, end)
+}
+
+const (
+	// instrumentalGapSeconds is how long a gap between lines has to be for
+	// the countdown to show
+	instrumentalGapSeconds = 8.0
+	// gapSettleSeconds leaves a sung line alone for a while before the
+	// countdown joins it
+	gapSettleSeconds = 4.0
+)
+
+// gapCountdown returns the seconds until the next line during intros and
+// long instrumental breaks
+func (m Model) gapCountdown() (float64, bool) {
+	lines := m.display.Lines
+	if len(lines) == 0 || m.lyricsEnded() {
+		return 0, false
+	}
+
+	position := m.position() + m.lyricOffset()
+	current := m.display.CurrentIndex
+	next := current + 1
+	start := 0.0
+	switch {
+	case current < 0:
+		next = 0
+	case position < lines[current].TimeSeconds:
+		// the intro, the first line already shows as the current one
+		next = current
+	default:
+		line := lines[current]
+		start = line.TimeSeconds
+		if line.Text != "" && position-start < gapSettleSeconds {
+			return 0, false
+		}
+	}
+	if next >= len(lines) {
+		return 0, false
+	}
+
+	remaining := lines[next].TimeSeconds - position
+	if lines[next].TimeSeconds-start < instrumentalGapSeconds || remaining <= 0 {
+		return 0, false
+	}
+
+	// the countdown is in real seconds, not track time
+	if m.clock.rate > 0 {
+		remaining /= m.clock.rate
+	}
+	return remaining, true
+}
+
+// renderGapCountdown draws "♪ · · · 12s" with a dot lighting up in turn, so
+// a long break doesn't look frozen
+func (m Model) renderGapCountdown(palette *artwork.Palette, remaining float64) string {
+	noteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
+	litStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary))
+
+	// one step per second at the normal tick rate
+	lit := (m.tickCount / 10) % 3
+
+	dots := make([]string, 3)
+	for i := range dots {
+		if i == lit {
+			dots[i] = litStyle.Render("·")
+		} else {
+			dots[i] = dimStyle.Render("·")
+		}
+	}
+
+	return noteStyle.Render("♪") + " " + strings.Join(dots, " ") + " " +
+		dimStyle.Render(fmt.Sprintf("%ds", int(math.Ceil(remaining))))
+}
+
 func (m Model) renderErrorSection(palette *artwork.Palette, height int, width int) []string {
 	lines := make([]string, 0, height)
 
@@ -421,7 +1084,13 @@ func (m Model) renderErrorSection(palette *artwork.Palette, height int, width in
 		Foreground(lipgloss.Color("#FF6B6B"))
 
 	errText := m.err.Error()
-	lines = append(lines, centerText(errStyle.Render(errText), len(errText), width))
+	lines = append(lines, centerText(errStyle.Render(errText), width))
+
+	if m.display.Track != nil {
+		hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+		hint := "r retry · R skip the cache · e edit search"
+		lines = append(lines, "", centerText(hintStyle.Render(truncateText(hint, width-4)), width))
+	}
 
 	return lines
 }
@@ -439,22 +1108,371 @@ func (m Model) renderWaitingForLyrics(palette *artwork.Palette, height int, widt
 		spinnerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
 		textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
 		msgText := spinnerStyle.Render(frames[idx]) + textStyle.Render(" loading")
-		lines = append(lines, centerText(msgText, 10, width))
-	} else if m.display.CurrentIndex >= len(m.display.Lines) {
+		lines = append(lines, centerText(msgText, width))
+		if progress := m.fetchProgress; progress.Total > 0 {
+			status := fmt.Sprintf("searching %s (%d/%d)…", progress.Provider, progress.Attempt, progress.Total)
+			lines = append(lines, "", centerText(textStyle.Render(truncateText(status, width-4)), width))
+		}
+	} else if m.display.CurrentIndex >= len(m.display.Lines) || m.lyricsEnded() {
 		style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
-		lines = append(lines, centerText(style.Render("·"), 1, width))
+		lines = append(lines, centerText(style.Render("·"), width))
+	} else if remaining, ok := m.gapCountdown(); ok {
+		lines = append(lines, centerText(m.renderGapCountdown(palette, remaining), width))
 	} else {
 		style := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
-		lines = append(lines, centerText(style.Render("♪"), 1, width))
+		lines = append(lines, centerText(style.Render("♪"), width))
+	}
+
+	return lines
+}
+
+func (m Model) renderLyricsEnd(palette *artwork.Palette, height int, width int) []string {
+	switch m.endBehavior {
+	case EndOutro:
+		return m.renderOutroCard(palette, height, width)
+	case EndScroll:
+		return m.renderLyricsScroll(palette, height, width)
+	default:
+		return m.renderWaitingForLyrics(palette, height, width)
+	}
+}
+
+func (m Model) renderOutroCard(palette *artwork.Palette, height int, width int) []string {
+	trk := m.display.Track
+	if trk == nil {
+		return m.renderWaitingForLyrics(palette, height, width)
+	}
+
+	noteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary)).Bold(true)
+	artistStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Italic(true)
+
+	card := []string{
+		centerText(noteStyle.Render("♪"), width),
+		"",
+		centerText(titleStyle.Render(truncateText(trk.Title, width-4)), width),
+		centerText(artistStyle.Render(truncateText(trk.Artist, width-4)), width),
+		"",
+		centerText(dimStyle.Render("end of lyrics"), width),
+	}
+
+	lines := make([]string, 0, height)
+	for i := 0; i < (height-len(card))/2; i++ {
+		lines = append(lines, "")
 	}
+	lines = append(lines, card...)
 
 	return lines
 }
 
-func centerText(text string, visualWidth int, screenWidth int) string {
-	padding := (screenWidth - visualWidth) / 2
+// renderLyricsScroll loops the whole lyric sheet upwards like end credits
+func (m Model) renderLyricsScroll(palette *artwork.Palette, height int, width int) []string {
+	var sheet []string
+	for _, line := range m.display.Lines {
+		sheet = append(sheet, wrapText(line.Text, max(width-8, 5), uniseg.StringWidth)...)
+		sheet = append(sheet, "")
+	}
+
+	output := make([]string, height)
+	if len(sheet) == 0 || height == 0 {
+		return output
+	}
+
+	// the sheet enters from the bottom edge and leaves through the top
+	cycle := len(sheet) + height
+	offset := (m.tickCount / 6) % cycle
+
+	for row := 0; row < height; row++ {
+		sheetIdx := offset - height + row
+		if sheetIdx < 0 || sheetIdx >= len(sheet) || sheet[sheetIdx] == "" {
+			continue
+		}
+
+		// fade towards the edges, brightest in the middle
+		dist := float64(row-height/2) / float64(height/2+1)
+		if dist < 0 {
+			dist = -dist
+		}
+		color := colors.BlendColors(palette.Primary, palette.Dim, clamp(dist*1.2, 0, 1))
+
+		text := sheet[sheetIdx]
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+		output[row] = centerText(style.Render(text), width)
+	}
+
+	return output
+}
+
+// sheetRow is one screen row of the lyric sheet, lines with breaks span
+// several rows
+type sheetRow struct {
+	text      string
+	lineIndex int
+	first     bool
+}
+
+// lyricRows splits the lyrics into rows no wider than maxWidth and returns
+// the first row of the given line
+func (m Model) lyricRows(lineIndex int, maxWidth int) ([]sheetRow, int) {
+	var rows []sheetRow
+	targetRow := 0
+	for i, line := range m.display.Lines {
+		text := line.Text
+		if text == "" {
+			text = "···"
+		}
+		if i == lineIndex {
+			targetRow = len(rows)
+		}
+		parts := wrapText(text, max(maxWidth, 5), uniseg.StringWidth)
+		if len(parts) == 0 {
+			parts = []string{""}
+		}
+		for j, part := range parts {
+			rows = append(rows, sheetRow{text: part, lineIndex: i, first: j == 0})
+		}
+	}
+	return rows, targetRow
+}
+
+// renderCompare puts the synced lyrics and the plain text of the same entry
+// side by side, both scrolled to about the playing line
+func (m Model) renderCompare(palette *artwork.Palette, height int, width int) []string {
+	output := make([]string, height)
+	if height < 4 {
+		return output
+	}
+
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Accent)).Bold(true)
+	currentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary)).Bold(true)
+	textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
+
+	columnWidth := (width - 6) / 2
+	plain := lyrics.PlainLines(m.plainLyrics)
+
+	current := max(m.display.CurrentIndex, 0)
+	if m.usingPlain && len(m.syncedLines) > 0 && len(m.display.Lines) > 0 {
+		// the index is into the plain lines, find the synced one at that time
+		at := m.display.Lines[min(current, len(m.display.Lines)-1)].TimeSeconds
+		current = max(lyrics.FindCurrentLineIndex(m.syncedLines, at), 0)
+	}
+	// the plain text has no timing, scroll it along proportionally
+	plainCurrent := 0
+	if len(m.syncedLines) > 0 {
+		plainCurrent = current * len(plain) / len(m.syncedLines)
+	}
+
+	synced := "synced"
+	plainTitle := "plain"
+	if m.usingPlain {
+		plainTitle += " (shown)"
+	} else {
+		synced += " (shown)"
+	}
+	output[0] = "  " + titleStyle.Render(fmt.Sprintf("%-*s", columnWidth, synced)) + "  " + titleStyle.Render(plainTitle)
+
+	// the first two rows hold the titles, the last one the key hints
+	listHeight := height - 3
+	for y := 0; y < listHeight; y++ {
+		left := ""
+		if i := current - listHeight/2 + y; i >= 0 && i < len(m.syncedLines) {
+			text := m.syncedLines[i].Text
+			if text == "" {
+				text = "···"
+			}
+			style := textStyle
+			if i == current {
+				style = currentStyle
+			}
+			left = style.Render(truncateText(text, columnWidth))
+		}
+
+		right := ""
+		if i := plainCurrent - listHeight/2 + y; i >= 0 && i < len(plain) {
+			style := dimStyle
+			if i == plainCurrent {
+				style = textStyle
+			}
+			right = style.Render(truncateText(plain[i], columnWidth))
+		}
+
+		output[y+2] = "  " + left + strings.Repeat(" ", max(columnWidth-lipgloss.Width(left), 0)) + "  " + right
+	}
+
+	hint := "enter show plain · esc back"
+	if m.usingPlain {
+		hint = "enter show synced · esc back"
+	}
+	output[height-1] = centerText(dimStyle.Render(hint), width)
+
+	return output
+}
+
+// renderBrowseList shows the lyric sheet as a plain list with the selected
+// line kept in the middle of the screen
+func (m Model) renderBrowseList(palette *artwork.Palette, height int, width int) []string {
+	// time stamp, marker and margins take 13 columns
+	rows, selectedRow := m.lyricRows(m.browseIndex, width-13)
+
+	output := make([]string, height)
+	if height < 3 {
+		return output
+	}
+
+	// the last row is reserved for the key hints
+	listHeight := height - 1
+	start := selectedRow - listHeight/2
+
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary)).Bold(true)
+	currentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	otherStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
+	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+
+	for y := 0; y < listHeight; y++ {
+		rowIdx := start + y
+		if rowIdx < 0 || rowIdx >= len(rows) {
+			continue
+		}
+		row := rows[rowIdx]
+
+		marker := "  "
+		style := otherStyle
+		switch {
+		case row.lineIndex == m.browseIndex:
+			style = selectedStyle
+			if row.first {
+				marker = "› "
+			}
+		case row.lineIndex == m.display.CurrentIndex:
+			style = currentStyle
+		}
+
+		stamp := "     "
+		if row.first {
+			stamp = fmt.Sprintf("%5s", colors.FormatTime(int64(m.display.Lines[row.lineIndex].TimeSeconds)))
+		}
+
+		output[y] = "  " + timeStyle.Render(stamp) + "  " + style.Render(marker+row.text)
+	}
+
+	hint := "↑/↓ select · enter seek · esc back"
+	output[height-1] = centerText(otherStyle.Render(hint), width)
+
+	return output
+}
+
+// renderLyricSheet shows the whole lyric sheet around sheetLine, marking the
+// line that is playing
+func (m Model) renderLyricSheet(palette *artwork.Palette, height int, width int) []string {
+	// indent, marker and margin take 8 columns, timestamps another 8
+	textWidth := width - 8
+	if m.timestamps {
+		textWidth -= 8
+	}
+	// room for the section names after the first row of each section
+	if m.sections {
+		textWidth -= 10
+	}
+	rows, centerRow := m.lyricRows(m.sheetLine, textWidth)
+
+	output := make([]string, height)
+	if height < 3 {
+		return output
+	}
+
+	// the last row is reserved for the key hints
+	listHeight := height - 1
+	start := centerRow - listHeight/2
+
+	currentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Primary)).Bold(true)
+	upcomingStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Secondary))
+	pastStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim))
+	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(palette.Dim)).Faint(true)
+
+	for y := 0; y < listHeight; y++ {
+		rowIdx := start + y
+		if rowIdx < 0 || rowIdx >= len(rows) {
+			continue
+		}
+		row := rows[rowIdx]
+
+		marker := "  "
+		style := upcomingStyle
+		switch {
+		case row.lineIndex == m.display.CurrentIndex:
+			style = currentStyle
+			if row.first {
+				marker = "› "
+			}
+		case row.lineIndex < m.display.CurrentIndex:
+			style = pastStyle
+		}
+
+		label := ""
+		if m.sections {
+			section, ok := lyrics.SectionAt(m.display.sections, row.lineIndex)
+			if ok && section.Kind == lyrics.SectionChorus && row.lineIndex != m.display.CurrentIndex {
+				style = style.Foreground(lipgloss.Color(palette.Accent))
+			}
+			if ok && row.first && section.Start == row.lineIndex {
+				label = pastStyle.Render("  " + section.Label())
+			}
+		}
+
+		indent := "    "
+		if m.timestamps {
+			stamp := "       "
+			if row.first {
+				stamp = lineStamp(m.display.Lines[row.lineIndex].TimeSeconds)
+			}
+			indent = "  " + timeStyle.Render(stamp) + "  "
+		}
+		output[y] = indent + style.Render(marker+row.text) + label
+	}
+
+	hint := "↑/↓ scroll · esc back"
+	switch {
+	case !m.sheet:
+		hint = "pgup/pgdn scroll · f follow"
+	case !m.sheetFollow:
+		hint = "↑/↓ scroll · f follow · esc back"
+	}
+	output[height-1] = centerText(hintStyle.Render(hint), width)
+
+	return output
+}
+
+// centerText pads text to the middle of the screen, measuring its display
+// width so styled and double-width text center correctly
+func centerText(text string, screenWidth int) string {
+	padding := (screenWidth - lipgloss.Width(text)) / 2
 	if padding < 0 {
 		padding = 0
 	}
 	return strings.Repeat(" ", padding) + text
 }
+
+// truncateText shortens text to maxWidth columns with a trailing ellipsis,
+// cutting between grapheme clusters so accents and emoji stay whole
+func truncateText(text string, maxWidth int) string {
+	if uniseg.StringWidth(text) <= maxWidth {
+		return text
+	}
+
+	var kept strings.Builder
+	width := 0
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		clusterWidth := graphemes.Width()
+		if width+clusterWidth > maxWidth-1 {
+			break
+		}
+		kept.WriteString(graphemes.Str())
+		width += clusterWidth
+	}
+
+	return kept.String() + "…"
+}
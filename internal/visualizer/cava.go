@@ -0,0 +1,119 @@
+package visualizer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// Bars is how many frequency bands cava reports, the view resamples
+	// them to its width
+	Bars = 64
+
+	// cava scales each bar from 0 to maxRange
+	maxRange = 1000
+)
+
+// cavaConfig makes cava print one line of ascii bar heights per frame
+const cavaConfig = `[general]
+bars = %d
+framerate = 30
+
+[output]
+method = raw
+raw_target = /dev/stdout
+data_format = ascii
+ascii_max_range = %d
+bar_delimiter = 59
+frame_delimiter = 10
+`
+
+// Cava reads spectrum levels from a cava process, which captures whatever
+// the system is playing through pulseaudio or pipewire
+type Cava struct {
+	cmd        *exec.Cmd
+	configPath string
+
+	mu     sync.RWMutex
+	levels []float64
+}
+
+// StartCava runs cava in the background with its own config
+func StartCava() (*Cava, error) {
+	path, err := exec.LookPath("cava")
+	if err != nil {
+		return nil, errors.New("cava not found, install it to use the visualizer")
+	}
+
+	configFile, err := os.CreateTemp("", "lyrecho-cava-*.conf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write cava config: %w", err)
+	}
+	_, err = fmt.Fprintf(configFile, cavaConfig, Bars, maxRange)
+	configFile.Close()
+	if err != nil {
+		os.Remove(configFile.Name())
+		return nil, fmt.Errorf("failed to write cava config: %w", err)
+	}
+
+	cmd := exec.Command(path, "-p", configFile.Name())
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.Remove(configFile.Name())
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		os.Remove(configFile.Name())
+		return nil, fmt.Errorf("failed to start cava: %w", err)
+	}
+
+	c := &Cava{cmd: cmd, configPath: configFile.Name()}
+	go c.read(stdout)
+
+	return c, nil
+}
+
+func (c *Cava) read(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSuffix(scanner.Text(), ";"), ";")
+		levels := make([]float64, 0, len(fields))
+		for _, field := range fields {
+			value, err := strconv.Atoi(field)
+			if err != nil {
+				continue
+			}
+			levels = append(levels, min(float64(value)/maxRange, 1))
+		}
+
+		c.mu.Lock()
+		c.levels = levels
+		c.mu.Unlock()
+	}
+}
+
+// Levels returns the latest bar heights from 0 to 1, nil before the first
+// frame
+func (c *Cava) Levels() []float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.levels
+}
+
+// Stop ends the cava process and removes its config
+func (c *Cava) Stop() {
+	if c.cmd.Process != nil {
+		_ = c.cmd.Process.Kill()
+		_ = c.cmd.Wait()
+	}
+	os.Remove(c.configPath)
+}